@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/abi"
+)
+
+func main() {
+	metadataPath := flag.String("metadata", "", "path to the ink! metadata.json or .contract file")
+	out := flag.String("out", "", "output file, stdout by default")
+	pkgName := flag.String("pkg", "", "package name of the generated bindings")
+	typeName := flag.String("type", "", "name of the generated contract type")
+	storageName := flag.String("storage", "", "contract storage struct name if it differs from the crate name")
+	flag.Parse()
+
+	if *metadataPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*metadataPath, *out, *pkgName, *typeName, *storageName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(metadataPath, out, pkgName, typeName, storageName string) error {
+	meta, err := abi.LoadMetadataFile(metadataPath)
+	if err != nil {
+		return err
+	}
+	if storageName != "" {
+		meta.SetStorageName(storageName)
+	}
+
+	source, err := abi.GenerateBindings(meta, abi.GenerateOptions{Package: pkgName, TypeName: typeName})
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+
+	return os.WriteFile(out, source, 0644)
+}
@@ -5,7 +5,6 @@ require (
 	github.com/decred/base58 v1.0.3
 	github.com/ethereum/go-ethereum v1.10.17
 	github.com/golang/groupcache v0.0.0-20191227052852-215e87163ea7
-	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.7.0
@@ -0,0 +1,369 @@
+package abi
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/scale"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DEFAULT_DEPLOY_GAS_LIMIT is scaled by pkg.CERE in pkg.BlockchainClient.Deploy.
+	DEFAULT_DEPLOY_GAS_LIMIT uint64 = 50
+)
+
+type (
+	// Contract is a generic ink! contract client which resolves messages and events by name from the contract metadata
+	// instead of hardcoded selectors and topics.
+	Contract interface {
+		GetContractAddress() string
+		GetMetadata() *Metadata
+
+		// Read dry-runs a message and returns its dynamically decoded result (see Registry.Decode).
+		Read(message string, args ...interface{}) (interface{}, error)
+		// ReadInto dry-runs a message and SCALE decodes its Ok value into result.
+		ReadInto(result interface{}, message string, args ...interface{}) error
+		Exec(ctx context.Context, keyPair signature.KeyringPair, message string, args ...interface{}) (types.Hash, error)
+		// ExecWithValue executes a payable message transferring value, in the smallest units, to the contract.
+		ExecWithValue(ctx context.Context, keyPair signature.KeyringPair, value uint64, message string, args ...interface{}) (types.Hash, error)
+		Deploy(ctx context.Context, keyPair signature.KeyringPair, code []byte, salt []byte, constructor string, args ...interface{}) (types.AccountID, error)
+
+		EncodeMessage(message string, args ...interface{}) ([]byte, error)
+		EncodeConstructor(constructor string, args ...interface{}) ([]byte, error)
+		DecodeEvent(data []byte) (*Event, error)
+		EventDispatcher(argumentTypes map[string]reflect.Type) (map[types.Hash]pkg.ContractEventDispatchEntry, error)
+	}
+
+	Event struct {
+		Name string
+		Args map[string]interface{}
+	}
+
+	contract struct {
+		chainClient         pkg.BlockchainClient
+		metadata            *Metadata
+		contractAddressSS58 string
+	}
+)
+
+var (
+	ErrLangError      = errors.New("ink! language error")
+	ErrContractResult = errors.New("contract returned an error")
+	ErrNotPayable     = errors.New("message is not payable")
+)
+
+func CreateContract(client pkg.BlockchainClient, metadata *Metadata, contractAddressSS58 string) Contract {
+	return &contract{
+		chainClient:         client,
+		metadata:            metadata,
+		contractAddressSS58: contractAddressSS58,
+	}
+}
+
+func (c *contract) GetContractAddress() string {
+	return c.contractAddressSS58
+}
+
+func (c *contract) GetMetadata() *Metadata {
+	return c.metadata
+}
+
+func (c *contract) EncodeMessage(message string, args ...interface{}) ([]byte, error) {
+	spec, err := c.metadata.Message(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.encodeCall(spec.Selector, spec.Args, args)
+}
+
+func (c *contract) EncodeConstructor(constructor string, args ...interface{}) ([]byte, error) {
+	spec, err := c.metadata.Constructor(constructor)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.encodeCall(spec.Selector, spec.Args, args)
+}
+
+func (c *contract) encodeCall(selector Selector, specs []ArgSpec, args []interface{}) ([]byte, error) {
+	if len(specs) != len(args) {
+		return nil, errors.Errorf("expected %d arguments, got %d", len(specs), len(args))
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	buf.Write(selector[:])
+	for i, arg := range args {
+		if err := c.metadata.Registry.Encode(buf, specs[i].Type.Type, arg); err != nil {
+			return nil, errors.Wrapf(err, "argument %s", specs[i].Label)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *contract) Read(message string, args ...interface{}) (interface{}, error) {
+	spec, data, err := c.callToRead(message, args)
+	if err != nil || spec.ReturnType == nil {
+		return nil, err
+	}
+
+	value, err := c.metadata.Registry.DecodeBytes(spec.ReturnType.Type, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode %s result", message)
+	}
+
+	if c.metadata.Version >= 4 {
+		if value, err = unwrapResult(value, ErrLangError); err != nil {
+			return nil, err
+		}
+	}
+
+	returnType, err := c.innerReturnType(spec)
+	if err != nil {
+		return nil, err
+	}
+	if returnType.Name() == "Result" {
+		return unwrapResult(value, ErrContractResult)
+	}
+
+	return value, nil
+}
+
+func (c *contract) ReadInto(result interface{}, message string, args ...interface{}) error {
+	spec, data, err := c.callToRead(message, args)
+	if err != nil || spec.ReturnType == nil {
+		return err
+	}
+
+	decoder := scale.NewDecoder(bytes.NewReader(data))
+	if c.metadata.Version >= 4 {
+		if err := c.expectOk(decoder, spec.ReturnType.Type, ErrLangError); err != nil {
+			return err
+		}
+	}
+
+	returnType, err := c.innerReturnType(spec)
+	if err != nil {
+		return err
+	}
+	if returnType.Name() == "Result" {
+		if err := c.expectOk(decoder, returnType.Id, ErrContractResult); err != nil {
+			return err
+		}
+	}
+
+	return decoder.Decode(result)
+}
+
+func (c *contract) callToRead(message string, args []interface{}) (*MessageSpec, []byte, error) {
+	spec, err := c.metadata.Message(message)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := c.encodeCall(spec.Selector, spec.Args, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoded, err := c.chainClient.CallToReadEncoded(c.contractAddressSS58, c.contractAddressSS58, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := codec.HexDecodeString(encoded)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "decode %s result", message)
+	}
+
+	return spec, result, nil
+}
+
+// innerReturnType resolves the type returned by the message itself, V4 wraps it into `Result<T, LangError>`.
+func (c *contract) innerReturnType(spec *MessageSpec) (*Type, error) {
+	t, err := c.metadata.Registry.Type(spec.ReturnType.Type)
+	if err != nil || c.metadata.Version < 4 {
+		return t, err
+	}
+
+	if t.Def.Variant == nil {
+		return nil, errors.Errorf("unexpected return type of %s", spec.Label)
+	}
+	okVariant := findVariant(t.Def.Variant.Variants, "Ok")
+	if okVariant == nil || len(okVariant.Fields) != 1 {
+		return nil, errors.Errorf("unexpected return type of %s", spec.Label)
+	}
+
+	return c.metadata.Registry.Type(okVariant.Fields[0].Type)
+}
+
+func (c *contract) expectOk(decoder *scale.Decoder, resultType int64, errType error) error {
+	index, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+	if index == 0 {
+		return nil
+	}
+
+	t, err := c.metadata.Registry.Type(resultType)
+	if err != nil {
+		return err
+	}
+	if t.Def.Variant == nil {
+		return errType
+	}
+	errVariant := findVariantByIndex(t.Def.Variant.Variants, index)
+	if errVariant == nil || len(errVariant.Fields) != 1 {
+		return errType
+	}
+	value, err := c.metadata.Registry.Decode(decoder, errVariant.Fields[0].Type)
+	if err != nil {
+		return errors.Wrap(errType, err.Error())
+	}
+
+	return errors.Wrapf(errType, "%v", value)
+}
+
+func (c *contract) Exec(ctx context.Context, keyPair signature.KeyringPair, message string, args ...interface{}) (types.Hash, error) {
+	return c.ExecWithValue(ctx, keyPair, 0, message, args...)
+}
+
+func (c *contract) ExecWithValue(ctx context.Context, keyPair signature.KeyringPair, value uint64, message string, args ...interface{}) (types.Hash, error) {
+	spec, err := c.metadata.Message(message)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	if value > 0 && !spec.Payable {
+		return types.Hash{}, errors.Wrap(ErrNotPayable, message)
+	}
+
+	data, err := c.encodeCall(spec.Selector, spec.Args, args)
+	if err != nil {
+		return types.Hash{}, err
+	}
+
+	contractAddress, err := pkg.DecodeAccountIDFromSS58(c.contractAddressSS58)
+	if err != nil {
+		return types.Hash{}, err
+	}
+
+	call := pkg.ContractCall{
+		ContractAddress:     contractAddress,
+		ContractAddressSS58: c.contractAddressSS58,
+		From:                keyPair,
+		Value:               value,
+		Method:              data,
+		ErrorDecoder: func(output []byte) error {
+			return c.decodeError(spec, output)
+		},
+	}
+
+	return c.chainClient.CallToExec(ctx, call)
+}
+
+// decodeError extracts the language or contract error from the output of a reverted message.
+func (c *contract) decodeError(spec *MessageSpec, output []byte) error {
+	if spec.ReturnType == nil {
+		return nil
+	}
+
+	decoder := scale.NewDecoder(bytes.NewReader(output))
+	if c.metadata.Version >= 4 {
+		if err := c.expectOk(decoder, spec.ReturnType.Type, ErrLangError); err != nil {
+			return err
+		}
+	}
+
+	returnType, err := c.innerReturnType(spec)
+	if err != nil || returnType.Name() != "Result" {
+		return nil
+	}
+
+	return c.expectOk(decoder, returnType.Id, ErrContractResult)
+}
+
+func (c *contract) Deploy(ctx context.Context, keyPair signature.KeyringPair, code []byte, salt []byte, constructor string, args ...interface{}) (types.AccountID, error) {
+	data, err := c.EncodeConstructor(constructor, args...)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+
+	if len(code) == 0 {
+		code = c.metadata.Wasm
+	}
+
+	deployCall := pkg.DeployCall{
+		Code:     code,
+		Salt:     salt,
+		From:     keyPair,
+		GasLimit: DEFAULT_DEPLOY_GAS_LIMIT,
+		Method:   data,
+	}
+
+	return c.chainClient.Deploy(ctx, deployCall)
+}
+
+// DecodeEvent decodes the data of a `Contracts.ContractEmitted` event: the index of the event in the contract spec
+// followed by the event arguments.
+func (c *contract) DecodeEvent(data []byte) (*Event, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty event data")
+	}
+
+	index := int(data[0])
+	if index >= len(c.metadata.Spec.Events) {
+		return nil, errors.Wrapf(ErrEventNotFound, "index %d", index)
+	}
+	spec := c.metadata.Spec.Events[index]
+
+	decoder := scale.NewDecoder(bytes.NewReader(data[1:]))
+	event := &Event{Name: spec.Label, Args: make(map[string]interface{}, len(spec.Args))}
+	for _, arg := range spec.Args {
+		value, err := c.metadata.Registry.Decode(decoder, arg.Type.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode %s.%s", spec.Label, arg.Label)
+		}
+		event.Args[arg.Label] = value
+	}
+
+	return event, nil
+}
+
+// EventDispatcher builds the dispatch table for pkg.BlockchainClient.SetEventDispatcher from Go types of
+// the events keyed by event label, e.g. the EventTypes map produced by abigen.
+func (c *contract) EventDispatcher(argumentTypes map[string]reflect.Type) (map[types.Hash]pkg.ContractEventDispatchEntry, error) {
+	dispatcher := make(map[types.Hash]pkg.ContractEventDispatchEntry, len(argumentTypes))
+	for label, argumentType := range argumentTypes {
+		spec, err := c.metadata.Event(label)
+		if err != nil {
+			return nil, err
+		}
+		dispatcher[spec.Topic] = pkg.ContractEventDispatchEntry{ArgumentType: argumentType}
+	}
+
+	return dispatcher, nil
+}
+
+func unwrapResult(value interface{}, errType error) (interface{}, error) {
+	enum, ok := value.(EnumValue)
+	if !ok {
+		return value, nil
+	}
+
+	switch enum.Name {
+	case "Ok":
+		return enum.Fields, nil
+	case "Err":
+		return nil, errors.Wrapf(errType, "%v", enum.Fields)
+	}
+
+	return value, nil
+}
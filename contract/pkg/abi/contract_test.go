@@ -0,0 +1,295 @@
+package abi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+type readOnlyClient struct {
+	pkg.BlockchainClient
+	result []byte
+	method []byte
+}
+
+func (c *readOnlyClient) CallToReadEncoded(_ string, _ string, method []byte, _ ...interface{}) (string, error) {
+	c.method = method
+	return codec.HexEncodeToString(c.result), nil
+}
+
+func (c *readOnlyClient) CallToExec(context.Context, pkg.ContractCall) (types.Hash, error) {
+	return types.Hash{}, errors.New("read only client")
+}
+
+type execClient struct {
+	pkg.BlockchainClient
+	call pkg.ContractCall
+}
+
+func (c *execClient) CallToExec(_ context.Context, call pkg.ContractCall) (types.Hash, error) {
+	c.call = call
+	return types.Hash{}, nil
+}
+
+type testBucket struct {
+	OwnerId            types.AccountID
+	ClusterId          types.U32
+	ResourceReserved   types.U32
+	PublicAvailability bool
+}
+
+const testContractAddress = "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL"
+
+func testOwner() types.AccountID {
+	owner, _ := pkg.DecodeAccountIDFromSS58(testContractAddress)
+	return owner
+}
+
+func TestEncodeMessage(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	contract := CreateContract(nil, meta, testContractAddress)
+	owner := testOwner()
+
+	tests := []struct {
+		name  string
+		owner interface{}
+		want  []byte
+	}{
+		{name: "None", owner: nil, want: []byte{0x0a, 0xeb, 0x23, 0x79, 0x08, '{', '}', 0x07, 0, 0, 0, 0x00}},
+		{name: "Some", owner: owner, want: append([]byte{0x0a, 0xeb, 0x23, 0x79, 0x08, '{', '}', 0x07, 0, 0, 0, 0x01}, owner[:]...)},
+		{name: "Some typed", owner: types.NewOption(owner), want: append([]byte{0x0a, 0xeb, 0x23, 0x79, 0x08, '{', '}', 0x07, 0, 0, 0, 0x01}, owner[:]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			data, err := contract.EncodeMessage("bucket_create", "{}", 7, tt.owner)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestEncodeMessageWrongArguments(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	contract := CreateContract(nil, meta, testContractAddress)
+
+	//when
+	_, errCount := contract.EncodeMessage("bucket_get")
+	_, errType := contract.EncodeMessage("bucket_get", "one")
+
+	//then
+	assert.Error(t, errCount)
+	assert.Error(t, errType)
+}
+
+func TestRegistryRoundTrip(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	owner := testOwner()
+	value := map[string]interface{}{
+		"owner_id":            owner[:],
+		"cluster_id":          uint32(2),
+		"resource_reserved":   uint32(100),
+		"public_availability": true,
+	}
+
+	//when
+	fromMap, errMap := meta.Registry.EncodeToBytes(6, value)
+	fromStruct, errStruct := meta.Registry.EncodeToBytes(6, testBucket{OwnerId: owner, ClusterId: 2, ResourceReserved: 100, PublicAvailability: true})
+	decoded, errDecode := meta.Registry.DecodeBytes(6, fromMap)
+
+	//then
+	assert.NoError(t, errMap)
+	assert.NoError(t, errStruct)
+	assert.NoError(t, errDecode)
+	assert.Equal(t, fromMap, fromStruct)
+	assert.Equal(t, value, decoded)
+}
+
+func TestRegistryEnum(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	value := EnumValue{Name: "InsufficientBalance", Index: 1, Fields: map[string]interface{}{"required": big.NewInt(1000)}}
+
+	//when
+	data, err := meta.Registry.EncodeToBytes(11, value)
+	decoded, errDecode := meta.Registry.DecodeBytes(11, data)
+	unit, errUnit := meta.Registry.EncodeToBytes(11, "BucketDoesNotExist")
+
+	//then
+	assert.NoError(t, err)
+	assert.NoError(t, errDecode)
+	assert.NoError(t, errUnit)
+	assert.Equal(t, value, decoded)
+	assert.Equal(t, []byte{0x00}, unit)
+}
+
+func TestRegistryIntegerRange(t *testing.T) {
+	//given
+	registry := &Registry{types: map[int64]*Type{
+		0: {Id: 0, Def: TypeDef{Primitive: "u8"}},
+		1: {Id: 1, Def: TypeDef{Primitive: "i8"}},
+		2: {Id: 2, Def: TypeDef{Primitive: "u32"}},
+	}}
+
+	tests := []struct {
+		name  string
+		id    int64
+		value interface{}
+		want  []byte
+	}{
+		{name: "u8 max", id: 0, value: 255, want: []byte{0xff}},
+		{name: "u8 overflow", id: 0, value: 300},
+		{name: "u32 negative", id: 2, value: -1},
+		{name: "i8 min", id: 1, value: -128, want: []byte{0x80}},
+		{name: "i8 max", id: 1, value: 127, want: []byte{0x7f}},
+		{name: "i8 underflow", id: 1, value: -129},
+		{name: "i8 overflow", id: 1, value: 128},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			data, err := registry.EncodeToBytes(tt.id, tt.value)
+
+			//then
+			if tt.want == nil {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, data)
+			}
+		})
+	}
+}
+
+func TestRegistrySequenceLengthBeyondInput(t *testing.T) {
+	//given
+	registry := &Registry{types: map[int64]*Type{
+		0: {Id: 0, Def: TypeDef{Primitive: "u8"}},
+		1: {Id: 1, Def: TypeDef{Sequence: &SequenceDef{Type: 0}}},
+		2: {Id: 2, Def: TypeDef{Sequence: &SequenceDef{Type: 1}}},
+	}}
+	// compact length 2^30 followed by 3 bytes
+	data := []byte{0x03, 0x00, 0x00, 0x00, 0x40, 1, 2, 3}
+
+	//when
+	_, errBytes := registry.DecodeBytes(1, data)
+	_, errItems := registry.DecodeBytes(2, data)
+	decoded, err := registry.DecodeBytes(1, []byte{0x0c, 1, 2, 3})
+
+	//then
+	assert.Error(t, errBytes)
+	assert.Error(t, errItems)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, decoded)
+}
+
+func TestRead(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	owner := testOwner()
+	bucketData := append(append([]byte{}, owner[:]...), 0x02, 0, 0, 0, 0x64, 0, 0, 0, 0x01)
+	client := &readOnlyClient{result: append([]byte{0x00, 0x00}, bucketData...)}
+	contract := CreateContract(client, meta, testContractAddress)
+
+	//when
+	result := testBucket{}
+	err := contract.ReadInto(&result, "bucket_get", uint32(2))
+	dynamic, errDynamic := contract.Read("bucket_get", uint32(2))
+
+	//then
+	assert.NoError(t, err)
+	assert.NoError(t, errDynamic)
+	assert.Equal(t, []byte{0x38, 0x02, 0xcb, 0x77, 0x02, 0, 0, 0}, client.method)
+	assert.Equal(t, testBucket{OwnerId: owner, ClusterId: 2, ResourceReserved: 100, PublicAvailability: true}, result)
+	assert.Equal(t, uint32(2), dynamic.(map[string]interface{})["cluster_id"])
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  []byte
+		wantErr error
+	}{
+		{name: "Contract error", result: []byte{0x00, 0x01, 0x00}, wantErr: ErrContractResult},
+		{name: "Language error", result: []byte{0x01, 0x01}, wantErr: ErrLangError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			meta, _ := ParseMetadata([]byte(testMetadataV4))
+			contract := CreateContract(&readOnlyClient{result: tt.result}, meta, testContractAddress)
+
+			//when
+			err := contract.ReadInto(&testBucket{}, "bucket_get", uint32(2))
+			_, errDynamic := contract.Read("bucket_get", uint32(2))
+
+			//then
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.ErrorIs(t, errDynamic, tt.wantErr)
+		})
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	contract := CreateContract(nil, meta, testContractAddress)
+	owner := testOwner()
+	data := append([]byte{0x02}, owner[:]...)
+	data = append(data, 0xe8, 0x03, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	//when
+	event, err := contract.DecodeEvent(data)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, "Deposit", event.Name)
+	assert.Equal(t, owner[:], event.Args["account_id"])
+	assert.Equal(t, big.NewInt(1000), event.Args["value"])
+}
+
+func TestExecErrorDecoder(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	client := &execClient{}
+	contract := CreateContract(client, meta, testContractAddress)
+
+	//when
+	_, err := contract.Exec(context.Background(), signature.KeyringPair{}, "bucket_get", uint32(2))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), client.call.GasLimit)
+	assert.NoError(t, client.call.ErrorDecoder([]byte{0x00, 0x00}))
+	assert.ErrorIs(t, client.call.ErrorDecoder([]byte{0x00, 0x01, 0x00}), ErrContractResult)
+	assert.ErrorIs(t, client.call.ErrorDecoder([]byte{0x01, 0x01}), ErrLangError)
+}
+
+func TestExecWithValue(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+	client := &execClient{}
+	contract := CreateContract(client, meta, testContractAddress)
+
+	//when
+	_, err := contract.ExecWithValue(context.Background(), signature.KeyringPair{}, 1000, "account_deposit")
+	value := client.call.Value
+	_, errNotPayable := contract.ExecWithValue(context.Background(), signature.KeyringPair{}, 1000, "bucket_get", uint32(2))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), value)
+	assert.ErrorIs(t, errNotPayable, ErrNotPayable)
+}
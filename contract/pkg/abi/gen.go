@@ -0,0 +1,490 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+type GenerateOptions struct {
+	// Package is the name of the generated Go package.
+	Package string
+	// TypeName is the name of the generated contract wrapper, the storage name by default.
+	TypeName string
+}
+
+type (
+	generator struct {
+		meta  *Metadata
+		names map[int64]string
+		used  map[string]int64
+		decls []string
+	}
+
+	bindings struct {
+		Package   string
+		TypeName  string
+		Source    string
+		Messages  []messageBinding
+		Events    []eventBinding
+		Decls     []string
+		UsesExec  bool
+		UsesScale bool
+		UsesTypes bool
+	}
+
+	messageBinding struct {
+		Label      string
+		Name       string
+		Selector   string
+		Mutates    bool
+		Payable    bool
+		Params     []paramBinding
+		ReturnType string
+	}
+
+	paramBinding struct {
+		Name string
+		Type string
+	}
+
+	eventBinding struct {
+		Label  string
+		Name   string
+		Topic  string
+		Fields []paramBinding
+	}
+)
+
+var bindingsTemplate = template.Must(template.New("bindings").Parse(`// Code generated by abigen from {{.Source}} metadata. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .UsesExec}}
+	"context"
+{{- end}}
+	"reflect"
+{{if .UsesScale}}
+	"github.com/centrifuge/go-substrate-rpc-client/v4/scale"
+{{- end}}
+{{- if .UsesExec}}
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+{{- end}}
+{{- if .UsesTypes}}
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+{{- end}}
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/abi"
+)
+
+const (
+{{- range .Messages}}
+	{{.Name}}Method = "{{.Selector}}"
+{{- end}}
+)
+
+const (
+{{- range .Events}}
+	{{.Name}}EventId = "{{.Topic}}"
+{{- end}}
+)
+
+{{range .Decls}}{{.}}
+{{end}}
+
+{{- range .Events}}
+type {{.Name}}Event struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+
+// EventTypes maps event labels to the Go types of the events, see abi.Contract.EventDispatcher.
+var EventTypes = map[string]reflect.Type{
+{{- range .Events}}
+	"{{.Label}}": reflect.TypeOf({{.Name}}Event{}),
+{{- end}}
+}
+
+type {{.TypeName}} struct {
+	abi.Contract
+}
+
+func Create{{.TypeName}}(client pkg.BlockchainClient, metadata *abi.Metadata, contractAddressSS58 string) *{{.TypeName}} {
+	return &{{.TypeName}}{Contract: abi.CreateContract(client, metadata, contractAddressSS58)}
+}
+{{range .Messages}}
+{{- if and .Mutates .Payable}}
+func (c *{{$.TypeName}}) {{.Name}}(ctx context.Context, keyPair signature.KeyringPair, value uint64{{range .Params}}, {{.Name}} {{.Type}}{{end}}) (types.Hash, error) {
+	return c.ExecWithValue(ctx, keyPair, value, "{{.Label}}"{{range .Params}}, {{.Name}}{{end}})
+}
+{{else if .Mutates}}
+func (c *{{$.TypeName}}) {{.Name}}(ctx context.Context, keyPair signature.KeyringPair{{range .Params}}, {{.Name}} {{.Type}}{{end}}) (types.Hash, error) {
+	return c.Exec(ctx, keyPair, "{{.Label}}"{{range .Params}}, {{.Name}}{{end}})
+}
+{{else if .ReturnType}}
+func (c *{{$.TypeName}}) {{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}} {{$p.Type}}{{end}}) (result {{.ReturnType}}, err error) {
+	err = c.ReadInto(&result, "{{.Label}}"{{range .Params}}, {{.Name}}{{end}})
+	return result, err
+}
+{{else}}
+func (c *{{$.TypeName}}) {{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}} {{$p.Type}}{{end}}) error {
+	_, err := c.Read("{{.Label}}"{{range .Params}}, {{.Name}}{{end}})
+	return err
+}
+{{end}}
+{{- end}}`))
+
+// GenerateBindings renders typed Go bindings for the contract: selector and event topic constants, Go types of
+// the registry types used by messages and events, event structs and a wrapper with a method per contract message.
+func GenerateBindings(meta *Metadata, options GenerateOptions) ([]byte, error) {
+	g := &generator{meta: meta, names: map[int64]string{}, used: map[string]int64{}}
+
+	data := bindings{
+		Package:  options.Package,
+		TypeName: options.TypeName,
+		Source:   meta.Contract.Name,
+	}
+	if data.Package == "" {
+		data.Package = strings.ToLower(meta.StorageName())
+	}
+	if data.TypeName == "" {
+		data.TypeName = meta.StorageName()
+	}
+
+	for _, message := range meta.Spec.Messages {
+		binding := messageBinding{
+			Label:    message.Label,
+			Name:     camelCase(message.Label),
+			Selector: message.Selector.Hex(),
+			Mutates:  message.Mutates,
+			Payable:  message.Payable,
+		}
+		for _, arg := range message.Args {
+			t, err := g.goType(arg.Type.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "message %s argument %s", message.Label, arg.Label)
+			}
+			binding.Params = append(binding.Params, paramBinding{Name: paramName(arg.Label), Type: t})
+		}
+		if !message.Mutates && message.ReturnType != nil {
+			t, err := g.returnType(message.ReturnType.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "message %s return type", message.Label)
+			}
+			binding.ReturnType = t
+		}
+		data.Messages = append(data.Messages, binding)
+	}
+
+	for _, event := range meta.Spec.Events {
+		binding := eventBinding{
+			Label: event.Label,
+			Name:  camelCase(event.Label),
+			Topic: event.Topic.Hex()[2:],
+		}
+		for _, arg := range event.Args {
+			t, err := g.goType(arg.Type.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "event %s argument %s", event.Label, arg.Label)
+			}
+			binding.Fields = append(binding.Fields, paramBinding{Name: camelCase(arg.Label), Type: t})
+		}
+		data.Events = append(data.Events, binding)
+	}
+	data.Decls = g.decls
+
+	rendered := strings.Join(g.decls, "")
+	for _, message := range data.Messages {
+		data.UsesExec = data.UsesExec || message.Mutates
+		rendered += message.ReturnType
+		for _, param := range message.Params {
+			rendered += param.Type
+		}
+	}
+	for _, event := range data.Events {
+		for _, field := range event.Fields {
+			rendered += field.Type
+		}
+	}
+	data.UsesScale = strings.Contains(rendered, "scale.")
+	data.UsesTypes = data.UsesExec || strings.Contains(rendered, "types.")
+
+	buf := &bytes.Buffer{}
+	if err := bindingsTemplate.Execute(buf, data); err != nil {
+		return nil, err
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "format generated source")
+	}
+
+	return source, nil
+}
+
+// returnType strips the `Result<T, LangError>` wrapper of V4 messages and the `Result<T, E>` of the message itself,
+// both errors are reported by abi.Contract.ReadInto.
+func (g *generator) returnType(id int64) (string, error) {
+	t, err := g.meta.Registry.Type(id)
+	if err != nil {
+		return "", err
+	}
+
+	if g.meta.Version >= 4 {
+		if id, err = okType(t); err != nil {
+			return "", err
+		}
+		if t, err = g.meta.Registry.Type(id); err != nil {
+			return "", err
+		}
+	}
+
+	if t.Name() == "Result" {
+		if id, err = okType(t); err != nil {
+			return "", err
+		}
+		if t, err = g.meta.Registry.Type(id); err != nil {
+			return "", err
+		}
+	}
+
+	if len(t.Def.Tuple) == 0 && t.Def.Tuple != nil {
+		return "", nil
+	}
+
+	return g.goType(id)
+}
+
+func okType(t *Type) (int64, error) {
+	if t.Def.Variant == nil {
+		return 0, errors.Errorf("type %d is not a Result", t.Id)
+	}
+	ok := findVariant(t.Def.Variant.Variants, "Ok")
+	if ok == nil || len(ok.Fields) != 1 {
+		return 0, errors.Errorf("type %d is not a Result", t.Id)
+	}
+
+	return ok.Fields[0].Type, nil
+}
+
+func (g *generator) goType(id int64) (string, error) {
+	if name, ok := g.names[id]; ok {
+		return name, nil
+	}
+
+	t, err := g.meta.Registry.Type(id)
+	if err != nil {
+		return "", err
+	}
+
+	def := t.Def
+	switch {
+	case def.Primitive != "":
+		return primitiveGoType(def.Primitive)
+
+	case def.Compact != nil:
+		return "types.UCompact", nil
+
+	case def.Sequence != nil:
+		if g.meta.Registry.isByte(def.Sequence.Type) {
+			return "[]byte", nil
+		}
+		item, err := g.goType(def.Sequence.Type)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+
+	case def.Array != nil:
+		if g.meta.Registry.isByte(def.Array.Type) {
+			return fmt.Sprintf("[%d]byte", def.Array.Len), nil
+		}
+		item, err := g.goType(def.Array.Type)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%d]%s", def.Array.Len, item), nil
+
+	case def.Tuple != nil:
+		if len(def.Tuple) == 0 {
+			return "struct{}", nil
+		}
+		fields := make([]Field, len(def.Tuple))
+		for i, itemType := range def.Tuple {
+			fields[i] = Field{Type: itemType}
+		}
+		return g.declareStruct(id, fmt.Sprintf("Tuple%d", id), fields)
+
+	case def.Composite != nil:
+		switch t.Name() {
+		case "AccountId":
+			return "types.AccountID", nil
+		case "Hash":
+			return "types.Hash", nil
+		}
+		return g.declareStruct(id, camelCase(t.Name()), def.Composite.Fields)
+
+	case def.Variant != nil:
+		if t.Name() == "Option" {
+			some := findVariant(def.Variant.Variants, "Some")
+			if some == nil || len(some.Fields) != 1 {
+				return "", errors.Errorf("type %d: malformed Option", id)
+			}
+			item, err := g.goType(some.Fields[0].Type)
+			if err != nil {
+				return "", err
+			}
+			return "types.Option[" + item + "]", nil
+		}
+		return g.declareEnum(id, camelCase(t.Name()), def.Variant.Variants)
+	}
+
+	return "", errors.Errorf("type %d: unsupported type definition", id)
+}
+
+func (g *generator) typeName(id int64, name string) string {
+	if name == "" {
+		name = fmt.Sprintf("Type%d", id)
+	}
+	if other, ok := g.used[name]; ok && other != id {
+		name = fmt.Sprintf("%s%d", name, id)
+	}
+	g.used[name] = id
+	g.names[id] = name
+
+	return name
+}
+
+func (g *generator) declareStruct(id int64, name string, fields []Field) (string, error) {
+	name = g.typeName(id, name)
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for i, f := range fields {
+		t, err := g.goType(f.Type)
+		if err != nil {
+			return "", errors.Wrapf(err, "field %s of %s", f.Name, name)
+		}
+		fieldName := camelCase(f.Name)
+		if fieldName == "" {
+			fieldName = fmt.Sprintf("F%d", i)
+		}
+		fmt.Fprintf(&b, "\t%s %s\n", fieldName, t)
+	}
+	b.WriteString("}\n")
+	g.decls = append(g.decls, b.String())
+
+	return name, nil
+}
+
+// declareEnum renders fieldless enums as uint8 constants and enums with data in the gsrpc style: an IsX flag and
+// an AsX value for every variant along with Encode and Decode methods.
+func (g *generator) declareEnum(id int64, name string, variants []Variant) (string, error) {
+	name = g.typeName(id, name)
+
+	sorted := append([]Variant(nil), variants...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	withFields := false
+	for _, v := range sorted {
+		withFields = withFields || len(v.Fields) > 0
+	}
+
+	b := strings.Builder{}
+	if !withFields {
+		fmt.Fprintf(&b, "type %s uint8\n\nconst (\n", name)
+		for _, v := range sorted {
+			fmt.Fprintf(&b, "\t%s%s %s = %d\n", name, camelCase(v.Name), name, v.Index)
+		}
+		b.WriteString(")\n")
+		g.decls = append(g.decls, b.String())
+		return name, nil
+	}
+
+	valueTypes := make([]string, len(sorted))
+	for i, v := range sorted {
+		var err error
+		switch {
+		case len(v.Fields) == 0:
+		case len(v.Fields) == 1 && v.Fields[0].Name == "":
+			valueTypes[i], err = g.goType(v.Fields[0].Type)
+		default:
+			valueTypes[i], err = g.declareStruct(-1-id*256-int64(v.Index), name+camelCase(v.Name), v.Fields)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "variant %s of %s", v.Name, name)
+		}
+	}
+
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for i, v := range sorted {
+		fmt.Fprintf(&b, "\tIs%s bool\n", camelCase(v.Name))
+		if valueTypes[i] != "" {
+			fmt.Fprintf(&b, "\tAs%s %s\n", camelCase(v.Name), valueTypes[i])
+		}
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "func (e *%s) Decode(decoder scale.Decoder) error {\n", name)
+	b.WriteString("\tindex, err := decoder.ReadOneByte()\n\tif err != nil {\n\t\treturn err\n\t}\n\n\tswitch index {\n")
+	for i, v := range sorted {
+		fmt.Fprintf(&b, "\tcase %d:\n\t\te.Is%s = true\n", v.Index, camelCase(v.Name))
+		if valueTypes[i] != "" {
+			fmt.Fprintf(&b, "\t\treturn decoder.Decode(&e.As%s)\n", camelCase(v.Name))
+		}
+	}
+	b.WriteString("\t}\n\n\treturn nil\n}\n\n")
+
+	fmt.Fprintf(&b, "func (e %s) Encode(encoder scale.Encoder) error {\n\tswitch {\n", name)
+	for i, v := range sorted {
+		fmt.Fprintf(&b, "\tcase e.Is%s:\n", camelCase(v.Name))
+		if valueTypes[i] != "" {
+			fmt.Fprintf(&b, "\t\tif err := encoder.PushByte(%d); err != nil {\n\t\t\treturn err\n\t\t}\n", v.Index)
+			fmt.Fprintf(&b, "\t\treturn encoder.Encode(e.As%s)\n", camelCase(v.Name))
+		} else {
+			fmt.Fprintf(&b, "\t\treturn encoder.PushByte(%d)\n", v.Index)
+		}
+	}
+	b.WriteString("\t}\n\n\treturn nil\n}\n")
+
+	g.decls = append(g.decls, b.String())
+
+	return name, nil
+}
+
+func primitiveGoType(primitive string) (string, error) {
+	switch primitive {
+	case "bool":
+		return "bool", nil
+	case "str":
+		return "string", nil
+	case "char":
+		return "types.U32", nil
+	case "u8", "u16", "u32", "u64", "u128", "u256", "i8", "i16", "i32", "i64", "i128", "i256":
+		return "types." + strings.ToUpper(primitive), nil
+	}
+
+	return "", errors.Errorf("unsupported primitive %s", primitive)
+}
+
+func paramName(label string) string {
+	name := camelCase(label)
+	if name == "" {
+		return "arg"
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+	if token.IsKeyword(name) || name == "ctx" || name == "keyPair" || name == "result" || name == "err" || name == "c" {
+		name += "Arg"
+	}
+
+	return name
+}
@@ -0,0 +1,40 @@
+package abi
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateBindings(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+
+	//when
+	source, err := GenerateBindings(meta, GenerateOptions{Package: "bucketabi"})
+
+	//then
+	assert.NoError(t, err)
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "bindings.go", source, parser.AllErrors)
+	assert.NoError(t, err)
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = config.Check("bucketabi", fset, []*ast.File{file}, nil)
+	assert.NoError(t, err)
+
+	code := string(source)
+	assert.Contains(t, code, "package bucketabi")
+	assert.Regexp(t, `BucketGetMethod\s+= "3802cb77"`, code)
+	assert.Regexp(t, `BucketCreatedEventId\s+= "004464634275636b65743a3a4275636b65744372656174656400000000000000"`, code)
+	assert.Contains(t, code, "type Bucket struct {")
+	assert.Regexp(t, `OwnerId\s+types.AccountID`, code)
+	assert.Contains(t, code, "func (c *DdcBucket) BucketGet(bucketId types.U32) (result Bucket, err error)")
+	assert.Contains(t, code, "func (c *DdcBucket) BucketCreate(ctx context.Context, keyPair signature.KeyringPair, value uint64, bucketParams string, clusterId types.U32, ownerId types.Option[types.AccountID]) (types.Hash, error)")
+	assert.Contains(t, code, "func (c *DdcBucket) AccountDeposit(ctx context.Context, keyPair signature.KeyringPair, value uint64) (types.Hash, error)")
+	assert.Regexp(t, `"Deposit":\s+reflect.TypeOf\(DepositEvent\{\}\),`, code)
+}
@@ -0,0 +1,295 @@
+package abi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+type (
+	// Metadata is the normalized form of an ink! contract metadata file (`.contract` bundle or `metadata.json`).
+	// Both V3 (wrapped into a "V3" object) and V4 (flat with "version": "4") layouts are supported.
+	Metadata struct {
+		Version  int
+		Source   Source
+		Contract ContractInfo
+		Spec     Spec
+		Registry *Registry
+
+		// Wasm is only filled for `.contract` bundles which embed the contract code.
+		Wasm []byte
+
+		storageName string
+	}
+
+	Source struct {
+		Hash     string `json:"hash"`
+		Language string `json:"language"`
+		Compiler string `json:"compiler"`
+		Wasm     string `json:"wasm,omitempty"`
+	}
+
+	ContractInfo struct {
+		Name    string   `json:"name"`
+		Version string   `json:"version"`
+		Authors []string `json:"authors"`
+	}
+
+	Spec struct {
+		Constructors []ConstructorSpec `json:"constructors"`
+		Messages     []MessageSpec     `json:"messages"`
+		Events       []EventSpec       `json:"events"`
+		Docs         []string          `json:"docs"`
+	}
+
+	ConstructorSpec struct {
+		Label      string    `json:"label"`
+		Selector   Selector  `json:"selector"`
+		Args       []ArgSpec `json:"args"`
+		Payable    bool      `json:"payable"`
+		ReturnType *TypeSpec `json:"returnType"`
+		Docs       []string  `json:"docs"`
+	}
+
+	MessageSpec struct {
+		Label      string    `json:"label"`
+		Selector   Selector  `json:"selector"`
+		Args       []ArgSpec `json:"args"`
+		Mutates    bool      `json:"mutates"`
+		Payable    bool      `json:"payable"`
+		ReturnType *TypeSpec `json:"returnType"`
+		Docs       []string  `json:"docs"`
+	}
+
+	EventSpec struct {
+		Label string           `json:"label"`
+		Args  []EventParamSpec `json:"args"`
+		Docs  []string         `json:"docs"`
+
+		// Topic is the signature topic the contract attaches to every emitted instance of the event.
+		Topic types.Hash `json:"-"`
+	}
+
+	ArgSpec struct {
+		Label string   `json:"label"`
+		Type  TypeSpec `json:"type"`
+	}
+
+	EventParamSpec struct {
+		Label   string   `json:"label"`
+		Indexed bool     `json:"indexed"`
+		Type    TypeSpec `json:"type"`
+		Docs    []string `json:"docs"`
+	}
+
+	TypeSpec struct {
+		Type        int64    `json:"type"`
+		DisplayName []string `json:"displayName"`
+	}
+
+	Selector [4]byte
+
+	metadataJson struct {
+		Source   Source          `json:"source"`
+		Contract ContractInfo    `json:"contract"`
+		Version  json.RawMessage `json:"version"`
+		V3       *versionedJson  `json:"V3"`
+		versionedJson
+	}
+
+	versionedJson struct {
+		Spec  *Spec      `json:"spec"`
+		Types []typeJson `json:"types"`
+	}
+)
+
+var (
+	ErrUnsupportedMetadata = errors.New("unsupported ink! metadata version")
+	ErrMessageNotFound     = errors.New("message not found in contract metadata")
+	ErrConstructorNotFound = errors.New("constructor not found in contract metadata")
+	ErrEventNotFound       = errors.New("event not found in contract metadata")
+)
+
+// LoadMetadataFile reads ink! metadata from a `.contract` or `metadata.json` file.
+func LoadMetadataFile(path string) (*Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read metadata file")
+	}
+
+	return ParseMetadata(data)
+}
+
+// ParseMetadata decodes ink! V3 or V4 metadata JSON.
+func ParseMetadata(data []byte) (*Metadata, error) {
+	raw := metadataJson{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "unmarshal metadata")
+	}
+
+	meta := &Metadata{Source: raw.Source, Contract: raw.Contract}
+
+	versioned := raw.versionedJson
+	switch {
+	case raw.V3 != nil:
+		meta.Version = 3
+		versioned = *raw.V3
+	case len(raw.Version) > 0:
+		version := strings.Trim(string(raw.Version), `"`)
+		if version != "4" {
+			return nil, errors.Wrapf(ErrUnsupportedMetadata, "version %s", version)
+		}
+		meta.Version = 4
+	default:
+		return nil, ErrUnsupportedMetadata
+	}
+
+	if versioned.Spec == nil {
+		return nil, errors.New("metadata has no contract spec")
+	}
+	meta.Spec = *versioned.Spec
+
+	registry, err := newRegistry(versioned.Types)
+	if err != nil {
+		return nil, err
+	}
+	meta.Registry = registry
+
+	if len(raw.Source.Wasm) > 0 {
+		meta.Wasm, err = hex.DecodeString(strings.TrimPrefix(raw.Source.Wasm, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "decode embedded wasm")
+		}
+	}
+
+	for i := range meta.Spec.Events {
+		meta.Spec.Events[i].Topic = EventSignatureTopic(meta.StorageName(), meta.Spec.Events[i].Label)
+	}
+
+	return meta, nil
+}
+
+// StorageName is the name of the contract storage struct, e.g. "DdcBucket" for the "ddc_bucket" crate.
+// ink! uses it as the prefix of event signature topics.
+func (m *Metadata) StorageName() string {
+	if len(m.storageName) > 0 {
+		return m.storageName
+	}
+
+	return camelCase(m.Contract.Name)
+}
+
+// SetStorageName overrides the storage struct name if it doesn't follow the crate name and recomputes the event topics.
+func (m *Metadata) SetStorageName(name string) {
+	m.storageName = name
+	for i := range m.Spec.Events {
+		m.Spec.Events[i].Topic = EventSignatureTopic(name, m.Spec.Events[i].Label)
+	}
+}
+
+func (m *Metadata) Message(label string) (*MessageSpec, error) {
+	for i := range m.Spec.Messages {
+		if m.Spec.Messages[i].Label == label {
+			return &m.Spec.Messages[i], nil
+		}
+	}
+
+	return nil, errors.Wrap(ErrMessageNotFound, label)
+}
+
+func (m *Metadata) Constructor(label string) (*ConstructorSpec, error) {
+	for i := range m.Spec.Constructors {
+		if m.Spec.Constructors[i].Label == label {
+			return &m.Spec.Constructors[i], nil
+		}
+	}
+
+	return nil, errors.Wrap(ErrConstructorNotFound, label)
+}
+
+func (m *Metadata) Event(label string) (*EventSpec, error) {
+	for i := range m.Spec.Events {
+		if m.Spec.Events[i].Label == label {
+			return &m.Spec.Events[i], nil
+		}
+	}
+
+	return nil, errors.Wrap(ErrEventNotFound, label)
+}
+
+// EventByTopic finds the event spec by one of the topics attached to the emitted event.
+func (m *Metadata) EventByTopic(topics []types.Hash) (*EventSpec, error) {
+	for _, topic := range topics {
+		for i := range m.Spec.Events {
+			if m.Spec.Events[i].Topic == topic {
+				return &m.Spec.Events[i], nil
+			}
+		}
+	}
+
+	return nil, ErrEventNotFound
+}
+
+// EventSignatureTopic computes the topic ink! attaches to an event: the SCALE encoded `PrefixedValue` of
+// "<StorageName>::<EventLabel>" padded with zeros or hashed with blake2b-256 if it doesn't fit into 32 bytes.
+func EventSignatureTopic(storageName string, eventLabel string) types.Hash {
+	encoded := append([]byte{0x00}, []byte(storageName+"::"+eventLabel)...)
+	if len(encoded) > len(types.Hash{}) {
+		return blake2b.Sum256(encoded)
+	}
+
+	topic := types.Hash{}
+	copy(topic[:], encoded)
+	return topic
+}
+
+func (s *Selector) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+
+	decoded, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(s) {
+		return errors.Errorf("invalid selector length %d", len(decoded))
+	}
+	copy(s[:], decoded)
+
+	return nil
+}
+
+func (s Selector) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + s.Hex())
+}
+
+func (s Selector) Hex() string {
+	return hex.EncodeToString(s[:])
+}
+
+// camelCase converts rust identifiers like "bucket_get" or "Erc20::transfer" into "BucketGet" and "Erc20Transfer".
+func camelCase(s string) string {
+	b := strings.Builder{}
+	upper := true
+	for _, r := range s {
+		if r == '_' || r == ':' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,182 @@
+package abi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTypes = `[
+	{"id": 0, "type": {"path": ["ink_primitives", "types", "AccountId"], "def": {"composite": {"fields": [{"type": 1, "typeName": "[u8; 32]"}]}}}},
+	{"id": 1, "type": {"def": {"array": {"len": 32, "type": 2}}}},
+	{"id": 2, "type": {"def": {"primitive": "u8"}}},
+	{"id": 3, "type": {"def": {"primitive": "u32"}}},
+	{"id": 4, "type": {"path": ["Option"], "params": [{"name": "T", "type": 0}], "def": {"variant": {"variants": [
+		{"name": "None", "index": 0},
+		{"name": "Some", "index": 1, "fields": [{"type": 0}]}
+	]}}}},
+	{"id": 5, "type": {"def": {"primitive": "str"}}},
+	{"id": 6, "type": {"path": ["ddc_bucket", "ddc_bucket", "bucket", "entity", "Bucket"], "def": {"composite": {"fields": [
+		{"name": "owner_id", "type": 0, "typeName": "AccountId"},
+		{"name": "cluster_id", "type": 3, "typeName": "ClusterId"},
+		{"name": "resource_reserved", "type": 3, "typeName": "Resource"},
+		{"name": "public_availability", "type": 12, "typeName": "bool"}
+	]}}}},
+	{"id": 7, "type": {"path": ["Result"], "params": [{"name": "T", "type": 6}, {"name": "E", "type": 11}], "def": {"variant": {"variants": [
+		{"name": "Ok", "index": 0, "fields": [{"type": 6}]},
+		{"name": "Err", "index": 1, "fields": [{"type": 11}]}
+	]}}}},
+	{"id": 8, "type": {"def": {"tuple": []}}},
+	{"id": 9, "type": {"path": ["Result"], "params": [{"name": "T", "type": 8}, {"name": "E", "type": 10}], "def": {"variant": {"variants": [
+		{"name": "Ok", "index": 0, "fields": [{"type": 8}]},
+		{"name": "Err", "index": 1, "fields": [{"type": 10}]}
+	]}}}},
+	{"id": 10, "type": {"path": ["ink_primitives", "LangError"], "def": {"variant": {"variants": [
+		{"name": "CouldNotReadInput", "index": 1}
+	]}}}},
+	{"id": 11, "type": {"path": ["ddc_bucket", "ddc_bucket", "Error"], "def": {"variant": {"variants": [
+		{"name": "BucketDoesNotExist", "index": 0},
+		{"name": "InsufficientBalance", "index": 1, "fields": [{"name": "required", "type": 13}]}
+	]}}}},
+	{"id": 12, "type": {"def": {"primitive": "bool"}}},
+	{"id": 13, "type": {"def": {"primitive": "u128"}}},
+	{"id": 14, "type": {"path": ["Result"], "params": [{"name": "T", "type": 7}, {"name": "E", "type": 10}], "def": {"variant": {"variants": [
+		{"name": "Ok", "index": 0, "fields": [{"type": 7}]},
+		{"name": "Err", "index": 1, "fields": [{"type": 10}]}
+	]}}}}
+]`
+
+const testEvents = `[
+	{"label": "BucketCreated", "args": [
+		{"label": "bucket_id", "indexed": true, "type": {"type": 3, "displayName": ["BucketId"]}},
+		{"label": "owner_id", "indexed": true, "type": {"type": 0, "displayName": ["AccountId"]}}
+	]},
+	{"label": "BucketAvailabilityUpdated", "args": [
+		{"label": "bucket_id", "indexed": true, "type": {"type": 3, "displayName": ["BucketId"]}},
+		{"label": "public_availability", "indexed": false, "type": {"type": 12, "displayName": ["bool"]}}
+	]},
+	{"label": "Deposit", "args": [
+		{"label": "account_id", "indexed": true, "type": {"type": 0, "displayName": ["AccountId"]}},
+		{"label": "value", "indexed": false, "type": {"type": 13, "displayName": ["Balance"]}}
+	]}
+]`
+
+var testMetadataV4 = fmt.Sprintf(`{
+	"source": {"hash": "0x00", "language": "ink! 4.2.0", "compiler": "rustc 1.69.0"},
+	"contract": {"name": "ddc_bucket", "version": "1.0.0", "authors": ["Cere Network"]},
+	"spec": {
+		"constructors": [{"label": "new", "selector": "0x9bae9d5e", "args": [], "payable": false, "returnType": {"type": 9, "displayName": []}}],
+		"messages": [
+			{"label": "bucket_create", "selector": "0x0aeb2379", "mutates": true, "payable": true, "args": [
+				{"label": "bucket_params", "type": {"type": 5, "displayName": ["BucketParams"]}},
+				{"label": "cluster_id", "type": {"type": 3, "displayName": ["ClusterId"]}},
+				{"label": "owner_id", "type": {"type": 4, "displayName": ["Option"]}}
+			], "returnType": {"type": 9, "displayName": []}},
+			{"label": "bucket_get", "selector": "0x3802cb77", "mutates": false, "payable": false, "args": [
+				{"label": "bucket_id", "type": {"type": 3, "displayName": ["BucketId"]}}
+			], "returnType": {"type": 14, "displayName": []}},
+			{"label": "account_deposit", "selector": "0xc311af62", "mutates": true, "payable": true, "args": [], "returnType": {"type": 9, "displayName": []}}
+		],
+		"events": %s
+	},
+	"types": %s,
+	"version": "4"
+}`, testEvents, testTypes)
+
+var testMetadataV3 = fmt.Sprintf(`{
+	"source": {"hash": "0x00", "language": "ink! 3.4.0", "compiler": "rustc 1.63.0"},
+	"contract": {"name": "ddc_bucket", "version": "1.0.0", "authors": ["Cere Network"]},
+	"V3": {
+		"spec": {
+			"constructors": [{"label": "new", "selector": "0x9bae9d5e", "args": [], "payable": false}],
+			"messages": [
+				{"label": "bucket_get", "selector": "0x3802cb77", "mutates": false, "payable": false, "args": [
+					{"label": "bucket_id", "type": {"type": 3, "displayName": ["BucketId"]}}
+				], "returnType": {"type": 7, "displayName": ["Result"]}}
+			],
+			"events": %s
+		},
+		"types": %s
+	}
+}`, testEvents, testTypes)
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		version int
+	}{
+		{name: "V3", data: testMetadataV3, version: 3},
+		{name: "V4", data: testMetadataV4, version: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			meta, err := ParseMetadata([]byte(tt.data))
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.version, meta.Version)
+			assert.Equal(t, "DdcBucket", meta.StorageName())
+
+			message, err := meta.Message("bucket_get")
+			assert.NoError(t, err)
+			assert.Equal(t, "3802cb77", message.Selector.Hex())
+
+			_, err = meta.Message("bucket_remove")
+			assert.ErrorIs(t, err, ErrMessageNotFound)
+		})
+	}
+}
+
+func TestParseMetadataUnsupportedVersion(t *testing.T) {
+	//when
+	_, err := ParseMetadata([]byte(`{"version": "5", "spec": {}, "types": []}`))
+
+	//then
+	assert.ErrorIs(t, err, ErrUnsupportedMetadata)
+}
+
+func TestEventTopics(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+
+	tests := []struct {
+		event string
+		want  string
+	}{
+		{event: "BucketCreated", want: bucket.BucketCreatedEventId},
+		{event: "BucketAvailabilityUpdated", want: bucket.BucketAvailabilityUpdatedId},
+		{event: "Deposit", want: bucket.DepositEventId},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			//when
+			event, err := meta.Event(tt.event)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, "0x"+tt.want, event.Topic.Hex())
+
+			byTopic, err := meta.EventByTopic([]types.Hash{{}, event.Topic})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.event, byTopic.Label)
+		})
+	}
+}
+
+func TestSetStorageName(t *testing.T) {
+	//given
+	meta, _ := ParseMetadata([]byte(testMetadataV4))
+
+	//when
+	meta.SetStorageName("Bucket")
+
+	//then
+	event, _ := meta.Event("Deposit")
+	assert.Equal(t, "Bucket", meta.StorageName())
+	assert.Equal(t, EventSignatureTopic("Bucket", "Deposit"), event.Topic)
+}
@@ -0,0 +1,763 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/scale"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Registry is the portable type registry of the contract metadata. It encodes Go values into SCALE
+	// and decodes SCALE into dynamic Go values following the type definitions of the contract.
+	Registry struct {
+		types map[int64]*Type
+	}
+
+	Type struct {
+		Id     int64
+		Path   []string
+		Params []TypeParam
+		Def    TypeDef
+		Docs   []string
+	}
+
+	TypeParam struct {
+		Name string `json:"name"`
+		Type *int64 `json:"type"`
+	}
+
+	TypeDef struct {
+		Composite   *CompositeDef   `json:"composite,omitempty"`
+		Variant     *VariantDef     `json:"variant,omitempty"`
+		Sequence    *SequenceDef    `json:"sequence,omitempty"`
+		Array       *ArrayDef       `json:"array,omitempty"`
+		Tuple       []int64         `json:"tuple,omitempty"`
+		Primitive   string          `json:"primitive,omitempty"`
+		Compact     *CompactDef     `json:"compact,omitempty"`
+		BitSequence json.RawMessage `json:"bitsequence,omitempty"`
+	}
+
+	CompositeDef struct {
+		Fields []Field `json:"fields"`
+	}
+
+	VariantDef struct {
+		Variants []Variant `json:"variants"`
+	}
+
+	Variant struct {
+		Name   string   `json:"name"`
+		Fields []Field  `json:"fields"`
+		Index  uint8    `json:"index"`
+		Docs   []string `json:"docs"`
+	}
+
+	Field struct {
+		Name     string   `json:"name"`
+		Type     int64    `json:"type"`
+		TypeName string   `json:"typeName"`
+		Docs     []string `json:"docs"`
+	}
+
+	SequenceDef struct {
+		Type int64 `json:"type"`
+	}
+
+	ArrayDef struct {
+		Len  uint32 `json:"len"`
+		Type int64  `json:"type"`
+	}
+
+	CompactDef struct {
+		Type int64 `json:"type"`
+	}
+
+	// EnumValue is the dynamic representation of a decoded enum (variant) value. Fields follows
+	// the composite rules: nil for unit variants, the value itself for a single unnamed field,
+	// []interface{} for several unnamed fields and map[string]interface{} for named fields.
+	EnumValue struct {
+		Name   string
+		Index  uint8
+		Fields interface{}
+	}
+
+	typeJson struct {
+		Id   *int64 `json:"id"`
+		Type struct {
+			Path   []string    `json:"path"`
+			Params []TypeParam `json:"params"`
+			Def    TypeDef     `json:"def"`
+			Docs   []string    `json:"docs"`
+		} `json:"type"`
+	}
+)
+
+var ErrTypeNotFound = errors.New("type not found in contract metadata registry")
+
+func newRegistry(typesJson []typeJson) (*Registry, error) {
+	registry := &Registry{types: make(map[int64]*Type, len(typesJson))}
+	for i, t := range typesJson {
+		// Old layouts list types without ids, the position is the id then.
+		id := int64(i)
+		if t.Id != nil {
+			id = *t.Id
+		}
+		registry.types[id] = &Type{
+			Id:     id,
+			Path:   t.Type.Path,
+			Params: t.Type.Params,
+			Def:    t.Type.Def,
+			Docs:   t.Type.Docs,
+		}
+	}
+
+	return registry, nil
+}
+
+func (r *Registry) Type(id int64) (*Type, error) {
+	t, ok := r.types[id]
+	if !ok {
+		return nil, errors.Wrapf(ErrTypeNotFound, "id %d", id)
+	}
+
+	return t, nil
+}
+
+// Name is the last segment of the type path, e.g. "AccountId" for ["ink_env", "types", "AccountId"].
+func (t *Type) Name() string {
+	if len(t.Path) == 0 {
+		return ""
+	}
+
+	return t.Path[len(t.Path)-1]
+}
+
+// Encode appends the SCALE encoding of value as the registry type id to buf.
+//
+// Values implementing scale.Encodeable (e.g. types.U128, types.OptionU32) are encoded as is. Everything else is
+// walked according to the type definition: composites accept structs, map[string]interface{} or []interface{},
+// enums accept EnumValue, the variant name or index, Option<T> accepts nil for None.
+func (r *Registry) Encode(buf *bytes.Buffer, id int64, value interface{}) error {
+	return r.encode(scale.NewEncoder(buf), id, value)
+}
+
+func (r *Registry) EncodeToBytes(id int64, value interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := r.Encode(buf, id, value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode reads a value of the registry type id from the decoder into its dynamic representation.
+func (r *Registry) Decode(decoder *scale.Decoder, id int64) (interface{}, error) {
+	return r.decode(decoder, id)
+}
+
+func (r *Registry) DecodeBytes(id int64, data []byte) (interface{}, error) {
+	return r.decode(scale.NewDecoder(bytes.NewReader(data)), id)
+}
+
+func (r *Registry) encode(encoder *scale.Encoder, id int64, value interface{}) error {
+	if encodeable, ok := value.(scale.Encodeable); ok {
+		return encodeable.Encode(*encoder)
+	}
+
+	t, err := r.Type(id)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.IsValid() && (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && !isBigInt(rv) {
+		if rv.IsNil() {
+			rv = reflect.Value{}
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	def := t.Def
+	switch {
+	case def.Primitive != "":
+		return encodePrimitive(encoder, def.Primitive, rv)
+
+	case def.Compact != nil:
+		v, err := toBigInt(rv)
+		if err != nil {
+			return errors.Wrapf(err, "type %d", id)
+		}
+		return encoder.EncodeUintCompact(*v)
+
+	case def.Sequence != nil:
+		if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array && rv.Kind() != reflect.String) {
+			return errors.Errorf("type %d: expected sequence, got %v", id, describe(rv))
+		}
+		if err := encoder.EncodeUintCompact(*big.NewInt(int64(rv.Len()))); err != nil {
+			return err
+		}
+		return r.encodeItems(encoder, def.Sequence.Type, rv)
+
+	case def.Array != nil:
+		if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array && rv.Kind() != reflect.String) {
+			return errors.Errorf("type %d: expected array, got %v", id, describe(rv))
+		}
+		if rv.Len() != int(def.Array.Len) {
+			return errors.Errorf("type %d: expected array of %d items, got %d", id, def.Array.Len, rv.Len())
+		}
+		return r.encodeItems(encoder, def.Array.Type, rv)
+
+	case def.Tuple != nil:
+		items, err := positional(rv, len(def.Tuple))
+		if err != nil {
+			return errors.Wrapf(err, "type %d", id)
+		}
+		for i, itemType := range def.Tuple {
+			if err := r.encode(encoder, itemType, items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case def.Composite != nil:
+		return r.encodeFields(encoder, id, def.Composite.Fields, rv)
+
+	case def.Variant != nil:
+		return r.encodeVariant(encoder, t, rv)
+
+	case def.BitSequence != nil:
+		return errors.Errorf("type %d: bit sequences are not supported", id)
+	}
+
+	// Empty tuple, the unit type.
+	return nil
+}
+
+func (r *Registry) encodeItems(encoder *scale.Encoder, itemType int64, rv reflect.Value) error {
+	if rv.Kind() == reflect.String {
+		rv = reflect.ValueOf([]byte(rv.String()))
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := r.encode(encoder, itemType, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) encodeFields(encoder *scale.Encoder, id int64, fields []Field, rv reflect.Value) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// Newtypes like AccountId([u8; 32]) take the inner value directly.
+	if len(fields) == 1 && !isFieldContainer(rv) && !hasField(rv, fields[0].Name) {
+		return r.encode(encoder, fields[0].Type, valueOf(rv))
+	}
+
+	if rv.IsValid() && rv.Kind() == reflect.Map {
+		for _, f := range fields {
+			item := rv.MapIndex(reflect.ValueOf(f.Name))
+			if !item.IsValid() {
+				item = rv.MapIndex(reflect.ValueOf(camelCase(f.Name)))
+			}
+			if !item.IsValid() {
+				return errors.Errorf("type %d: field %s is missing", id, f.Name)
+			}
+			if err := r.encode(encoder, f.Type, item.Interface()); err != nil {
+				return errors.Wrapf(err, "field %s", f.Name)
+			}
+		}
+		return nil
+	}
+
+	if rv.IsValid() && rv.Kind() == reflect.Struct && fields[0].Name != "" {
+		for _, f := range fields {
+			item := rv.FieldByNameFunc(func(name string) bool {
+				return strings.EqualFold(name, camelCase(f.Name))
+			})
+			if !item.IsValid() {
+				return errors.Errorf("type %d: field %s is missing in %s", id, f.Name, rv.Type())
+			}
+			if err := r.encode(encoder, f.Type, item.Interface()); err != nil {
+				return errors.Wrapf(err, "field %s", f.Name)
+			}
+		}
+		return nil
+	}
+
+	items, err := positional(rv, len(fields))
+	if err != nil {
+		return errors.Wrapf(err, "type %d", id)
+	}
+	for i, f := range fields {
+		if err := r.encode(encoder, f.Type, items[i]); err != nil {
+			return errors.Wrapf(err, "field %d", i)
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) encodeVariant(encoder *scale.Encoder, t *Type, rv reflect.Value) error {
+	variants := t.Def.Variant.Variants
+
+	var variant *Variant
+	var fields interface{}
+	switch {
+	case !rv.IsValid() && t.Name() == "Option":
+		variant = findVariant(variants, "None")
+	case rv.IsValid() && rv.Type() == reflect.TypeOf(EnumValue{}):
+		enum := rv.Interface().(EnumValue)
+		fields = enum.Fields
+		if enum.Name != "" {
+			variant = findVariant(variants, enum.Name)
+		} else {
+			variant = findVariantByIndex(variants, enum.Index)
+		}
+	case t.Name() == "Option":
+		variant = findVariant(variants, "Some")
+		fields = rv.Interface()
+	case rv.IsValid() && rv.Kind() == reflect.String:
+		variant = findVariant(variants, rv.String())
+	case rv.IsValid() && rv.CanUint():
+		variant = findVariantByIndex(variants, uint8(rv.Uint()))
+	case rv.IsValid() && rv.CanInt():
+		variant = findVariantByIndex(variants, uint8(rv.Int()))
+	}
+	if variant == nil {
+		return errors.Errorf("type %d: can't match %v to a variant of %s", t.Id, describe(rv), t.Name())
+	}
+
+	if err := encoder.PushByte(variant.Index); err != nil {
+		return err
+	}
+
+	return r.encodeFields(encoder, t.Id, variant.Fields, reflect.ValueOf(fields))
+}
+
+func (r *Registry) decode(decoder *scale.Decoder, id int64) (interface{}, error) {
+	t, err := r.Type(id)
+	if err != nil {
+		return nil, err
+	}
+
+	def := t.Def
+	switch {
+	case def.Primitive != "":
+		return decodePrimitive(decoder, def.Primitive)
+
+	case def.Compact != nil:
+		v, err := decoder.DecodeUintCompact()
+		if err != nil {
+			return nil, err
+		}
+		if v.IsUint64() {
+			return v.Uint64(), nil
+		}
+		return v, nil
+
+	case def.Sequence != nil:
+		l, err := decoder.DecodeUintCompact()
+		if err != nil {
+			return nil, err
+		}
+		if !l.IsUint64() || l.Uint64() > math.MaxInt32 {
+			return nil, errors.Errorf("type %d: sequence length %v out of range", id, l)
+		}
+		return r.decodeItems(decoder, def.Sequence.Type, int(l.Uint64()))
+
+	case def.Array != nil:
+		return r.decodeItems(decoder, def.Array.Type, int(def.Array.Len))
+
+	case def.Tuple != nil:
+		if len(def.Tuple) == 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, len(def.Tuple))
+		for i, itemType := range def.Tuple {
+			if items[i], err = r.decode(decoder, itemType); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+
+	case def.Composite != nil:
+		return r.decodeFields(decoder, def.Composite.Fields)
+
+	case def.Variant != nil:
+		index, err := decoder.ReadOneByte()
+		if err != nil {
+			return nil, err
+		}
+		variant := findVariantByIndex(def.Variant.Variants, index)
+		if variant == nil {
+			return nil, errors.Errorf("type %d: unknown variant index %d of %s", id, index, t.Name())
+		}
+		fields, err := r.decodeFields(decoder, variant.Fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "variant %s", variant.Name)
+		}
+		return EnumValue{Name: variant.Name, Index: variant.Index, Fields: fields}, nil
+
+	case def.BitSequence != nil:
+		return nil, errors.Errorf("type %d: bit sequences are not supported", id)
+	}
+
+	return nil, nil
+}
+
+// decodeItemsChunk bounds what is allocated for the items ahead of reading them. The lengths come from the input, a
+// length beyond the remaining input fails once the input runs out instead of allocating the whole length upfront.
+const decodeItemsChunk = 64 * 1024
+
+func (r *Registry) decodeItems(decoder *scale.Decoder, itemType int64, l int) (interface{}, error) {
+	if r.isByte(itemType) {
+		data := make([]byte, 0, minInt(l, decodeItemsChunk))
+		for len(data) < l {
+			n := minInt(l-len(data), decodeItemsChunk)
+			data = append(data, make([]byte, n)...)
+			if err := decoder.Read(data[len(data)-n:]); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	}
+
+	items := make([]interface{}, 0, minInt(l, decodeItemsChunk))
+	for i := 0; i < l; i++ {
+		item, err := r.decode(decoder, itemType)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *Registry) decodeFields(decoder *scale.Decoder, fields []Field) (interface{}, error) {
+	switch {
+	case len(fields) == 0:
+		return nil, nil
+	case len(fields) == 1 && fields[0].Name == "":
+		return r.decode(decoder, fields[0].Type)
+	case fields[0].Name == "":
+		items := make([]interface{}, len(fields))
+		for i, f := range fields {
+			item, err := r.decode(decoder, f.Type)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		item, err := r.decode(decoder, f.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s", f.Name)
+		}
+		values[f.Name] = item
+	}
+
+	return values, nil
+}
+
+func (r *Registry) isByte(id int64) bool {
+	t, ok := r.types[id]
+	return ok && t.Def.Primitive == "u8"
+}
+
+func encodePrimitive(encoder *scale.Encoder, primitive string, rv reflect.Value) error {
+	if !rv.IsValid() {
+		return errors.Errorf("can't encode nil as %s", primitive)
+	}
+
+	switch primitive {
+	case "bool":
+		if rv.Kind() != reflect.Bool {
+			return errors.Errorf("expected bool, got %v", describe(rv))
+		}
+		return encoder.Encode(rv.Bool())
+	case "str":
+		if rv.Kind() == reflect.String {
+			return encoder.Encode(rv.String())
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return encoder.Encode(rv.Bytes())
+		}
+		return errors.Errorf("expected string, got %v", describe(rv))
+	case "char":
+		v, err := toBigInt(rv)
+		if err != nil {
+			return err
+		}
+		data, err := toLittleEndian(v, 4, false)
+		if err != nil {
+			return errors.Wrap(err, primitive)
+		}
+		return encoder.Write(data)
+	}
+
+	size, signed, err := primitiveSize(primitive)
+	if err != nil {
+		return err
+	}
+
+	v, err := toBigInt(rv)
+	if err != nil {
+		return err
+	}
+
+	data, err := toLittleEndian(v, size, signed)
+	if err != nil {
+		return errors.Wrap(err, primitive)
+	}
+
+	return encoder.Write(data)
+}
+
+func decodePrimitive(decoder *scale.Decoder, primitive string) (interface{}, error) {
+	switch primitive {
+	case "bool":
+		var v bool
+		err := decoder.Decode(&v)
+		return v, err
+	case "str":
+		var v string
+		err := decoder.Decode(&v)
+		return v, err
+	case "char":
+		var v uint32
+		err := decoder.Decode(&v)
+		return rune(v), err
+	}
+
+	size, signed, err := primitiveSize(primitive)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, size)
+	if err := decoder.Read(data); err != nil {
+		return nil, err
+	}
+	v := fromLittleEndian(data, signed)
+
+	switch primitive {
+	case "u8":
+		return uint8(v.Uint64()), nil
+	case "u16":
+		return uint16(v.Uint64()), nil
+	case "u32":
+		return uint32(v.Uint64()), nil
+	case "u64":
+		return v.Uint64(), nil
+	case "i8":
+		return int8(v.Int64()), nil
+	case "i16":
+		return int16(v.Int64()), nil
+	case "i32":
+		return int32(v.Int64()), nil
+	case "i64":
+		return v.Int64(), nil
+	}
+
+	return v, nil
+}
+
+func primitiveSize(primitive string) (size int, signed bool, err error) {
+	switch primitive {
+	case "u8", "i8":
+		size = 1
+	case "u16", "i16":
+		size = 2
+	case "u32", "i32":
+		size = 4
+	case "u64", "i64":
+		size = 8
+	case "u128", "i128":
+		size = 16
+	case "u256", "i256":
+		size = 32
+	default:
+		return 0, false, errors.Errorf("unknown primitive %s", primitive)
+	}
+
+	return size, strings.HasPrefix(primitive, "i"), nil
+}
+
+func toLittleEndian(v *big.Int, size int, signed bool) ([]byte, error) {
+	bits := size * 8
+	switch {
+	case !signed && v.Sign() < 0:
+		return nil, errors.Errorf("negative value %v of an unsigned integer", v)
+	case !signed && v.BitLen() > bits:
+		return nil, errors.Errorf("value %v overflows %d bits", v, bits)
+	case signed && v.Sign() >= 0 && v.BitLen() > bits-1:
+		return nil, errors.Errorf("value %v overflows %d bits", v, bits)
+	case signed && v.Sign() < 0 && new(big.Int).Not(v).BitLen() > bits-1:
+		// ^v is -v-1, the magnitude of the most negative value in bits is 2^(bits-1).
+		return nil, errors.Errorf("value %v overflows %d bits", v, bits)
+	}
+
+	if v.Sign() < 0 {
+		// Two's complement of the negative value in size bytes.
+		v = new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	}
+
+	data := make([]byte, size)
+	v.FillBytes(data)
+	scale.Reverse(data)
+	return data, nil
+}
+
+func fromLittleEndian(data []byte, signed bool) *big.Int {
+	be := make([]byte, len(data))
+	copy(be, data)
+	scale.Reverse(be)
+
+	v := new(big.Int).SetBytes(be)
+	if signed && len(be) > 0 && be[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(be)*8)))
+	}
+
+	return v
+}
+
+func toBigInt(rv reflect.Value) (*big.Int, error) {
+	if !rv.IsValid() {
+		return nil, errors.New("can't encode nil as integer")
+	}
+
+	if isBigInt(rv) {
+		if rv.Kind() == reflect.Ptr {
+			return rv.Interface().(*big.Int), nil
+		}
+		v := rv.Interface().(big.Int)
+		return &v, nil
+	}
+
+	switch {
+	case rv.CanUint():
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	case rv.CanInt():
+		return big.NewInt(rv.Int()), nil
+	}
+
+	return nil, errors.Errorf("expected integer, got %v", describe(rv))
+}
+
+func isBigInt(rv reflect.Value) bool {
+	t := rv.Type()
+	return t == reflect.TypeOf(big.Int{}) || t == reflect.TypeOf(&big.Int{})
+}
+
+// isFieldContainer tells whether the value carries composite fields itself rather than being the only field value.
+func isFieldContainer(rv reflect.Value) bool {
+	if !rv.IsValid() {
+		return false
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice:
+		return rv.Type().Elem().Kind() == reflect.Interface
+	}
+
+	return false
+}
+
+func hasField(rv reflect.Value, name string) bool {
+	if name == "" || !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return false
+	}
+
+	return rv.FieldByNameFunc(func(fieldName string) bool {
+		return strings.EqualFold(fieldName, camelCase(name))
+	}).IsValid()
+}
+
+func positional(rv reflect.Value, n int) ([]interface{}, error) {
+	if !rv.IsValid() {
+		return nil, errors.Errorf("expected %d values, got nil", n)
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Len() != n {
+			return nil, errors.Errorf("expected %d values, got %d", n, rv.Len())
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return items, nil
+	case reflect.Struct:
+		if rv.NumField() != n {
+			return nil, errors.Errorf("expected %d fields, got %d in %s", n, rv.NumField(), rv.Type())
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = rv.Field(i).Interface()
+		}
+		return items, nil
+	}
+
+	return nil, errors.Errorf("expected %d values, got %v", n, describe(rv))
+}
+
+func findVariant(variants []Variant, name string) *Variant {
+	for i := range variants {
+		if variants[i].Name == name {
+			return &variants[i]
+		}
+	}
+
+	return nil
+}
+
+func findVariantByIndex(variants []Variant, index uint8) *Variant {
+	for i := range variants {
+		if variants[i].Index == index {
+			return &variants[i]
+		}
+	}
+
+	return nil
+}
+
+func valueOf(rv reflect.Value) interface{} {
+	if !rv.IsValid() {
+		return nil
+	}
+
+	return rv.Interface()
+}
+
+func describe(rv reflect.Value) string {
+	if !rv.IsValid() {
+		return "nil"
+	}
+
+	return rv.Type().String()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,208 @@
+// Package balance parses, formats and computes the CERE balances of the contracts, the bucket.Balance values in the
+// smallest unit of the chain.
+package balance
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/pkg/errors"
+)
+
+// Decimals is the number of decimals of CERE, 1 CERE is pkg.CERE in the smallest unit.
+const Decimals = 10
+
+const usdSymbol = "USD"
+
+var (
+	CERE      = Unit{Symbol: "CERE", Exponent: Decimals}
+	MilliCERE = Unit{Symbol: "mCERE", Exponent: Decimals - 3}
+	MicroCERE = Unit{Symbol: "µCERE", Exponent: Decimals - 6}
+
+	ErrOverflow  = errors.New("balance overflow")
+	ErrUnderflow = errors.New("balance underflow")
+
+	units = map[string]Unit{
+		CERE.Symbol:      CERE,
+		MilliCERE.Symbol: MilliCERE,
+		MicroCERE.Symbol: MicroCERE,
+		"uCERE":          MicroCERE,
+	}
+	maxBalance = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	one        = big.NewInt(pkg.CERE)
+)
+
+type (
+	// Unit is a denomination of CERE, 1 of the unit is 10^Exponent in the smallest unit.
+	Unit struct {
+		Symbol   string
+		Exponent int
+	}
+
+	// UsdPerCereReader reads the USD price of 1 CERE, e.g. bucket.DdcBucketContractReader.
+	UsdPerCereReader interface {
+		AccountGetUsdPerCere() (types.U128, error)
+	}
+)
+
+// Parse parses a decimal amount followed by its unit, e.g. "12.5 CERE" or "300 mCERE", the amounts without unit are in
+// CERE.
+func Parse(s string) (types.U128, error) {
+	number, symbol := strings.TrimSpace(s), ""
+	if strings.HasPrefix(number, "-") {
+		return types.U128{}, errors.Errorf("negative balance %q", s)
+	}
+	if end := strings.IndexFunc(number, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); end >= 0 {
+		number, symbol = number[:end], strings.TrimSpace(number[end:])
+	}
+
+	unit := CERE
+	if symbol != "" {
+		var ok bool
+		if unit, ok = units[symbol]; !ok {
+			return types.U128{}, errors.Errorf("unknown unit %q of balance %q", symbol, s)
+		}
+	}
+
+	amount, err := parseDecimal(number, unit.Exponent)
+	if err != nil {
+		return types.U128{}, errors.Wrapf(err, "invalid balance %q", s)
+	}
+
+	return checked(amount)
+}
+
+// Format formats the amount in CERE with at most precision decimals, e.g. "12.5 CERE". The decimals past the precision
+// are truncated.
+func Format(amount types.U128, precision int) string {
+	return FormatIn(amount, CERE, precision)
+}
+
+// FormatIn formats the amount in the unit with at most precision decimals, the decimals past the precision are
+// truncated.
+func FormatIn(amount types.U128, unit Unit, precision int) string {
+	return formatDecimal(value(amount), unit.Exponent, precision) + " " + unit.Symbol
+}
+
+// String formats the amount in CERE with all its decimals.
+func String(amount types.U128) string {
+	return Format(amount, Decimals)
+}
+
+func Add(a types.U128, b types.U128) (types.U128, error) {
+	return checked(new(big.Int).Add(value(a), value(b)))
+}
+
+func Sub(a types.U128, b types.U128) (types.U128, error) {
+	return checked(new(big.Int).Sub(value(a), value(b)))
+}
+
+func Mul(amount types.U128, factor uint64) (types.U128, error) {
+	return checked(new(big.Int).Mul(value(amount), new(big.Int).SetUint64(factor)))
+}
+
+// ToUsd converts the amount into USD at the price of 1 CERE returned by AccountGetUsdPerCere, the USD amounts have the
+// decimals of CERE.
+func ToUsd(amount types.U128, usdPerCere types.U128) (types.U128, error) {
+	usd := new(big.Int).Mul(value(amount), value(usdPerCere))
+	return checked(usd.Quo(usd, one))
+}
+
+// FromUsd converts the USD amount into CERE at the price of 1 CERE returned by AccountGetUsdPerCere.
+func FromUsd(usd types.U128, usdPerCere types.U128) (types.U128, error) {
+	if value(usdPerCere).Sign() == 0 {
+		return types.U128{}, errors.New("USD per CERE is not set")
+	}
+
+	amount := new(big.Int).Mul(value(usd), one)
+	return checked(amount.Quo(amount, value(usdPerCere)))
+}
+
+// ConvertToUsd converts the amount into USD at the current price of the contract.
+func ConvertToUsd(reader UsdPerCereReader, amount types.U128) (types.U128, error) {
+	usdPerCere, err := reader.AccountGetUsdPerCere()
+	if err != nil {
+		return types.U128{}, err
+	}
+
+	return ToUsd(amount, usdPerCere)
+}
+
+// ConvertFromUsd converts the USD amount into CERE at the current price of the contract.
+func ConvertFromUsd(reader UsdPerCereReader, usd types.U128) (types.U128, error) {
+	usdPerCere, err := reader.AccountGetUsdPerCere()
+	if err != nil {
+		return types.U128{}, err
+	}
+
+	return FromUsd(usd, usdPerCere)
+}
+
+// FormatUsd formats the USD amount with at most precision decimals, e.g. "0.05 USD".
+func FormatUsd(usd types.U128, precision int) string {
+	return formatDecimal(value(usd), Decimals, precision) + " " + usdSymbol
+}
+
+func checked(amount *big.Int) (types.U128, error) {
+	if amount.Sign() < 0 {
+		return types.U128{}, ErrUnderflow
+	}
+	if amount.Cmp(maxBalance) > 0 {
+		return types.U128{}, ErrOverflow
+	}
+
+	return types.NewU128(*amount), nil
+}
+
+// value returns the integer of the balance, the zero value of types.U128 has none.
+func value(amount types.U128) *big.Int {
+	if amount.Int == nil {
+		return new(big.Int)
+	}
+
+	return amount.Int
+}
+
+func parseDecimal(number string, exponent int) (*big.Int, error) {
+	integer, fraction, _ := strings.Cut(number, ".")
+	if integer == "" && fraction == "" {
+		return nil, errors.New("no amount")
+	}
+	if len(fraction) > exponent {
+		return nil, errors.Errorf("more than %d decimals", exponent)
+	}
+
+	digits := integer + fraction + strings.Repeat("0", exponent-len(fraction))
+	amount, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, errors.Errorf("not a decimal number %q", number)
+	}
+
+	return amount, nil
+}
+
+func formatDecimal(amount *big.Int, exponent int, precision int) string {
+	digits := amount.String()
+	if exponent <= 0 {
+		return digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	integer, fraction := digits[:len(digits)-exponent], digits[len(digits)-exponent:]
+	if precision < 0 {
+		precision = 0
+	}
+	if precision < len(fraction) {
+		fraction = fraction[:precision]
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if fraction == "" {
+		return integer
+	}
+
+	return integer + "." + fraction
+}
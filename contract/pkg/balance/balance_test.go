@@ -0,0 +1,128 @@
+package balance
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type usdPerCereReader struct {
+	usdPerCere types.U128
+	err        error
+}
+
+func (r usdPerCereReader) AccountGetUsdPerCere() (types.U128, error) {
+	return r.usdPerCere, r.err
+}
+
+func smallest(value int64) types.U128 {
+	return types.NewU128(*big.NewInt(value))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    types.U128
+		wantErr string
+	}{
+		{name: "CERE", s: "12.5 CERE", want: smallest(125_000_000_000)},
+		{name: "mCERE", s: "300 mCERE", want: smallest(3_000_000_000)},
+		{name: "µCERE without space", s: "0.5µCERE", want: smallest(5_000)},
+		{name: "uCERE", s: "1 uCERE", want: smallest(10_000)},
+		{name: "no unit", s: " .1 ", want: smallest(1_000_000_000)},
+		{name: "smallest unit", s: "0.0000000001 CERE", want: smallest(1)},
+		{name: "too many decimals", s: "0.00000000001 CERE", wantErr: `invalid balance "0.00000000001 CERE": more than 10 decimals`},
+		{name: "unknown unit", s: "1 DOT", wantErr: `unknown unit "DOT" of balance "1 DOT"`},
+		{name: "negative", s: "-1 CERE", wantErr: `negative balance "-1 CERE"`},
+		{name: "no amount", s: "CERE", wantErr: `invalid balance "CERE": no amount`},
+		{name: "not a number", s: "1.2.3", wantErr: `invalid balance "1.2.3": not a decimal number "1.2.3"`},
+		{name: "overflow", s: "100000000000000000000000000000 CERE", wantErr: "balance overflow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			got, err := Parse(tt.s)
+
+			//then
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	//given
+	amount := smallest(125_123_456_789)
+
+	//then
+	assert.Equal(t, "12.5123456789 CERE", String(amount))
+	assert.Equal(t, "12.51 CERE", Format(amount, 2))
+	assert.Equal(t, "12 CERE", Format(amount, 0))
+	assert.Equal(t, "12512.3456789 mCERE", FormatIn(amount, MilliCERE, 10))
+	assert.Equal(t, "0.0001 CERE", String(smallest(1_000_000)))
+	assert.Equal(t, "0 CERE", String(types.U128{}))
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	//given
+	amount := smallest(7_000_000_001)
+
+	//when
+	parsed, err := Parse(String(amount))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, amount, parsed)
+}
+
+func TestArithmetic(t *testing.T) {
+	//given
+	max := types.NewU128(*new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1)))
+
+	//when
+	sum, errSum := Add(smallest(2), smallest(3))
+	difference, errDifference := Sub(smallest(3), smallest(2))
+	product, errProduct := Mul(smallest(3), 4)
+	_, errOverflow := Add(max, smallest(1))
+	_, errUnderflow := Sub(smallest(2), smallest(3))
+	_, errMulOverflow := Mul(max, 2)
+
+	//then
+	assert.NoError(t, errSum)
+	assert.NoError(t, errDifference)
+	assert.NoError(t, errProduct)
+	assert.Equal(t, smallest(5), sum)
+	assert.Equal(t, smallest(1), difference)
+	assert.Equal(t, smallest(12), product)
+	assert.ErrorIs(t, errOverflow, ErrOverflow)
+	assert.ErrorIs(t, errUnderflow, ErrUnderflow)
+	assert.ErrorIs(t, errMulOverflow, ErrOverflow)
+}
+
+func TestUsd(t *testing.T) {
+	//given
+	reader := usdPerCereReader{usdPerCere: smallest(100_000_000)}
+	amount, _ := Parse("5 CERE")
+
+	//when
+	usd, errUsd := ConvertToUsd(reader, amount)
+	cere, errCere := ConvertFromUsd(reader, usd)
+	_, errUnset := ConvertFromUsd(usdPerCereReader{usdPerCere: smallest(0)}, usd)
+	_, errRead := ConvertToUsd(usdPerCereReader{err: errors.New("read failed")}, amount)
+
+	//then
+	assert.NoError(t, errUsd)
+	assert.NoError(t, errCere)
+	assert.Equal(t, "0.05 USD", FormatUsd(usd, 2))
+	assert.Equal(t, amount, cere)
+	assert.EqualError(t, errUnset, "USD per CERE is not set")
+	assert.EqualError(t, errRead, "read failed")
+}
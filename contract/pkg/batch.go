@@ -0,0 +1,210 @@
+package pkg
+
+import (
+	"context"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/chainevents"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBatchEmpty       = errors.New("batch has no calls")
+	ErrBatchInterrupted = errors.New("batch interrupted")
+	ErrBatchReverted    = errors.New("batch reverted")
+)
+
+type (
+	// BatchCall dispatches the calls in one Utility extrinsic signed by From, the calls are dispatched in order from
+	// the account of From.
+	BatchCall struct {
+		From  signature.KeyringPair
+		Calls []BatchItem
+		// Interruptible dispatches the calls with Utility.batch, the calls before a failed one stay applied, instead of
+		// Utility.batch_all which reverts all of them.
+		Interruptible bool
+	}
+
+	// BatchItem is either a contract call or a pallet call.
+	BatchItem struct {
+		// Contract is a contract call, its From is ignored. Its limits are estimated with a dry run against the state
+		// before the batch unless both GasLimit and ProofSizeLimit are set, e.g. for a call on a node created earlier in
+		// the batch.
+		Contract *ContractCall
+		// Call is the name of the pallet call, e.g. "Balances.transfer", with its Args.
+		Call string
+		Args []interface{}
+	}
+
+	BatchResult struct {
+		ExtrinsicResult
+		// Items are the outcomes of the calls in the order of the batch.
+		Items  []BatchItemResult
+		Events *chainevents.EventRecords
+	}
+
+	BatchItemResult struct {
+		Completed bool
+		// Err is the dispatch error of the call which interrupted the batch, the calls after it are not dispatched.
+		Err error
+	}
+)
+
+// ContractItem adds the contract call to a batch.
+func ContractItem(contractCall ContractCall) BatchItem {
+	return BatchItem{Contract: &contractCall}
+}
+
+// PalletItem adds the pallet call to a batch, e.g. PalletItem("Balances.transfer", dest, value).
+func PalletItem(call string, args ...interface{}) BatchItem {
+	return BatchItem{Call: call, Args: args}
+}
+
+// CallBatch submits the calls in one extrinsic. Once the extrinsic is included the result holds the outcome of each
+// call, it is returned along with ErrBatchInterrupted or ErrBatchReverted when a call fails, or without the outcomes
+// along with ErrExtrinsicIndexUnknown when the extrinsic is not found in its block.
+func (b *blockchainClient) CallBatch(ctx context.Context, batchCall BatchCall) (BatchResult, error) {
+	if len(batchCall.Calls) == 0 {
+		return BatchResult{}, ErrBatchEmpty
+	}
+
+	limits := make([]contractCallLimits, len(batchCall.Calls))
+	for i, item := range batchCall.Calls {
+		if item.Contract == nil {
+			continue
+		}
+		var err error
+		if limits[i], err = b.batchItemLimits(ctx, *item.Contract, batchCall.From.Address); err != nil {
+			return BatchResult{}, errors.Wrapf(err, "call %d", i)
+		}
+	}
+
+	batch := "Utility.batch_all"
+	if batchCall.Interruptible {
+		batch = "Utility.batch"
+	}
+
+	extrinsic, err := withRetryOnClosedNetwork(b, func() (types.Extrinsic, error) {
+		meta, err := b.RPC.State.GetMetadataLatest()
+		if err != nil {
+			return types.Extrinsic{}, errors.Wrap(err, "get metadata lastest")
+		}
+		weightV2, err := b.isWeightV2()
+		if err != nil {
+			return types.Extrinsic{}, err
+		}
+
+		calls := make([]types.Call, 0, len(batchCall.Calls))
+		for i, item := range batchCall.Calls {
+			call, err := newBatchCall(meta, item, limits[i], weightV2)
+			if err != nil {
+				return types.Extrinsic{}, errors.Wrapf(err, "call %d", i)
+			}
+			calls = append(calls, call)
+		}
+		return b.createExtrinsic(batch, batchCall.From, calls)
+	})
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	result, err := withRetryOnClosedNetwork(b, func() (ExtrinsicResult, error) {
+		return b.submitAndWaitExtrinsic(ctx, extrinsic, batchCall.From.Address)
+	})
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	events, err := withRetryOnClosedNetwork(b, func() (*chainevents.EventRecords, error) {
+		return b.blockEvents(result.BlockHash)
+	})
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	items, err := batchOutcomes(events, result.ExtrinsicIndex, len(batchCall.Calls))
+	return BatchResult{ExtrinsicResult: result, Items: items, Events: events}, err
+}
+
+type contractCallLimits struct {
+	gasLimit            Weight
+	storageDepositLimit types.Option[types.UCompact]
+	data                []byte
+}
+
+func (b *blockchainClient) batchItemLimits(ctx context.Context, contractCall ContractCall, origin string) (contractCallLimits, error) {
+	data, err := GetContractData(contractCall.Method, contractCall.Args...)
+	if err != nil {
+		return contractCallLimits{}, err
+	}
+
+	if contractCall.GasLimit > 0 && contractCall.ProofSizeLimit > 0 {
+		storageDepositLimit := types.NewEmptyOption[types.UCompact]()
+		if contractCall.StorageDepositLimit > 0 {
+			storageDepositLimit = types.NewOption(types.NewUCompactFromUInt(contractCall.StorageDepositLimit))
+		}
+		gasLimit := Weight{RefTime: contractCall.GasLimit, ProofSize: contractCall.ProofSizeLimit}
+		return contractCallLimits{gasLimit: gasLimit, storageDepositLimit: storageDepositLimit, data: data}, nil
+	}
+
+	contractCall.From.Address = origin
+	gasLimit, storageDepositLimit, err := b.estimateContractCall(ctx, contractCall, data)
+	if err != nil {
+		return contractCallLimits{}, err
+	}
+
+	return contractCallLimits{gasLimit: gasLimit, storageDepositLimit: storageDepositLimit, data: data}, nil
+}
+
+func newBatchCall(meta *types.Metadata, item BatchItem, limits contractCallLimits, weightV2 bool) (types.Call, error) {
+	if item.Contract == nil {
+		call, err := types.NewCall(meta, item.Call, item.Args...)
+		return call, errors.Wrap(err, "new call error")
+	}
+
+	dest := types.MultiAddress{IsID: true, AsID: item.Contract.ContractAddress}
+	value := types.NewUCompactFromUInt(item.Contract.Value)
+	call, err := types.NewCall(meta, "Contracts.call", dest, value, limits.gasLimit.encode(weightV2), limits.storageDepositLimit, limits.data)
+	return call, errors.Wrap(err, "new call error")
+}
+
+// batchOutcomes derives the outcome of the calls of the batch from the Utility events of the extrinsic. The events of
+// a reverted Utility.batch_all are discarded, only its System.ExtrinsicFailed is left. The outcomes are unknown when
+// the extrinsic couldn't be located in its block, the events of the other extrinsics can't be told apart.
+func batchOutcomes(events *chainevents.EventRecords, extrinsicIndex uint32, calls int) ([]BatchItemResult, error) {
+	if extrinsicIndex == 0 {
+		return nil, ErrExtrinsicIndexUnknown
+	}
+	inExtrinsic := func(phase chainevents.Phase) bool {
+		return phase.IsApplyExtrinsic && phase.AsApplyExtrinsic == extrinsicIndex
+	}
+
+	items := make([]BatchItemResult, calls)
+	completed := 0
+	for _, e := range events.Utility_ItemCompleted {
+		if inExtrinsic(e.Phase) && completed < calls {
+			items[completed].Completed = true
+			completed++
+		}
+	}
+
+	for _, e := range events.Utility_BatchInterrupted {
+		if !inExtrinsic(e.Phase) {
+			continue
+		}
+		err := dispatchError(e.DispatchError)
+		if index := int(e.Index); index < calls {
+			items[index].Err = err
+		}
+		return items, errors.Wrapf(ErrBatchInterrupted, "call %d: %s", e.Index, err)
+	}
+
+	for _, e := range events.System_ExtrinsicFailed {
+		if inExtrinsic(e.Phase) {
+			return items, errors.Wrap(ErrBatchReverted, dispatchError(e.DispatchError).Error())
+		}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/chainevents"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchOutcomes(t *testing.T) {
+	//given
+	inExtrinsic := func(index uint32) chainevents.Phase {
+		return chainevents.Phase{IsApplyExtrinsic: true, AsApplyExtrinsic: index}
+	}
+	moduleError := types.DispatchError{IsModule: true, ModuleError: types.ModuleError{Index: 8, Error: [4]types.U8{11}}}
+	events := &chainevents.EventRecords{
+		Utility_ItemCompleted: []chainevents.EventUtilityItemCompleted{
+			{Phase: inExtrinsic(1)}, {Phase: inExtrinsic(2)}, {Phase: inExtrinsic(2)}, {Phase: inExtrinsic(3)},
+		},
+		Utility_BatchInterrupted: []chainevents.EventUtilityBatchInterrupted{
+			{Phase: inExtrinsic(3), Index: 1, DispatchError: moduleError},
+		},
+		System_ExtrinsicFailed: []chainevents.EventSystemExtrinsicFailed{
+			{Phase: inExtrinsic(4), DispatchError: types.DispatchError{IsBadOrigin: true}},
+		},
+	}
+
+	//when
+	completed, errCompleted := batchOutcomes(events, 2, 2)
+	interrupted, errInterrupted := batchOutcomes(events, 3, 3)
+	reverted, errReverted := batchOutcomes(events, 4, 2)
+	unknown, errUnknown := batchOutcomes(events, 0, 2)
+
+	//then
+	assert.NoError(t, errCompleted)
+	assert.ErrorIs(t, errUnknown, ErrExtrinsicIndexUnknown)
+	assert.Nil(t, unknown)
+	assert.Equal(t, []BatchItemResult{{Completed: true}, {Completed: true}}, completed)
+
+	assert.ErrorIs(t, errInterrupted, ErrBatchInterrupted)
+	assert.EqualError(t, errInterrupted, "call 1: module 8 error [11 0 0 0]: batch interrupted")
+	assert.True(t, interrupted[0].Completed)
+	assert.False(t, interrupted[1].Completed)
+	assert.EqualError(t, interrupted[1].Err, "module 8 error [11 0 0 0]")
+	assert.Equal(t, BatchItemResult{}, interrupted[2])
+
+	assert.ErrorIs(t, errReverted, ErrBatchReverted)
+	assert.EqualError(t, errReverted, "bad origin: batch reverted")
+	assert.Equal(t, []BatchItemResult{{}, {}}, reverted)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
@@ -104,26 +105,45 @@ const (
 )
 
 type (
+	// DdcBucketContractReader reads the contract state.
+	DdcBucketContractReader interface {
+		AccountGetUsdPerCere() (Balance, error)
+		GetAccounts() ([]AccountId, error)
+		BucketGet(bucketId BucketId) (*BucketInfo, error)
+		BucketList(offset types.U32, limit types.U32, ownerId types.OptionAccountID) (*BucketListInfo, error)
+		BucketListForAccount(ownerId AccountId) ([]Bucket, error)
+		ClusterGet(clusterId ClusterId) (*ClusterInfo, error)
+		ClusterList(offset types.U32, limit types.U32, filterManagerId types.OptionAccountID) (*ClusterListInfo, error)
+		NodeGet(nodeKey NodeKey) (*NodeInfo, error)
+		NodeList(offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*NodeListInfo, error)
+		CdnNodeGet(nodeKey CdnNodeKey) (*CdnNodeInfo, error)
+		CdnNodeList(offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*CdnNodeListInfo, error)
+		AccountGet(account AccountId) (*Account, error)
+		HasPermission(account AccountId, permission string) (bool, error)
+	}
+
+	// ContractOption adjusts the contract created by CreateDdcBucketContract.
+	ContractOption func(*ddcBucketContract)
+
 	DdcBucketContract interface {
+		DdcBucketContractReader
+		// Reader reads with the context, at the block given by the read options, e.g. pkg.ReadAt.
+		Reader(ctx context.Context, options ...pkg.ReadOption) DdcBucketContractReader
+
 		GetContractAddress() string
 		GetLastAccessTime() time.Time
 
 		AccountDeposit(ctx context.Context, keyPair signature.KeyringPair) error
 		AccountBond(ctx context.Context, keyPair signature.KeyringPair, bondAmount Balance) error
 		AccountUnbond(ctx context.Context, keyPair signature.KeyringPair, bondAmount Cash) error
-		AccountGetUsdPerCere() (Balance, error)
 		AccountSetUsdPerCere(ctx context.Context, keyPair signature.KeyringPair, usdPerCere Balance) error
 		AccountWithdrawUnbonded(ctx context.Context, keyPair signature.KeyringPair) error
-		GetAccounts() ([]AccountId, error)
 
-		BucketGet(bucketId BucketId) (*BucketInfo, error)
 		BucketCreate(ctx context.Context, keyPair signature.KeyringPair, bucketParams BucketParams, clusterId ClusterId, ownerId types.OptionAccountID) (blockHash types.Hash, err error)
 		BucketChangeOwner(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId, ownerId AccountId) error
 		BucketAllocIntoCluster(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId, resource Resource) error
 		BucketSettlePayment(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId) error
 		BucketChangeParams(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId, bucketParams BucketParams) error
-		BucketList(offset types.U32, limit types.U32, ownerId types.OptionAccountID) (*BucketListInfo, error)
-		BucketListForAccount(ownerId AccountId) ([]Bucket, error)
 		BucketSetAvailability(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId, publicAvailability bool) error
 		BucketSetResourceCap(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId, newResourceCap Resource) error
 		GetBucketWriters(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId) ([]AccountId, error)
@@ -133,7 +153,6 @@ type (
 		BucketSetReaderPerm(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId, reader AccountId) error
 		BucketRevokeReaderPerm(ctx context.Context, keyPair signature.KeyringPair, bucketId BucketId, reader AccountId) error
 
-		ClusterGet(clusterId ClusterId) (*ClusterInfo, error)
 		ClusterCreate(ctx context.Context, keyPair signature.KeyringPair, params Params, resourcePerVNode Resource) (blockHash types.Hash, err error)
 		ClusterAddNode(ctx context.Context, keyPair signature.KeyringPair, clusterId ClusterId, nodeKey NodeKey, vNodes [][]Token) error
 		ClusterRemoveNode(ctx context.Context, keyPair signature.KeyringPair, clusterId ClusterId, nodeKey NodeKey) error
@@ -145,36 +164,32 @@ type (
 		ClusterRemove(ctx context.Context, keyPair signature.KeyringPair, clusterId ClusterId) error
 		ClusterSetNodeStatus(ctx context.Context, keyPair signature.KeyringPair, clusterId ClusterId, nodeKey NodeKey, statusInCluster string) error
 		ClusterSetCdnNodeStatus(ctx context.Context, keyPair signature.KeyringPair, clusterId ClusterId, nodeKey CdnNodeKey, statusInCluster string) error
-		ClusterList(offset types.U32, limit types.U32, filterManagerId types.OptionAccountID) (*ClusterListInfo, error)
 
-		NodeGet(nodeKey NodeKey) (*NodeInfo, error)
 		NodeCreate(ctx context.Context, keyPair signature.KeyringPair, nodeKey NodeKey, params Params, capacity Resource, rent Rent) (blockHash types.Hash, err error)
 		NodeRemove(ctx context.Context, keyPair signature.KeyringPair, nodeKey NodeKey) error
 		NodeSetParams(ctx context.Context, keyPair signature.KeyringPair, nodeKey NodeKey, params Params) error
-		NodeList(offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*NodeListInfo, error)
-		CdnNodeGet(nodeKey CdnNodeKey) (*CdnNodeInfo, error)
 		CdnNodeCreate(ctx context.Context, keyPair signature.KeyringPair, nodeKey CdnNodeKey, params CDNNodeParams) error
 		CdnNodeRemove(ctx context.Context, keyPair signature.KeyringPair, nodeKey CdnNodeKey) error
 		CdnNodeSetParams(ctx context.Context, keyPair signature.KeyringPair, nodeKey CdnNodeKey, params CDNNodeParams) error
-		CdnNodeList(offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*CdnNodeListInfo, error)
 
-		AccountGet(account AccountId) (*Account, error)
-		HasPermission(account AccountId, permission string) (bool, error)
 		GrantTrustedManagerPermission(ctx context.Context, keyPair signature.KeyringPair, managerId AccountId) error
 		RevokeTrustedManagerPermission(ctx context.Context, keyPair signature.KeyringPair, managerId AccountId) error
 		AdminGrantPermission(ctx context.Context, keyPair signature.KeyringPair, grantee AccountId, permission string) error
 		AdminRevokePermission(ctx context.Context, keyPair signature.KeyringPair, grantee AccountId, permission string) error
 		AdminTransferNodeOwnership(ctx context.Context, keyPair signature.KeyringPair, nodeKey NodeKey, newOwner AccountId) error
 		AdminTransferCdnNodeOwnership(ctx context.Context, keyPair signature.KeyringPair, nodeKey CdnNodeKey, newOwner AccountId) error
-		AddContractEventHandler(event string, handler func(interface{})) error
+		AddContractEventHandler(event string, handler pkg.ContractEventHandler) (unregister func(), err error)
 		GetEventDispatcher() map[types.Hash]pkg.ContractEventDispatchEntry
 	}
 
 	ddcBucketContract struct {
 		chainClient                            pkg.BlockchainClient
-		lastAccessTime                         time.Time
+		lastAccessTime                         *int64
+		readCtx                                context.Context
+		readOptions                            []pkg.ReadOption
 		contractAddressSS58                    string
 		keyringPair                            signature.KeyringPair
+		errorTable                             ErrorTable
 		nodeCreateMethodId                     []byte
 		nodeRemoveMethodId                     []byte
 		nodeSetParamsMethodId                  []byte
@@ -231,6 +246,7 @@ type (
 		bucketRevokeReaderPermMethodId         []byte
 
 		eventDispatcher map[types.Hash]pkg.ContractEventDispatchEntry
+		eventHandlers   map[types.Hash]*pkg.ContractEventHandlers
 	}
 )
 
@@ -271,7 +287,14 @@ const (
 	DEFAULT_GAS_LIMIT uint64 = 500_000 * pkg.MGAS
 )
 
-func CreateDdcBucketContract(client pkg.BlockchainClient, contractAddressSS58 string) DdcBucketContract {
+// WithErrorTable decodes the errors of the contract with the table of its version, ErrorTableV1 by default.
+func WithErrorTable(errorTable ErrorTable) ContractOption {
+	return func(d *ddcBucketContract) {
+		d.errorTable = errorTable
+	}
+}
+
+func CreateDdcBucketContract(client pkg.BlockchainClient, contractAddressSS58 string, options ...ContractOption) DdcBucketContract {
 	bucketGetMethodId, err := hex.DecodeString(bucketGetMethod)
 	if err != nil {
 		log.WithError(err).WithField("method", bucketGetMethod).Fatal("Can't decode method bucketGetMethod")
@@ -543,16 +566,20 @@ func CreateDdcBucketContract(client pkg.BlockchainClient, contractAddressSS58 st
 	}
 
 	eventDispatcher := make(map[types.Hash]pkg.ContractEventDispatchEntry)
+	eventHandlers := make(map[types.Hash]*pkg.ContractEventHandlers)
 	for k, v := range eventDispatchTable {
 		if eventKey, err := types.NewHashFromHexString(k); err != nil {
 			log.WithError(err).WithField("hash", k).Fatalf("Bad event hash for event %s", v.Name())
 		} else {
-			eventDispatcher[eventKey] = pkg.ContractEventDispatchEntry{ArgumentType: v}
+			handlers := &pkg.ContractEventHandlers{}
+			eventHandlers[eventKey] = handlers
+			eventDispatcher[eventKey] = pkg.ContractEventDispatchEntry{ArgumentType: v, Handler: handlers.Handle}
 		}
 	}
 
-	return &ddcBucketContract{
+	contract := &ddcBucketContract{
 		chainClient:                            client,
+		lastAccessTime:                         new(int64),
 		contractAddressSS58:                    contractAddressSS58,
 		keyringPair:                            signature.KeyringPair{Address: contractAddressSS58},
 		bucketGetMethodId:                      bucketGetMethodId,
@@ -588,6 +615,7 @@ func CreateDdcBucketContract(client pkg.BlockchainClient, contractAddressSS58 st
 		adminTransferNodeOwnershipMethodId:     adminTransferNodeOwnershipMethodId,
 		adminTransferCdnNodeOwnershipMethodId:  adminTransferCdnNodeOwnershipMethodId,
 		eventDispatcher:                        eventDispatcher,
+		eventHandlers:                          eventHandlers,
 		accountDepositMethodId:                 accountDepositMethodId,
 		accountBondMethodId:                    accountBondMethodId,
 		accountUnbondMethodId:                  accountUnbondMethodId,
@@ -610,7 +638,13 @@ func CreateDdcBucketContract(client pkg.BlockchainClient, contractAddressSS58 st
 		bucketRevokeWriterPermMethodId:         bucketRevokeWriterPermMethodId,
 		bucketSetReaderPermMethodId:            bucketSetReaderPermMethodId,
 		bucketRevokeReaderPermMethodId:         bucketRevokeReaderPermMethodId,
+		errorTable:                             ErrorTableV1,
 	}
+	for _, option := range options {
+		option(contract)
+	}
+
+	return contract
 }
 
 func (d *ddcBucketContract) BucketGet(bucketId BucketId) (*BucketInfo, error) {
@@ -662,9 +696,9 @@ func (d *ddcBucketContract) callToExec(ctx context.Context, keyPair signature.Ke
 		ContractAddressSS58: d.contractAddressSS58,
 		From:                keyPair,
 		Value:               0,
-		GasLimit:            DEFAULT_GAS_LIMIT,
 		Method:              method,
 		Args:                args,
+		ErrorDecoder:        d.errorTable.errorDecoder(method),
 	}
 
 	blockHash, err := d.chainClient.CallToExec(ctx, call)
@@ -672,20 +706,43 @@ func (d *ddcBucketContract) callToExec(ctx context.Context, keyPair signature.Ke
 		return types.Hash{}, err
 	}
 
-	d.lastAccessTime = time.Now()
+	atomic.StoreInt64(d.lastAccessTime, time.Now().UnixNano())
 
 	return blockHash, nil
 }
 
+func (d *ddcBucketContract) read(method []byte, args ...interface{}) (string, error) {
+	ctx := d.readCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	readCall := pkg.ReadCall{
+		ContractAddressSS58: d.contractAddressSS58,
+		From:                d.contractAddressSS58,
+		Method:              method,
+		Args:                args,
+	}
+	for _, option := range d.readOptions {
+		option(&readCall)
+	}
+
+	data, err := d.chainClient.CallToRead(ctx, readCall)
+	if err != nil {
+		return "", err
+	}
+
+	atomic.StoreInt64(d.lastAccessTime, time.Now().UnixNano())
+
+	return data, nil
+}
+
 func (d *ddcBucketContract) callToRead(result interface{}, method []byte, args ...interface{}) error {
-	data, err := d.chainClient.CallToReadEncoded(d.contractAddressSS58, d.contractAddressSS58, method, args...)
+	data, err := d.read(method, args...)
 	if err != nil {
 		return err
 	}
 
-	d.lastAccessTime = time.Now()
-
-	res := Result{data: result}
+	res := Result{data: result, method: method, errorTable: d.errorTable}
 	if err = res.decodeDdcBucketContract(data); err != nil {
 		return err
 	}
@@ -694,31 +751,34 @@ func (d *ddcBucketContract) callToRead(result interface{}, method []byte, args .
 }
 
 func (d *ddcBucketContract) callToReadNoResult(res interface{}, method []byte, args ...interface{}) error {
-	data, err := d.chainClient.CallToReadEncoded(d.contractAddressSS58, d.contractAddressSS58, method, args...)
+	data, err := d.read(method, args...)
 	if err != nil {
 		return err
 	}
 
-	d.lastAccessTime = time.Now()
-
 	return codec.DecodeFromHex(data, res)
 }
 
-func (d *ddcBucketContract) AddContractEventHandler(event string, handler func(interface{})) error {
+// Reader returns a copy of the contract reading with the context and the options.
+func (d *ddcBucketContract) Reader(ctx context.Context, options ...pkg.ReadOption) DdcBucketContractReader {
+	reader := *d
+	reader.readCtx = ctx
+	reader.readOptions = options
+	return &reader
+}
+
+// AddContractEventHandler adds a handler of the event next to the ones already added. The returned function removes
+// the handler.
+func (d *ddcBucketContract) AddContractEventHandler(event string, handler pkg.ContractEventHandler) (unregister func(), err error) {
 	eventKey, err := types.NewHashFromHexString(event)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	entry, found := d.eventDispatcher[eventKey]
+	handlers, found := d.eventHandlers[eventKey]
 	if !found {
-		return errors.New("Event not found")
-	}
-	if entry.Handler != nil {
-		return errors.New("Contract event handler already set for " + event)
+		return nil, errors.New("Event not found")
 	}
-	entry.Handler = handler
-	d.eventDispatcher[eventKey] = entry
-	return nil
+	return handlers.Add(handler), nil
 }
 
 func (d *ddcBucketContract) GetContractAddress() string {
@@ -726,7 +786,12 @@ func (d *ddcBucketContract) GetContractAddress() string {
 }
 
 func (d *ddcBucketContract) GetLastAccessTime() time.Time {
-	return d.lastAccessTime
+	lastAccessTime := atomic.LoadInt64(d.lastAccessTime)
+	if lastAccessTime == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, lastAccessTime)
 }
 
 func (d *ddcBucketContract) GetEventDispatcher() map[types.Hash]pkg.ContractEventDispatchEntry {
@@ -734,6 +799,9 @@ func (d *ddcBucketContract) GetEventDispatcher() map[types.Hash]pkg.ContractEven
 }
 
 func (d *ddcBucketContract) ClusterCreate(ctx context.Context, keyPair signature.KeyringPair, params Params, resourcePerVNode Resource) (blockHash types.Hash, err error) {
+	if err = ValidateClusterParams(params); err != nil {
+		return types.Hash{}, err
+	}
 	blockHash, err = d.callToExec(ctx, keyPair, d.clusterCreateMethodId, params, resourcePerVNode)
 	return blockHash, err
 }
@@ -769,6 +837,9 @@ func (d *ddcBucketContract) ClusterRemoveCdnNode(ctx context.Context, keyPair si
 }
 
 func (d *ddcBucketContract) ClusterSetParams(ctx context.Context, keyPair signature.KeyringPair, clusterId ClusterId, params Params) error {
+	if err := ValidateClusterParams(params); err != nil {
+		return err
+	}
 	_, err := d.callToExec(ctx, keyPair, d.clusterSetParamsMethodId, clusterId, params)
 	return err
 }
@@ -795,6 +866,9 @@ func (d *ddcBucketContract) ClusterList(offset types.U32, limit types.U32, filte
 }
 
 func (d *ddcBucketContract) NodeCreate(ctx context.Context, keyPair signature.KeyringPair, nodeKey NodeKey, params Params, capacity Resource, rent Rent) (blockHash types.Hash, err error) {
+	if err = ValidateNodeParams(params); err != nil {
+		return types.Hash{}, err
+	}
 	blockHash, err = d.callToExec(ctx, keyPair, d.nodeCreateMethodId, nodeKey, params, capacity, rent)
 	return blockHash, err
 }
@@ -805,6 +879,9 @@ func (d *ddcBucketContract) NodeRemove(ctx context.Context, keyPair signature.Ke
 }
 
 func (d *ddcBucketContract) NodeSetParams(ctx context.Context, keyPair signature.KeyringPair, nodeKey NodeKey, params Params) error {
+	if err := ValidateNodeParams(params); err != nil {
+		return err
+	}
 	_, err := d.callToExec(ctx, keyPair, d.nodeSetParamsMethodId, nodeKey, params)
 	return err
 }
@@ -912,6 +989,9 @@ func (d *ddcBucketContract) GetAccounts() ([]types.AccountID, error) {
 }
 
 func (d *ddcBucketContract) BucketCreate(ctx context.Context, keyPair signature.KeyringPair, bucketParams BucketParams, clusterId ClusterId, ownerId types.OptionAccountID) (blockHash types.Hash, err error) {
+	if err = ValidateBucketParams(bucketParams); err != nil {
+		return types.Hash{}, err
+	}
 	blockHash, err = d.callToExec(ctx, keyPair, d.bucketCreateMethodId, bucketParams, clusterId, ownerId)
 	return blockHash, err
 }
@@ -932,6 +1012,9 @@ func (d *ddcBucketContract) BucketSettlePayment(ctx context.Context, keyPair sig
 }
 
 func (d *ddcBucketContract) BucketChangeParams(ctx context.Context, keyPair signature.KeyringPair, bucketId types.U32, bucketParams BucketParams) error {
+	if err := ValidateBucketParams(bucketParams); err != nil {
+		return err
+	}
 	_, err := d.callToExec(ctx, keyPair, d.bucketChangeParamsMethodId, bucketParams, bucketId)
 	return err
 }
@@ -1,53 +1,14 @@
 package bucket
 
-import "errors"
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
 
-const (
-	nodeDoesNotExist = iota
-	cdnNodeDoesNotExist
-	nodeAlreadyExists
-	cdnNodeAlreadyExists
-	accountDoesNotExist
-	paramsDoesNotExist
-	paramsSizeExceedsLimit
-	onlyOwner
-	onlyNodeProvider
-	onlyCdnNodeProvider
-	onlyClusterManager
-	onlyTrustedClusterManager
-	onlyValidator
-	onlySuperAdmin
-	onlyClusterManagerOrNodeProvider
-	onlyClusterManagerOrCdnNodeProvider
-	unauthorized
-	clusterDoesNotExist
-	clusterIsNotEmpty
-	topologyIsNotCreated
-	topologyAlreadyExists
-	nodesSizeExceedsLimit
-	cdnNodesSizeExceedsLimit
-	vNodesSizeExceedsLimit
-	accountsSizeExceedsLimit
-	nodeIsNotAddedToCluster
-	nodeIsAddedToCluster
-	cdnNodeIsNotAddedToCluster
-	cdnNodeIsAddedToCluster
-	vNodeDoesNotExistsInCluster
-	vNodeIsNotAssignedToNode
-	vNodeIsAlreadyAssignedToNode
-	atLeastOneVNodeHasToBeAssigned
-	nodeProviderIsNotSuperAdmin
-	cdnNodeOwnerIsNotSuperAdmin
-	bucketDoesNotExist
-	bondingPeriodNotFinished
-	transferFailed
-	insufficientBalance
-	insufficientNodeResources
-	insufficientClusterResources
-	eraSettingFailed
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
 )
 
-// ToDo update error regarding latest contract
 var (
 	ErrBucketDoesNotExist                  = errors.New("bucket doesn't exist")
 	ErrTransferFailed                      = errors.New("transfer failed")
@@ -94,93 +55,117 @@ var (
 	ErrEraSettingFailed                    = errors.New("era setting failed")
 )
 
-func parseDdcBucketContractError(error uint8) error {
-	switch error {
-	case bucketDoesNotExist:
-		return ErrBucketDoesNotExist
-	case transferFailed:
-		return ErrTransferFailed
-	case nodeDoesNotExist:
-		return ErrNodeDoesNotExist
-	case cdnNodeDoesNotExist:
-		return ErrCdnNodeDoesNotExist
-	case nodeAlreadyExists:
-		return ErrNodeAlreadyExists
-	case cdnNodeAlreadyExists:
-		return ErrCdnNodeAlreadyExists
-	case accountDoesNotExist:
-		return ErrAccountDoesNotExist
-	case paramsDoesNotExist:
-		return ErrParamsDoesNotExist
-	case paramsSizeExceedsLimit:
-		return ErrParamsSizeExceedsLimit
-	case onlyOwner:
-		return ErrOnlyOwner
-	case onlyNodeProvider:
-		return ErrOnlyNodeProvider
-	case onlyCdnNodeProvider:
-		return ErrOnlyCdnNodeProvider
-	case onlyClusterManager:
-		return ErrOnlyClusterManager
-	case onlyTrustedClusterManager:
-		return ErrOnlyTrustedClusterManager
-	case onlyValidator:
-		return ErrOnlyValidator
-	case onlySuperAdmin:
-		return ErrOnlySuperAdmin
-	case onlyClusterManagerOrNodeProvider:
-		return ErrOnlyClusterManagerOrNodeProvider
-	case onlyClusterManagerOrCdnNodeProvider:
-		return ErrOnlyClusterManagerOrCdnNodeProvider
-	case unauthorized:
-		return ErrUnauthorized
-	case clusterDoesNotExist:
-		return ErrClusterDoesNotExist
-	case clusterIsNotEmpty:
-		return ErrClusterIsNotEmpty
-	case topologyIsNotCreated:
-		return ErrTopologyIsNotCreated
-	case topologyAlreadyExists:
-		return ErrTopologyAlreadyExists
-	case nodesSizeExceedsLimit:
-		return ErrNodesSizeExceedsLimit
-	case cdnNodesSizeExceedsLimit:
-		return ErrCdnNodesSizeExceedsLimit
-	case vNodesSizeExceedsLimit:
-		return ErrVNodesSizeExceedsLimit
-	case accountsSizeExceedsLimit:
-		return ErrAccountsSizeExceedsLimit
-	case nodeIsNotAddedToCluster:
-		return ErrNodeIsNotAddedToCluster
-	case nodeIsAddedToCluster:
-		return ErrNodeIsAddedToCluster
-	case cdnNodeIsNotAddedToCluster:
-		return ErrCdnNodeIsNotAddedToCluster
-	case cdnNodeIsAddedToCluster:
-		return ErrCdnNodeIsAddedToCluster
-	case vNodeDoesNotExistsInCluster:
-		return ErrVNodeDoesNotExistsInCluster
-	case vNodeIsNotAssignedToNode:
-		return ErrVNodeIsNotAssignedToNode
-	case vNodeIsAlreadyAssignedToNode:
-		return ErrVNodeIsAlreadyAssignedToNode
-	case atLeastOneVNodeHasToBeAssigned:
-		return ErrAtLeastOneVNodeHasToBeAssigned
-	case nodeProviderIsNotSuperAdmin:
-		return ErrNodeProviderIsNotSuperAdmin
-	case cdnNodeOwnerIsNotSuperAdmin:
-		return ErrCdnNodeOwnerIsNotSuperAdmin
-	case bondingPeriodNotFinished:
-		return ErrBondingPeriodNotFinished
-	case insufficientBalance:
-		return ErrInsufficientBalance
-	case insufficientNodeResources:
-		return ErrInsufficientNodeResources
-	case insufficientClusterResources:
-		return ErrInsufficientClusterResources
-	case eraSettingFailed:
-		return ErrEraSettingFailed
-	default:
-		return ErrUndefined
+// ErrorTableV1 lists the errors of the DDC bucket contract 1.x in the order of its error enum.
+var ErrorTableV1 = ErrorTable{
+	{Name: "NodeDoesNotExist", Err: ErrNodeDoesNotExist},
+	{Name: "CdnNodeDoesNotExist", Err: ErrCdnNodeDoesNotExist},
+	{Name: "NodeAlreadyExists", Err: ErrNodeAlreadyExists},
+	{Name: "CdnNodeAlreadyExists", Err: ErrCdnNodeAlreadyExists},
+	{Name: "AccountDoesNotExist", Err: ErrAccountDoesNotExist},
+	{Name: "ParamsDoesNotExist", Err: ErrParamsDoesNotExist},
+	{Name: "ParamsSizeExceedsLimit", Err: ErrParamsSizeExceedsLimit},
+	{Name: "OnlyOwner", Err: ErrOnlyOwner},
+	{Name: "OnlyNodeProvider", Err: ErrOnlyNodeProvider},
+	{Name: "OnlyCdnNodeProvider", Err: ErrOnlyCdnNodeProvider},
+	{Name: "OnlyClusterManager", Err: ErrOnlyClusterManager},
+	{Name: "OnlyTrustedClusterManager", Err: ErrOnlyTrustedClusterManager},
+	{Name: "OnlyValidator", Err: ErrOnlyValidator},
+	{Name: "OnlySuperAdmin", Err: ErrOnlySuperAdmin},
+	{Name: "OnlyClusterManagerOrNodeProvider", Err: ErrOnlyClusterManagerOrNodeProvider},
+	{Name: "OnlyClusterManagerOrCdnNodeProvider", Err: ErrOnlyClusterManagerOrCdnNodeProvider},
+	{Name: "Unauthorized", Err: ErrUnauthorized},
+	{Name: "ClusterDoesNotExist", Err: ErrClusterDoesNotExist},
+	{Name: "ClusterIsNotEmpty", Err: ErrClusterIsNotEmpty},
+	{Name: "TopologyIsNotCreated", Err: ErrTopologyIsNotCreated},
+	{Name: "TopologyAlreadyExists", Err: ErrTopologyAlreadyExists},
+	{Name: "NodesSizeExceedsLimit", Err: ErrNodesSizeExceedsLimit},
+	{Name: "CdnNodesSizeExceedsLimit", Err: ErrCdnNodesSizeExceedsLimit},
+	{Name: "VNodesSizeExceedsLimit", Err: ErrVNodesSizeExceedsLimit},
+	{Name: "AccountsSizeExceedsLimit", Err: ErrAccountsSizeExceedsLimit},
+	{Name: "NodeIsNotAddedToCluster", Err: ErrNodeIsNotAddedToCluster},
+	{Name: "NodeIsAddedToCluster", Err: ErrNodeIsAddedToCluster},
+	{Name: "CdnNodeIsNotAddedToCluster", Err: ErrCdnNodeIsNotAddedToCluster},
+	{Name: "CdnNodeIsAddedToCluster", Err: ErrCdnNodeIsAddedToCluster},
+	{Name: "VNodeDoesNotExistsInCluster", Err: ErrVNodeDoesNotExistsInCluster},
+	{Name: "VNodeIsNotAssignedToNode", Err: ErrVNodeIsNotAssignedToNode},
+	{Name: "VNodeIsAlreadyAssignedToNode", Err: ErrVNodeIsAlreadyAssignedToNode},
+	{Name: "AtLeastOneVNodeHasToBeAssigned", Err: ErrAtLeastOneVNodeHasToBeAssigned},
+	{Name: "NodeProviderIsNotSuperAdmin", Err: ErrNodeProviderIsNotSuperAdmin},
+	{Name: "CdnNodeOwnerIsNotSuperAdmin", Err: ErrCdnNodeOwnerIsNotSuperAdmin},
+	{Name: "BucketDoesNotExist", Err: ErrBucketDoesNotExist},
+	{Name: "BondingPeriodNotFinished", Err: ErrBondingPeriodNotFinished},
+	{Name: "TransferFailed", Err: ErrTransferFailed},
+	{Name: "InsufficientBalance", Err: ErrInsufficientBalance},
+	{Name: "InsufficientNodeResources", Err: ErrInsufficientNodeResources},
+	{Name: "InsufficientClusterResources", Err: ErrInsufficientClusterResources},
+	{Name: "EraSettingFailed", Err: ErrEraSettingFailed},
+}
+
+type (
+	// ErrorTable lists the variants of the error enum of a contract version, the index of a variant is its code.
+	ErrorTable []ErrorVariant
+
+	ErrorVariant struct {
+		Name string
+		// Err is the sentinel error the contract errors of the variant match with errors.Is.
+		Err error
+		// Payload is the type of the data carried by the variant, nil if it has none.
+		Payload reflect.Type
 	}
+
+	// ContractError is an error returned by the contract, it matches its sentinel error with errors.Is. The codes
+	// unknown to the error table match ErrUndefined.
+	ContractError struct {
+		Code uint8
+		Name string
+		// Method is the hex selector of the contract method which returned the error, empty if unknown.
+		Method string
+		// Payload is the decoded data of the variant, nil if it has none.
+		Payload interface{}
+		err     error
+	}
+)
+
+func (e *ContractError) Error() string {
+	message := e.err.Error()
+	if e.Payload != nil {
+		message = fmt.Sprintf("%s %+v", message, e.Payload)
+	}
+	if e.Method != "" {
+		message = fmt.Sprintf("%s (method %s)", message, e.Method)
+	}
+
+	return message
+}
+
+func (e *ContractError) Unwrap() error {
+	return e.err
+}
+
+// decode decodes the code of the variant and its payload which follows.
+func (t ErrorTable) decode(method []byte, data []byte) (*ContractError, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty contract error")
+	}
+
+	contractErr := &ContractError{Code: data[0], err: ErrUndefined}
+	if method != nil {
+		contractErr.Method = hex.EncodeToString(method)
+	}
+	if int(data[0]) >= len(t) {
+		return contractErr, nil
+	}
+
+	variant := t[data[0]]
+	contractErr.Name = variant.Name
+	contractErr.err = variant.Err
+	if variant.Payload != nil {
+		payload := reflect.New(variant.Payload)
+		if err := codec.Decode(data[1:], payload.Interface()); err != nil {
+			return nil, fmt.Errorf("can't decode payload of contract error %s: %w", variant.Name, err)
+		}
+		contractErr.Payload = payload.Elem().Interface()
+	}
+
+	return contractErr, nil
 }
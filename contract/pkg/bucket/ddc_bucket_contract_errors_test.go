@@ -0,0 +1,67 @@
+package bucket
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractErrorOfRead(t *testing.T) {
+	//given
+	client := &readClient{result: "0x0123"}
+	contract := CreateDdcBucketContract(client, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+
+	//when
+	_, err := contract.BucketGet(1)
+
+	//then
+	var contractErr *ContractError
+	assert.ErrorIs(t, err, ErrBucketDoesNotExist)
+	assert.True(t, errors.As(err, &contractErr))
+	assert.Equal(t, uint8(0x23), contractErr.Code)
+	assert.Equal(t, "BucketDoesNotExist", contractErr.Name)
+	assert.Equal(t, bucketGetMethod, contractErr.Method)
+	assert.EqualError(t, err, "bucket doesn't exist (method "+bucketGetMethod+")")
+}
+
+func TestContractErrorUndefined(t *testing.T) {
+	//given
+	decoder := ErrorTableV1.errorDecoder(nil)
+
+	//when
+	err := decoder([]byte{0x01, 0xff})
+
+	//then
+	var contractErr *ContractError
+	assert.ErrorIs(t, err, ErrUndefined)
+	assert.True(t, errors.As(err, &contractErr))
+	assert.Equal(t, uint8(0xff), contractErr.Code)
+	assert.Empty(t, contractErr.Name)
+	assert.NoError(t, decoder([]byte{0x00}))
+}
+
+func TestContractErrorWithPayload(t *testing.T) {
+	//given
+	errorTable := ErrorTable{
+		{Name: "BucketDoesNotExist", Err: ErrBucketDoesNotExist},
+		{Name: "NodeIsAddedToCluster", Err: ErrNodeIsAddedToCluster, Payload: reflect.TypeOf(ClusterId(0))},
+	}
+	client := &readClient{result: "0x010107000000"}
+	contract := CreateDdcBucketContract(client, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL", WithErrorTable(errorTable))
+
+	//when
+	_, err := contract.NodeGet(types.AccountID{1})
+	errTruncated := errorTable.errorDecoder(nil)([]byte{0x01, 0x01, 0x07})
+
+	//then
+	var contractErr *ContractError
+	assert.ErrorIs(t, err, ErrNodeIsAddedToCluster)
+	assert.True(t, errors.As(err, &contractErr))
+	assert.Equal(t, ClusterId(7), contractErr.Payload)
+	assert.EqualError(t, err, "node is added to cluster 7 (method "+nodeGetMethod+")")
+	assert.Error(t, errTruncated)
+	assert.False(t, errors.Is(errTruncated, ErrNodeIsAddedToCluster))
+}
@@ -0,0 +1,114 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddContractEventHandler(t *testing.T) {
+	//given
+	contract := CreateDdcBucketContract(nil, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+	errHandler := errors.New("handler")
+	var received []BucketId
+	var blocks []types.BlockNumber
+	_, errCache := contract.AddContractEventHandler(BucketCreatedEventId, func(event pkg.ContractEventContext, raw interface{}) error {
+		received = append(received, raw.(*BucketCreatedEvent).BucketId)
+		blocks = append(blocks, event.BlockNumber)
+		return nil
+	})
+	unregister, errLogic := contract.AddContractEventHandler(BucketCreatedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
+		return errHandler
+	})
+	eventKey, _ := types.NewHashFromHexString(BucketCreatedEventId)
+	entry := contract.GetEventDispatcher()[eventKey]
+
+	//when
+	errBefore := entry.Handler(pkg.ContractEventContext{BlockNumber: 10}, &BucketCreatedEvent{BucketId: 1})
+	unregister()
+	errAfter := entry.Handler(pkg.ContractEventContext{BlockNumber: 11}, &BucketCreatedEvent{BucketId: 2})
+	_, errUnknown := contract.AddContractEventHandler("0x00", func(pkg.ContractEventContext, interface{}) error { return nil })
+
+	//then
+	assert.NoError(t, errCache)
+	assert.NoError(t, errLogic)
+	assert.ErrorIs(t, errBefore, errHandler)
+	assert.NoError(t, errAfter)
+	assert.Equal(t, []BucketId{1, 2}, received)
+	assert.Equal(t, []types.BlockNumber{10, 11}, blocks)
+	assert.Error(t, errUnknown)
+}
+
+type readClient struct {
+	pkg.BlockchainClient
+	ctx      context.Context
+	readCall pkg.ReadCall
+	result   string
+}
+
+func (c *readClient) CallToRead(ctx context.Context, readCall pkg.ReadCall) (string, error) {
+	c.ctx = ctx
+	c.readCall = readCall
+	return c.result, nil
+}
+
+func TestReader(t *testing.T) {
+	//given
+	result, _ := codec.EncodeToHex(BucketListInfo{Total: 3})
+	client := &readClient{result: result}
+	contract := CreateDdcBucketContract(client, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	blockHash := types.Hash{1}
+
+	//when
+	pinned, errPinned := contract.Reader(ctx, pkg.ReadAt(blockHash)).BucketList(0, 10, types.OptionAccountID{})
+	pinnedCall, pinnedCtx := client.readCall, client.ctx
+	best, errBest := contract.BucketList(0, 10, types.OptionAccountID{})
+
+	//then
+	assert.NoError(t, errPinned)
+	assert.NoError(t, errBest)
+	assert.Equal(t, types.U32(3), pinned.Total)
+	assert.Equal(t, types.U32(3), best.Total)
+	assert.Equal(t, &blockHash, pinnedCall.At)
+	assert.Equal(t, ctx, pinnedCtx)
+	assert.Nil(t, client.readCall.At)
+	assert.Equal(t, context.Background(), client.ctx)
+	assert.False(t, contract.GetLastAccessTime().IsZero())
+}
+
+type resultClient struct {
+	pkg.BlockchainClient
+	result string
+}
+
+func (c *resultClient) CallToRead(context.Context, pkg.ReadCall) (string, error) {
+	return c.result, nil
+}
+
+func TestConcurrentReaders(t *testing.T) {
+	//given
+	result, _ := codec.EncodeToHex(BucketListInfo{Total: 3})
+	contract := CreateDdcBucketContract(&resultClient{result: result}, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+	errs := make(chan error, 8)
+
+	//when
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := contract.Reader(context.Background()).BucketList(0, 10, types.OptionAccountID{})
+			errs <- err
+		}()
+	}
+
+	//then
+	for i := 0; i < cap(errs); i++ {
+		assert.NoError(t, <-errs)
+	}
+	assert.False(t, contract.GetLastAccessTime().IsZero())
+}
@@ -0,0 +1,325 @@
+package bucket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+)
+
+const (
+	DEFAULT_PAGE_SIZE        = 100
+	DEFAULT_LIST_CONCURRENCY = 4
+)
+
+type (
+	// Iterator walks a contract listing page by page, the pages are fetched lazily while the iteration goes on.
+	//
+	//	it := bucket.Buckets(ctx, contract, types.OptionAccountID{})
+	//	for it.Next() {
+	//		bucketInfo := it.Value()
+	//	}
+	//	if err := it.Err(); err != nil {
+	//		...
+	//	}
+	Iterator[T any] struct {
+		ctx      context.Context
+		reader   DdcBucketContractReader
+		fetch    pageFetcher[T]
+		pageSize uint32
+		prefetch bool
+
+		page      []T
+		position  int
+		current   T
+		offset    uint32
+		exhausted bool
+		pending   chan pageResult[T]
+		err       error
+	}
+
+	ListOption func(*listOptions)
+
+	listOptions struct {
+		pageSize    uint32
+		prefetch    bool
+		concurrency int
+	}
+
+	// pageFetcher reads the items of the listing in [offset, offset+limit) and the total size of the listing.
+	pageFetcher[T any] func(reader DdcBucketContractReader, offset, limit uint32) ([]T, uint32, error)
+
+	pageResult[T any] struct {
+		items []T
+		total uint32
+		err   error
+	}
+)
+
+// WithPageSize sets the number of entries requested by one contract call.
+func WithPageSize(size uint32) ListOption {
+	return func(o *listOptions) {
+		if size > 0 {
+			o.pageSize = size
+		}
+	}
+}
+
+// WithPrefetch makes the iterator request the next page while the current one is consumed.
+func WithPrefetch() ListOption {
+	return func(o *listOptions) {
+		o.prefetch = true
+	}
+}
+
+// WithConcurrency limits the number of pages requested at the same time by the Collect functions.
+func WithConcurrency(concurrency int) ListOption {
+	return func(o *listOptions) {
+		if concurrency > 0 {
+			o.concurrency = concurrency
+		}
+	}
+}
+
+// Buckets iterates the buckets, of the owner if it is set.
+func Buckets(ctx context.Context, contract DdcBucketContract, ownerId types.OptionAccountID, options ...ListOption) *Iterator[BucketInfo] {
+	return newIterator(ctx, contract, bucketPages(ownerId), options)
+}
+
+// Clusters iterates the clusters, of the manager if it is set.
+func Clusters(ctx context.Context, contract DdcBucketContract, managerId types.OptionAccountID, options ...ListOption) *Iterator[ClusterInfo] {
+	return newIterator(ctx, contract, clusterPages(managerId), options)
+}
+
+// Nodes iterates the storage nodes, of the provider if it is set.
+func Nodes(ctx context.Context, contract DdcBucketContract, providerId types.OptionAccountID, options ...ListOption) *Iterator[NodeInfo] {
+	return newIterator(ctx, contract, nodePages(providerId), options)
+}
+
+// CdnNodes iterates the CDN nodes, of the provider if it is set.
+func CdnNodes(ctx context.Context, contract DdcBucketContract, providerId types.OptionAccountID, options ...ListOption) *Iterator[CdnNodeInfo] {
+	return newIterator(ctx, contract, cdnNodePages(providerId), options)
+}
+
+// CollectBuckets reads all the buckets, of the owner if it is set, requesting the pages concurrently.
+func CollectBuckets(ctx context.Context, contract DdcBucketContract, ownerId types.OptionAccountID, options ...ListOption) ([]BucketInfo, error) {
+	return collect(ctx, contract, bucketPages(ownerId), options)
+}
+
+// CollectClusters reads all the clusters, of the manager if it is set, requesting the pages concurrently.
+func CollectClusters(ctx context.Context, contract DdcBucketContract, managerId types.OptionAccountID, options ...ListOption) ([]ClusterInfo, error) {
+	return collect(ctx, contract, clusterPages(managerId), options)
+}
+
+// CollectNodes reads all the storage nodes, of the provider if it is set, requesting the pages concurrently.
+func CollectNodes(ctx context.Context, contract DdcBucketContract, providerId types.OptionAccountID, options ...ListOption) ([]NodeInfo, error) {
+	return collect(ctx, contract, nodePages(providerId), options)
+}
+
+// CollectCdnNodes reads all the CDN nodes, of the provider if it is set, requesting the pages concurrently.
+func CollectCdnNodes(ctx context.Context, contract DdcBucketContract, providerId types.OptionAccountID, options ...ListOption) ([]CdnNodeInfo, error) {
+	return collect(ctx, contract, cdnNodePages(providerId), options)
+}
+
+func bucketPages(ownerId types.OptionAccountID) pageFetcher[BucketInfo] {
+	return func(reader DdcBucketContractReader, offset, limit uint32) ([]BucketInfo, uint32, error) {
+		list, err := reader.BucketList(types.U32(offset), types.U32(limit), ownerId)
+		if err != nil {
+			return nil, 0, err
+		}
+		return list.Buckets, uint32(list.Total), nil
+	}
+}
+
+func clusterPages(managerId types.OptionAccountID) pageFetcher[ClusterInfo] {
+	return func(reader DdcBucketContractReader, offset, limit uint32) ([]ClusterInfo, uint32, error) {
+		list, err := reader.ClusterList(types.U32(offset), types.U32(limit), managerId)
+		if err != nil {
+			return nil, 0, err
+		}
+		return list.Clusters, uint32(list.Total), nil
+	}
+}
+
+func nodePages(providerId types.OptionAccountID) pageFetcher[NodeInfo] {
+	return func(reader DdcBucketContractReader, offset, limit uint32) ([]NodeInfo, uint32, error) {
+		list, err := reader.NodeList(types.U32(offset), types.U32(limit), providerId)
+		if err != nil {
+			return nil, 0, err
+		}
+		return list.Nodes, uint32(list.Total), nil
+	}
+}
+
+func cdnNodePages(providerId types.OptionAccountID) pageFetcher[CdnNodeInfo] {
+	return func(reader DdcBucketContractReader, offset, limit uint32) ([]CdnNodeInfo, uint32, error) {
+		list, err := reader.CdnNodeList(types.U32(offset), types.U32(limit), providerId)
+		if err != nil {
+			return nil, 0, err
+		}
+		return list.Nodes, uint32(list.Total), nil
+	}
+}
+
+func newListOptions(options []ListOption) listOptions {
+	opts := listOptions{pageSize: DEFAULT_PAGE_SIZE, concurrency: DEFAULT_LIST_CONCURRENCY}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return opts
+}
+
+func newIterator[T any](ctx context.Context, contract DdcBucketContract, fetch pageFetcher[T], options []ListOption) *Iterator[T] {
+	opts := newListOptions(options)
+
+	return &Iterator[T]{
+		ctx:      ctx,
+		reader:   contract.Reader(ctx),
+		fetch:    fetch,
+		pageSize: opts.pageSize,
+		prefetch: opts.prefetch,
+	}
+}
+
+// Next advances to the next entry, it returns false at the end of the listing or on an error, see Err.
+func (it *Iterator[T]) Next() bool {
+	for it.position >= len(it.page) {
+		if it.err != nil || it.exhausted {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		it.loadPage()
+	}
+
+	it.current = it.page[it.position]
+	it.position++
+	return true
+}
+
+// Value returns the entry Next advanced to.
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the error which stopped the iteration, nil if the listing was read to the end.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+func (it *Iterator[T]) loadPage() {
+	var result pageResult[T]
+	if it.pending != nil {
+		select {
+		case result = <-it.pending:
+		case <-it.ctx.Done():
+			it.err = it.ctx.Err()
+			return
+		}
+		it.pending = nil
+	} else {
+		result = it.fetchPage(it.offset)
+	}
+	if result.err != nil {
+		it.err = result.err
+		return
+	}
+
+	// The contract applies the filter within the requested range, so a page may hold fewer entries than the page
+	// size, even none, while the listing goes on up to the total.
+	it.page, it.position = result.items, 0
+	it.offset += it.pageSize
+	if it.offset >= result.total {
+		it.exhausted = true
+		return
+	}
+
+	if it.prefetch {
+		pending := make(chan pageResult[T], 1)
+		offset := it.offset
+		go func() {
+			pending <- it.fetchPage(offset)
+		}()
+		it.pending = pending
+	}
+}
+
+func (it *Iterator[T]) fetchPage(offset uint32) pageResult[T] {
+	items, total, err := it.fetch(it.reader, offset, it.pageSize)
+	return pageResult[T]{items: items, total: total, err: err}
+}
+
+// collect reads the first page to learn the total, then the remaining pages with at most opts.concurrency requests
+// at the same time. The entries keep the order of the listing.
+func collect[T any](ctx context.Context, contract DdcBucketContract, fetch pageFetcher[T], options []ListOption) ([]T, error) {
+	opts := newListOptions(options)
+	reader := contract.Reader(ctx)
+
+	first, total, err := fetch(reader, 0, opts.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if total <= opts.pageSize {
+		return first, nil
+	}
+
+	pages := make([][]T, (uint64(total)+uint64(opts.pageSize)-1)/uint64(opts.pageSize))
+	pages[0] = first
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	limiter := make(chan struct{}, opts.concurrency)
+	for i := 1; i < len(pages); i++ {
+		select {
+		case limiter <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-limiter }()
+
+			items, _, err := fetch(reader, uint32(i)*opts.pageSize, opts.pageSize)
+			if err != nil {
+				fail(err)
+				return
+			}
+			pages[i] = items
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []T
+	for _, page := range pages {
+		result = append(result, page...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+type listContract struct {
+	DdcBucketContract
+	mutex   sync.Mutex
+	buckets []BucketInfo
+	owners  []types.AccountID
+	offsets []types.U32
+	failAt  types.U32
+}
+
+func (c *listContract) Reader(context.Context, ...pkg.ReadOption) DdcBucketContractReader {
+	return c
+}
+
+func (c *listContract) BucketList(offset types.U32, limit types.U32, ownerId types.OptionAccountID) (*BucketListInfo, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.offsets = append(c.offsets, offset)
+	if c.failAt > 0 && offset == c.failAt {
+		return nil, errors.New("read failed")
+	}
+
+	result := &BucketListInfo{Total: types.U32(len(c.buckets))}
+	for i := offset; i < offset+limit && int(i) < len(c.buckets); i++ {
+		if hasOwner, owner := ownerId.Unwrap(); hasOwner && owner != c.owners[i] {
+			continue
+		}
+		result.Buckets = append(result.Buckets, c.buckets[i])
+	}
+
+	return result, nil
+}
+
+func createListContract(size int) *listContract {
+	contract := &listContract{}
+	for i := 0; i < size; i++ {
+		contract.buckets = append(contract.buckets, BucketInfo{BucketId: BucketId(i)})
+		contract.owners = append(contract.owners, types.AccountID{byte(i % 2)})
+	}
+
+	return contract
+}
+
+func bucketIds(buckets []BucketInfo) []BucketId {
+	var ids []BucketId
+	for _, bucketInfo := range buckets {
+		ids = append(ids, bucketInfo.BucketId)
+	}
+
+	return ids
+}
+
+func TestBucketsIterator(t *testing.T) {
+	//given
+	contract := createListContract(7)
+	it := Buckets(context.Background(), contract, types.OptionAccountID{}, WithPageSize(3), WithPrefetch())
+
+	//when
+	var buckets []BucketInfo
+	for it.Next() {
+		buckets = append(buckets, it.Value())
+	}
+
+	//then
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []BucketId{0, 1, 2, 3, 4, 5, 6}, bucketIds(buckets))
+	assert.Equal(t, []types.U32{0, 3, 6}, contract.offsets)
+}
+
+func TestBucketsIteratorFilter(t *testing.T) {
+	//given
+	contract := createListContract(7)
+	it := Buckets(context.Background(), contract, types.NewOptionAccountID(types.AccountID{1}), WithPageSize(1))
+
+	//when
+	var buckets []BucketInfo
+	for it.Next() {
+		buckets = append(buckets, it.Value())
+	}
+
+	//then
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []BucketId{1, 3, 5}, bucketIds(buckets))
+	assert.Len(t, contract.offsets, 7)
+}
+
+func TestBucketsIteratorCancelled(t *testing.T) {
+	//given
+	contract := createListContract(7)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := Buckets(ctx, contract, types.OptionAccountID{}, WithPageSize(3))
+
+	//when
+	var buckets []BucketInfo
+	for it.Next() {
+		buckets = append(buckets, it.Value())
+		cancel()
+	}
+
+	//then
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+	assert.Equal(t, []BucketId{0, 1, 2}, bucketIds(buckets))
+	assert.Equal(t, []types.U32{0}, contract.offsets)
+}
+
+func TestCollectBuckets(t *testing.T) {
+	//given
+	contract := createListContract(10)
+
+	//when
+	buckets, err := CollectBuckets(context.Background(), contract, types.OptionAccountID{}, WithPageSize(3), WithConcurrency(2))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, []BucketId{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, bucketIds(buckets))
+	assert.ElementsMatch(t, []types.U32{0, 3, 6, 9}, contract.offsets)
+}
+
+func TestCollectBucketsError(t *testing.T) {
+	//given
+	contract := createListContract(10)
+	contract.failAt = 6
+
+	//when
+	buckets, err := CollectBuckets(context.Background(), contract, types.OptionAccountID{}, WithPageSize(3))
+
+	//then
+	assert.EqualError(t, err, "read failed")
+	assert.Nil(t, buckets)
+}
@@ -327,10 +327,6 @@ func (a *Account) HasBalance() bool {
 	return a.Bonded.Cmp(big.NewInt(0)) > 0
 }
 
-type ClusterParams struct {
-	ReplicationFactor FlexInt `json:"replicationFactor"`
-}
-
 func (c *ClusterInfo) ReplicationFactor() uint {
 	params := &ClusterParams{}
 	err := json.Unmarshal([]byte(c.Cluster.Params), params)
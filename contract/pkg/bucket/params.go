@@ -0,0 +1,221 @@
+package bucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const maxPort = 65535
+
+var ErrInvalidParams = errors.New("invalid params")
+
+type (
+	// StorageNodeParams is the structure-helper for json on the storage node params string.
+	StorageNodeParams struct {
+		Url      string  `json:"url"`
+		GrpcPort FlexInt `json:"grpcPort,omitempty"`
+		HttpPort FlexInt `json:"httpPort,omitempty"`
+		P2pPort  FlexInt `json:"p2pPort,omitempty"`
+		// Location is the ISO 3166-1 alpha-2 code of the country of the node, e.g. "DE", empty if it is not set.
+		Location string  `json:"location"`
+		Size     FlexInt `json:"size"`
+	}
+
+	// ClusterParams is the structure-helper for json on the cluster params string.
+	ClusterParams struct {
+		// ReplicationFactor is the number of nodes storing each piece, 0 if it is not set.
+		ReplicationFactor FlexInt `json:"replicationFactor"`
+		// ErasureCoding splits the pieces into the data and parity shards instead of replicating them, nil if it is not.
+		ErasureCoding *ErasureCoding `json:"erasureCoding,omitempty"`
+		// Durability is the targeted durability in nines, e.g. 11 for 99.999999999%, 0 if there is no target.
+		Durability FlexInt `json:"durability,omitempty"`
+	}
+
+	ErasureCoding struct {
+		DataShards   FlexInt `json:"dataShards"`
+		ParityShards FlexInt `json:"parityShards"`
+	}
+
+	// StorageBucketParams is the structure-helper for json on the bucket params string.
+	StorageBucketParams struct {
+		// Replication overrides the replication factor of the cluster, 0 keeps the one of the cluster.
+		Replication FlexInt `json:"replication,omitempty"`
+	}
+
+	// ParamsError lists the constraints violated by the params, it matches ErrInvalidParams with errors.Is.
+	ParamsError struct {
+		Violations []string
+	}
+
+	paramsValidator struct {
+		violations []string
+	}
+)
+
+func (e *ParamsError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidParams, strings.Join(e.Violations, "; "))
+}
+
+func (e *ParamsError) Is(target error) bool {
+	return target == ErrInvalidParams
+}
+
+func ReadStorageNodeParams(s NodeParams) (p StorageNodeParams, err error) {
+	err = json.Unmarshal([]byte(s), &p)
+	return
+}
+
+// ValidateNodeParams reads the storage node params and checks their constraints.
+func ValidateNodeParams(s NodeParams) error {
+	p, err := ReadStorageNodeParams(s)
+	if err != nil {
+		return &ParamsError{Violations: []string{"not a json object: " + err.Error()}}
+	}
+
+	return p.Validate()
+}
+
+func (p StorageNodeParams) Validate() error {
+	v := &paramsValidator{}
+	v.url("url", p.Url)
+	v.port("grpcPort", p.GrpcPort)
+	v.port("httpPort", p.HttpPort)
+	v.port("p2pPort", p.P2pPort)
+	v.location("location", p.Location)
+	v.minimum("size", p.Size, 0)
+	return v.err()
+}
+
+// Encode validates the params and encodes them into the params string of the contract.
+func (p StorageNodeParams) Encode() (NodeParams, error) {
+	return encodeParams(p)
+}
+
+func ReadClusterParams(s Params) (p ClusterParams, err error) {
+	err = json.Unmarshal([]byte(s), &p)
+	return
+}
+
+// ValidateClusterParams reads the cluster params and checks their constraints.
+func ValidateClusterParams(s Params) error {
+	p, err := ReadClusterParams(s)
+	if err != nil {
+		return &ParamsError{Violations: []string{"not a json object: " + err.Error()}}
+	}
+
+	return p.Validate()
+}
+
+func (p ClusterParams) Validate() error {
+	v := &paramsValidator{}
+	v.minimum("replicationFactor", p.ReplicationFactor, 0)
+	if p.ErasureCoding != nil {
+		v.minimum("erasureCoding.dataShards", p.ErasureCoding.DataShards, 1)
+		v.minimum("erasureCoding.parityShards", p.ErasureCoding.ParityShards, 1)
+	}
+	v.minimum("durability", p.Durability, 0)
+	return v.err()
+}
+
+// Encode validates the params and encodes them into the params string of the contract.
+func (p ClusterParams) Encode() (Params, error) {
+	return encodeParams(p)
+}
+
+func ReadStorageBucketParams(s BucketParams) (p StorageBucketParams, err error) {
+	err = json.Unmarshal([]byte(s), &p)
+	return
+}
+
+// ValidateBucketParams reads the bucket params and checks their constraints, the buckets may have no params.
+func ValidateBucketParams(s BucketParams) error {
+	if s == "" {
+		return nil
+	}
+
+	p, err := ReadStorageBucketParams(s)
+	if err != nil {
+		return &ParamsError{Violations: []string{"not a json object: " + err.Error()}}
+	}
+
+	return p.Validate()
+}
+
+func (p StorageBucketParams) Validate() error {
+	v := &paramsValidator{}
+	v.minimum("replication", p.Replication, 0)
+	return v.err()
+}
+
+// Encode validates the params and encodes them into the params string of the contract.
+func (p StorageBucketParams) Encode() (BucketParams, error) {
+	return encodeParams(p)
+}
+
+func encodeParams(p interface{ Validate() error }) (Params, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (v *paramsValidator) url(property string, value string) {
+	if value == "" {
+		v.violation(property, "is required")
+		return
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		v.violation(property, "must be an absolute url, got %q", value)
+	}
+}
+
+// port checks the port is valid, 0 means it is not set.
+func (v *paramsValidator) port(property string, value FlexInt) {
+	if value < 0 || value > maxPort {
+		v.violation(property, "must be between 1 and %d or 0 if it is not set, got %d", maxPort, value)
+	}
+}
+
+// location checks the location is a country code, empty means it is not set.
+func (v *paramsValidator) location(property string, value string) {
+	if value == "" {
+		return
+	}
+
+	if len(value) != 2 || !isUpperLetter(value[0]) || !isUpperLetter(value[1]) {
+		v.violation(property, "must be an ISO 3166-1 alpha-2 country code, got %q", value)
+	}
+}
+
+func (v *paramsValidator) minimum(property string, value FlexInt, minimum int) {
+	if int(value) < minimum {
+		v.violation(property, "must be at least %d, got %d", minimum, value)
+	}
+}
+
+func (v *paramsValidator) violation(property string, format string, args ...interface{}) {
+	v.violations = append(v.violations, property+" "+fmt.Sprintf(format, args...))
+}
+
+func (v *paramsValidator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return &ParamsError{Violations: v.violations}
+}
+
+func isUpperLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
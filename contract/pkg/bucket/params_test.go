@@ -0,0 +1,105 @@
+package bucket
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageNodeParamsRoundTrip(t *testing.T) {
+	//given
+	params := StorageNodeParams{Url: "https://node-0.cere.network", GrpcPort: 9090, HttpPort: 8080, P2pPort: 5000, Location: "DE", Size: 4}
+
+	//when
+	encoded, errEncode := params.Encode()
+	decoded, errRead := ReadStorageNodeParams(encoded)
+
+	//then
+	assert.NoError(t, errEncode)
+	assert.NoError(t, errRead)
+	assert.Equal(t, params, decoded)
+	assert.NoError(t, ValidateNodeParams(`{"url":"http://localhost:8080","httpPort":"8080","size":"1","location":"US"}`))
+}
+
+func TestStorageNodeParamsValidation(t *testing.T) {
+	//given
+	params := StorageNodeParams{Url: "node-0", GrpcPort: 70000, Location: "Germany", Size: -1}
+
+	//when
+	_, err := params.Encode()
+	errJson := ValidateNodeParams(`{"url":`)
+
+	//then
+	assert.ErrorIs(t, err, ErrInvalidParams)
+	assert.EqualError(t, err, `invalid params: url must be an absolute url, got "node-0"; grpcPort must be between 1 and 65535 or 0 if it is not set, got 70000; location must be an ISO 3166-1 alpha-2 country code, got "Germany"; size must be at least 0, got -1`)
+	assert.ErrorIs(t, errJson, ErrInvalidParams)
+}
+
+func TestClusterParamsRoundTrip(t *testing.T) {
+	//given
+	params := ClusterParams{ReplicationFactor: 6, ErasureCoding: &ErasureCoding{DataShards: 4, ParityShards: 2}, Durability: 11}
+	cluster := &ClusterInfo{}
+
+	//when
+	encoded, errEncode := params.Encode()
+	decoded, errRead := ReadClusterParams(encoded)
+	cluster.Cluster.Params = encoded
+
+	//then
+	assert.NoError(t, errEncode)
+	assert.NoError(t, errRead)
+	assert.Equal(t, params, decoded)
+	assert.Equal(t, uint(6), cluster.ReplicationFactor())
+	assert.Equal(t, `{"replicationFactor":3}`, mustEncode(t, ClusterParams{ReplicationFactor: 3}))
+}
+
+func TestClusterParamsValidation(t *testing.T) {
+	//when
+	err := ValidateClusterParams(`{"replicationFactor":-1,"erasureCoding":{"dataShards":4}}`)
+	errUnset := ValidateClusterParams(`{}`)
+
+	//then
+	assert.EqualError(t, err, "invalid params: replicationFactor must be at least 0, got -1; erasureCoding.parityShards must be at least 1, got 0")
+	assert.NoError(t, errUnset)
+}
+
+func TestStorageBucketParams(t *testing.T) {
+	//given
+	params := StorageBucketParams{Replication: 3}
+
+	//when
+	encoded, errEncode := params.Encode()
+	decoded, errRead := ReadStorageBucketParams(encoded)
+
+	//then
+	assert.NoError(t, errEncode)
+	assert.NoError(t, errRead)
+	assert.Equal(t, params, decoded)
+	assert.NoError(t, ValidateBucketParams(""))
+	assert.NoError(t, ValidateBucketParams("{}"))
+	assert.EqualError(t, ValidateBucketParams(`{"replication":-1}`), "invalid params: replication must be at least 0, got -1")
+}
+
+func TestParamsValidatedBeforeSubmit(t *testing.T) {
+	//given
+	contract := CreateDdcBucketContract(nil, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+	ctx := context.Background()
+
+	//when
+	_, errNode := contract.NodeCreate(ctx, signature.TestKeyringPairAlice, types.AccountID{1}, `{"size":1}`, 100, types.NewU128(*big.NewInt(1)))
+	errCluster := contract.ClusterSetParams(ctx, signature.TestKeyringPairAlice, 1, `{"durability":-1}`)
+
+	//then
+	assert.EqualError(t, errNode, "invalid params: url is required")
+	assert.EqualError(t, errCluster, "invalid params: durability must be at least 0, got -1")
+}
+
+func mustEncode(t *testing.T, params ClusterParams) Params {
+	encoded, err := params.Encode()
+	assert.NoError(t, err)
+	return encoded
+}
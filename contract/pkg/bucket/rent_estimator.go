@@ -0,0 +1,111 @@
+package bucket
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+)
+
+// MS_PER_MONTH converts the monthly rent of the nodes into the rate per millisecond the buckets pay.
+const MS_PER_MONTH = 30 * 24 * 60 * 60 * 1000
+
+const msPerDay = 24 * 60 * 60 * 1000
+
+type (
+	// RentEstimator estimates the rent of the buckets and how long the accounts cover it.
+	RentEstimator interface {
+		// BucketRent is the rent per month of reserving the resource in the cluster, it fails with
+		// ErrInsufficientClusterResources if the cluster can't reserve it.
+		BucketRent(clusterId ClusterId, resource Resource) (Balance, error)
+		// AccountCoverage projects the bonded balance of the account paying its payable schedule.
+		AccountCoverage(accountId AccountId) (*AccountCoverage, error)
+	}
+
+	AccountCoverage struct {
+		// Available is the bonded balance left once the rent due until now is paid.
+		Available Balance
+		// RentPerMonth is the rent of all the buckets of the account.
+		RentPerMonth Balance
+		// DepletedAt is when the bonded balance runs out, zero if the account pays no rent.
+		DepletedAt time.Time
+		// DaysLeft are the days of rent the bonded balance covers, +Inf if the account pays no rent.
+		DaysLeft float64
+	}
+
+	rentEstimator struct {
+		reader DdcBucketContractReader
+		now    func() time.Time
+	}
+)
+
+func CreateRentEstimator(reader DdcBucketContractReader) RentEstimator {
+	return &rentEstimator{reader: reader, now: time.Now}
+}
+
+func (e *rentEstimator) BucketRent(clusterId ClusterId, resource Resource) (Balance, error) {
+	cluster, err := e.reader.ClusterGet(clusterId)
+	if err != nil {
+		return Balance{}, err
+	}
+	if uint64(cluster.Cluster.ResourceUsed)+uint64(resource) > uint64(cluster.Cluster.ResourcePerVNode) {
+		return Balance{}, ErrInsufficientClusterResources
+	}
+
+	rent := new(big.Int).Mul(intOf(cluster.Cluster.TotalRent), big.NewInt(int64(resource)))
+	return types.NewU128(*rent), nil
+}
+
+// AccountCoverage follows the payable schedule of the contract, the rent due at a time in milliseconds is
+// rate * time - offset.
+func (e *rentEstimator) AccountCoverage(accountId AccountId) (*AccountCoverage, error) {
+	account, err := e.reader.AccountGet(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := e.now()
+	rate, offset, bonded := intOf(account.PayableSchedule.Rate), intOf(account.PayableSchedule.Offset), intOf(account.Bonded)
+	coverage := &AccountCoverage{
+		Available:    types.NewU128(*bonded),
+		RentPerMonth: types.NewU128(*new(big.Int).Mul(rate, big.NewInt(MS_PER_MONTH))),
+		DaysLeft:     math.Inf(1),
+	}
+	if rate.Sign() == 0 {
+		return coverage, nil
+	}
+
+	nowMs := now.UnixMilli()
+	due := new(big.Int).Mul(rate, big.NewInt(nowMs))
+	due.Sub(due, offset)
+	if due.Sign() > 0 {
+		available := new(big.Int).Sub(bonded, due)
+		if available.Sign() < 0 {
+			available = new(big.Int)
+		}
+		coverage.Available = types.NewU128(*available)
+	}
+
+	depletedAtMs := new(big.Int).Add(bonded, offset)
+	depletedAtMs.Quo(depletedAtMs, rate)
+	if !depletedAtMs.IsInt64() {
+		return coverage, nil
+	}
+	if depletedAtMs.Int64() < nowMs {
+		depletedAtMs.SetInt64(nowMs)
+	}
+
+	coverage.DepletedAt = time.UnixMilli(depletedAtMs.Int64())
+	coverage.DaysLeft = float64(depletedAtMs.Int64()-nowMs) / msPerDay
+
+	return coverage, nil
+}
+
+func intOf(balance Balance) *big.Int {
+	if balance.Int == nil {
+		return new(big.Int)
+	}
+
+	return balance.Int
+}
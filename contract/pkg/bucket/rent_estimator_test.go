@@ -0,0 +1,104 @@
+package bucket
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type estimatorReader struct {
+	DdcBucketContractReader
+	cluster *ClusterInfo
+	account *Account
+}
+
+func (r *estimatorReader) ClusterGet(ClusterId) (*ClusterInfo, error) {
+	return r.cluster, nil
+}
+
+func (r *estimatorReader) AccountGet(AccountId) (*Account, error) {
+	if r.account == nil {
+		return nil, ErrAccountDoesNotExist
+	}
+	return r.account, nil
+}
+
+func balanceOf(value int64) Balance {
+	return types.NewU128(*big.NewInt(value))
+}
+
+func createEstimator(reader DdcBucketContractReader, now time.Time) *rentEstimator {
+	return &rentEstimator{reader: reader, now: func() time.Time { return now }}
+}
+
+func TestBucketRent(t *testing.T) {
+	//given
+	reader := &estimatorReader{cluster: &ClusterInfo{Cluster: Cluster{TotalRent: balanceOf(300), ResourcePerVNode: 10, ResourceUsed: 4}}}
+	testSubject := CreateRentEstimator(reader)
+
+	//when
+	rent, err := testSubject.BucketRent(1, 6)
+	_, errTooLarge := testSubject.BucketRent(1, 7)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, balanceOf(1800), rent)
+	assert.ErrorIs(t, errTooLarge, ErrInsufficientClusterResources)
+}
+
+func TestAccountCoverage(t *testing.T) {
+	//given
+	now := time.UnixMilli(10 * msPerDay)
+	// 1 per ms since the day 8, paid until the day 9
+	account := &Account{Bonded: balanceOf(3 * msPerDay), PayableSchedule: Schedule{Rate: balanceOf(1), Offset: balanceOf(9 * msPerDay)}}
+	testSubject := createEstimator(&estimatorReader{account: account}, now)
+
+	//when
+	coverage, err := testSubject.AccountCoverage(AccountId{1})
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, &AccountCoverage{
+		Available:    balanceOf(2 * msPerDay),
+		RentPerMonth: balanceOf(MS_PER_MONTH),
+		DepletedAt:   time.UnixMilli(12 * msPerDay),
+		DaysLeft:     2,
+	}, coverage)
+}
+
+func TestAccountCoverageDepleted(t *testing.T) {
+	//given
+	now := time.UnixMilli(10 * msPerDay)
+	account := &Account{Bonded: balanceOf(msPerDay), PayableSchedule: Schedule{Rate: balanceOf(1), Offset: balanceOf(5 * msPerDay)}}
+	testSubject := createEstimator(&estimatorReader{account: account}, now)
+
+	//when
+	coverage, err := testSubject.AccountCoverage(AccountId{1})
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, balanceOf(0), coverage.Available)
+	assert.Equal(t, now, coverage.DepletedAt)
+	assert.Equal(t, float64(0), coverage.DaysLeft)
+}
+
+func TestAccountCoverageWithoutRent(t *testing.T) {
+	//given
+	account := &Account{Bonded: balanceOf(10)}
+	testSubject := CreateRentEstimator(&estimatorReader{account: account})
+
+	//when
+	coverage, err := testSubject.AccountCoverage(AccountId{1})
+	_, errMissing := CreateRentEstimator(&estimatorReader{}).AccountCoverage(AccountId{1})
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, balanceOf(10), coverage.Available)
+	assert.True(t, coverage.DepletedAt.IsZero())
+	assert.Equal(t, math.Inf(1), coverage.DaysLeft)
+	assert.ErrorIs(t, errMissing, ErrAccountDoesNotExist)
+}
@@ -1,10 +1,10 @@
 package bucket
 
 import (
+	"encoding/hex"
 	"errors"
 	"strings"
 
-	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
 )
 
@@ -14,8 +14,10 @@ const (
 )
 
 type Result struct {
-	data interface{}
-	err  error
+	data       interface{}
+	err        error
+	method     []byte
+	errorTable ErrorTable
 }
 
 func (result *Result) decodeDdcBucketContract(encodedData string) error {
@@ -29,15 +31,35 @@ func (result *Result) decodeDdcBucketContract(encodedData string) error {
 	}
 
 	if strings.HasPrefix(encodedData, errPrefix) {
-		encodedData = strings.TrimPrefix(encodedData, errPrefix)
-		var errRes types.U8
-		if err := codec.DecodeFromHex(encodedData, &errRes); err != nil {
+		data, err := hex.DecodeString(strings.TrimPrefix(encodedData, errPrefix))
+		if err != nil {
 			return err
 		}
 
-		result.err = parseDdcBucketContractError(uint8(errRes))
+		contractErr, err := result.errorTable.decode(result.method, data)
+		if err != nil {
+			return err
+		}
+
+		result.err = contractErr
 		return nil
 	}
 
 	return errors.New("can't decode storage contract result")
 }
+
+// errorDecoder returns the decoder converting the output of a reverted call of the method into the contract error.
+func (t ErrorTable) errorDecoder(method []byte) func(data []byte) error {
+	return func(data []byte) error {
+		if len(data) < 2 || data[0] != 0x01 {
+			return nil
+		}
+
+		contractErr, err := t.decode(method, data[1:])
+		if err != nil {
+			return err
+		}
+
+		return contractErr
+	}
+}
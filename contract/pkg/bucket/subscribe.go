@@ -0,0 +1,152 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+)
+
+const (
+	DEFAULT_EVENT_BUFFER_SIZE      = 16
+	DEFAULT_EVENT_OVERFLOW_TIMEOUT = time.Second
+)
+
+type (
+	// ContractEvent is any event of the DDC bucket contract.
+	ContractEvent interface {
+		BucketCreatedEvent | BucketAllocatedEvent | BucketSettlePaymentEvent | BucketAvailabilityUpdatedEvent |
+			BucketParamsSetEvent | ClusterCreatedEvent | ClusterRemovedEvent | ClusterParamsSetEvent |
+			ClusterNodeAddedEvent | ClusterNodeRemovedEvent | ClusterNodeReplacedEvent | ClusterNodeResetEvent |
+			ClusterNodeStatusSetEvent | ClusterCdnNodeAddedEvent | ClusterCdnNodeRemovedEvent |
+			ClusterCdnNodeStatusSetEvent | ClusterReserveResourceEvent | ClusterDistributeRevenuesEvent |
+			ClusterDistributeCdnRevenuesEvent | NodeCreatedEvent | NodeRemovedEvent | NodeParamsSetEvent |
+			NodeOwnershipTransferredEvent | CdnNodeCreatedEvent | CdnNodeRemovedEvent | CdnNodeParamsSetEvent |
+			CdnNodeOwnershipTransferredEvent | DepositEvent | GrantPermissionEvent | RevokePermissionEvent |
+			PermissionGrantedEvent | PermissionRevokedEvent
+	}
+
+	// Event is a contract event delivered by Subscribe.
+	Event[T ContractEvent] struct {
+		pkg.ContractEventContext
+		Args T
+	}
+
+	SubscribeOption func(*subscribeOptions)
+
+	subscribeOptions struct {
+		bufferSize      int
+		overflowTimeout time.Duration
+	}
+)
+
+// ErrSubscriptionOverflow is reported to the event error handler of the blockchain client, see
+// pkg.WithContractEventErrorHandler, for each event dropped as the subscription channel stayed full.
+var ErrSubscriptionOverflow = errors.New("subscription channel full, event dropped")
+
+// eventAliases are the event types sharing the layout and the event id with a type of the eventDispatchTable.
+var eventAliases = map[reflect.Type]string{
+	reflect.TypeOf(PermissionGrantedEvent{}): GrantPermissionEventId,
+	reflect.TypeOf(PermissionRevokedEvent{}): RevokePermissionEventId,
+}
+
+// WithBufferSize sets the capacity of the subscription channel.
+func WithBufferSize(size int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.bufferSize = size
+	}
+}
+
+// WithOverflowTimeout sets how long the event dispatch waits for the consumer once the subscription channel is full
+// before dropping the event with ErrSubscriptionOverflow. A timeout of 0 or less waits until the consumer reads or the
+// subscription ends, so no event is dropped but a stuck consumer stops the events of all the contracts.
+func WithOverflowTimeout(timeout time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.overflowTimeout = timeout
+	}
+}
+
+// Subscribe delivers the events of the type T emitted by the contract until ctx is done, then the channel is closed.
+// The events are dispatched once the contract dispatcher is set to the blockchain client, see
+// pkg.BlockchainClient.SetEventDispatcher.
+//
+// The events of all the contracts of the blockchain client are dispatched one at a time, the channel is fed in that
+// dispatch: while it is full the dispatch of every event, cache invalidation included, waits for the consumer. The
+// wait is bounded by DEFAULT_EVENT_OVERFLOW_TIMEOUT, see WithOverflowTimeout, past it the event is dropped and
+// ErrSubscriptionOverflow is reported.
+func Subscribe[T ContractEvent](ctx context.Context, contract DdcBucketContract, options ...SubscribeOption) (<-chan Event[T], error) {
+	opts := subscribeOptions{bufferSize: DEFAULT_EVENT_BUFFER_SIZE, overflowTimeout: DEFAULT_EVENT_OVERFLOW_TIMEOUT}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	argumentType := reflect.TypeOf((*T)(nil)).Elem()
+	eventId, err := eventIdOf(argumentType)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event[T], opts.bufferSize)
+	mutex := sync.RWMutex{}
+	closed := false
+	unregister, err := contract.AddContractEventHandler(eventId, func(event pkg.ContractEventContext, raw interface{}) error {
+		mutex.RLock()
+		defer mutex.RUnlock()
+		if closed {
+			return nil
+		}
+
+		args := reflect.ValueOf(raw).Elem().Convert(argumentType).Interface().(T)
+		item := Event[T]{ContractEventContext: event, Args: args}
+		select {
+		case events <- item:
+			return nil
+		default:
+		}
+
+		var overflow <-chan time.Time
+		if opts.overflowTimeout > 0 {
+			timer := time.NewTimer(opts.overflowTimeout)
+			defer timer.Stop()
+			overflow = timer.C
+		}
+		select {
+		case events <- item:
+		case <-ctx.Done():
+		case <-overflow:
+			return ErrSubscriptionOverflow
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		unregister()
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		closed = true
+		close(events)
+	}()
+
+	return events, nil
+}
+
+func eventIdOf(argumentType reflect.Type) (string, error) {
+	if eventId, ok := eventAliases[argumentType]; ok {
+		return eventId, nil
+	}
+	for eventId, t := range eventDispatchTable {
+		if t == argumentType {
+			return eventId, nil
+		}
+	}
+
+	return "", errors.New("Event not found for " + argumentType.Name())
+}
@@ -0,0 +1,87 @@
+package bucket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func dispatchEvent(contract DdcBucketContract, eventId string, event pkg.ContractEventContext, args interface{}) error {
+	eventKey, _ := types.NewHashFromHexString(eventId)
+	return contract.GetEventDispatcher()[eventKey].Handler(event, args)
+}
+
+func TestSubscribe(t *testing.T) {
+	//given
+	contract := CreateDdcBucketContract(nil, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+	ctx, cancel := context.WithCancel(context.Background())
+	created, errCreated := Subscribe[BucketCreatedEvent](ctx, contract, WithBufferSize(2))
+	granted, errGranted := Subscribe[PermissionGrantedEvent](ctx, contract)
+
+	//when
+	errDispatch := dispatchEvent(contract, BucketCreatedEventId, pkg.ContractEventContext{BlockNumber: 7, EventIndex: 3}, &BucketCreatedEvent{BucketId: 1})
+	errAlias := dispatchEvent(contract, GrantPermissionEventId, pkg.ContractEventContext{}, &GrantPermissionEvent{Permission: 2})
+	createdEvent := <-created
+	grantedEvent := <-granted
+	cancel()
+	_, openCreated := <-created
+	_, openGranted := <-granted
+	errClosed := dispatchEvent(contract, BucketCreatedEventId, pkg.ContractEventContext{}, &BucketCreatedEvent{BucketId: 2})
+
+	//then
+	assert.NoError(t, errCreated)
+	assert.NoError(t, errGranted)
+	assert.NoError(t, errDispatch)
+	assert.NoError(t, errAlias)
+	assert.NoError(t, errClosed)
+	assert.Equal(t, Event[BucketCreatedEvent]{
+		ContractEventContext: pkg.ContractEventContext{BlockNumber: 7, EventIndex: 3},
+		Args:                 BucketCreatedEvent{BucketId: 1},
+	}, createdEvent)
+	assert.Equal(t, byte(2), grantedEvent.Args.Permission)
+	assert.False(t, openCreated)
+	assert.False(t, openGranted)
+}
+
+func TestSubscribeFullBuffer(t *testing.T) {
+	//given
+	contract := CreateDdcBucketContract(nil, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+	ctx, cancel := context.WithCancel(context.Background())
+	deposits, _ := Subscribe[DepositEvent](ctx, contract, WithBufferSize(0))
+	dispatched := make(chan error)
+
+	//when
+	go func() {
+		dispatched <- dispatchEvent(contract, DepositEventId, pkg.ContractEventContext{}, &DepositEvent{})
+	}()
+	cancel()
+	err := <-dispatched
+	_, open := <-deposits
+
+	//then
+	assert.NoError(t, err)
+	assert.False(t, open)
+}
+
+func TestSubscribeOverflow(t *testing.T) {
+	//given
+	contract := CreateDdcBucketContract(nil, "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deposits, _ := Subscribe[DepositEvent](ctx, contract, WithBufferSize(1), WithOverflowTimeout(time.Millisecond))
+
+	//when
+	errFirst := dispatchEvent(contract, DepositEventId, pkg.ContractEventContext{EventIndex: 1}, &DepositEvent{})
+	errOverflow := dispatchEvent(contract, DepositEventId, pkg.ContractEventContext{EventIndex: 2}, &DepositEvent{})
+	first := <-deposits
+
+	//then
+	assert.NoError(t, errFirst)
+	assert.ErrorIs(t, errOverflow, ErrSubscriptionOverflow)
+	assert.Equal(t, uint32(1), first.EventIndex)
+	assert.Empty(t, deposits)
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
 	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
 	"github.com/golang/groupcache/singleflight"
-	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
 )
 
@@ -23,24 +22,54 @@ const (
 type (
 	DdcBucketContractCache interface {
 		HookContractEvents() error
+		SaveSnapshot(client pkg.BlockchainClient, path string) error
+		LoadSnapshot(path string) (types.BlockNumber, error)
+		WarmStart(client pkg.BlockchainClient, path string) error
+		LastEventBlock() types.BlockNumber
+		Stats() ContractCacheStats
 		Clear()
 		ClearNodes()
 		ClearBuckets()
 		ClearAccounts()
+		ClearClusters()
+		ClearCdnNodes()
+		ClearLists()
 		ClearNodeById(id bucket.NodeKey)
 		ClearBucketById(id bucket.BucketId)
 		ClearAccountById(id bucket.AccountId)
+		ClearClusterById(id bucket.ClusterId)
+		ClearCdnNodeByKey(key bucket.CdnNodeKey)
 		bucket.DdcBucketContract
 	}
 
 	ddcBucketContractCached struct {
 		ddcBucketContract   bucket.DdcBucketContract
-		bucketCache         *cache.Cache
+		bucketCache         *entityCache
 		bucketSingleFlight  singleflight.Group
-		nodeCache           *cache.Cache
+		nodeCache           *entityCache
 		nodeSingleFlight    singleflight.Group
-		accountCache        *cache.Cache
+		accountCache        *entityCache
 		accountSingleFlight singleflight.Group
+		clusterCache        *entityCache
+		clusterSingleFlight singleflight.Group
+		cdnNodeCache        *entityCache
+		cdnNodeSingleFlight singleflight.Group
+		// The list caches are keyed by the page and the filter, their entries are dropped when an entity they contain
+		// changes and flushed when an entity is created or removed, as the pages shift.
+		clusterListCache        *entityCache
+		clusterListSingleFlight singleflight.Group
+		nodeListCache           *entityCache
+		nodeListSingleFlight    singleflight.Group
+		// eventBlock is the block of the last contract event handled, accessed atomically.
+		eventBlock uint32
+	}
+
+	// ddcBucketContractCachedReader reads the cached entities through the cache, the rest with the reader. The
+	// entities are loaded with the contract reader shared by all the callers, ctx only bounds the wait.
+	ddcBucketContractCachedReader struct {
+		bucket.DdcBucketContractReader
+		ctx   context.Context
+		cache *ddcBucketContractCached
 	}
 
 	BucketCacheParameters struct {
@@ -52,213 +81,362 @@ type (
 
 		AccountCacheExpiration time.Duration
 		AccountCacheCleanUp    time.Duration
+
+		ClusterCacheExpiration time.Duration
+		ClusterCacheCleanUp    time.Duration
+
+		CdnNodeCacheExpiration time.Duration
+		CdnNodeCacheCleanUp    time.Duration
+
+		ListCacheExpiration time.Duration
+		ListCacheCleanUp    time.Duration
+
+		// The caches hold at most that many entries, evicted by the policy, LRU by default.
+		BucketCacheSize    int
+		BucketCachePolicy  EvictionPolicy
+		NodeCacheSize      int
+		NodeCachePolicy    EvictionPolicy
+		AccountCacheSize   int
+		AccountCachePolicy EvictionPolicy
+		ClusterCacheSize   int
+		ClusterCachePolicy EvictionPolicy
+		CdnNodeCacheSize   int
+		CdnNodeCachePolicy EvictionPolicy
+		ListCacheSize      int
+		ListCachePolicy    EvictionPolicy
+
+		// NegativeCacheExpiration is how long the entities found not to exist are remembered.
+		NegativeCacheExpiration time.Duration
+
+		// MaxStaleness keeps serving the expired entries for that long while one background read refreshes them, the
+		// callers wait for the read past it. 0 waits for the read as soon as the entries expire.
+		MaxStaleness time.Duration
+		// RefreshAhead refreshes in the background the entries read within that time before their expiration, so the hot
+		// entries don't expire. 0 disables it.
+		RefreshAhead time.Duration
+	}
+
+	ContractCacheStats struct {
+		Buckets      CacheStats
+		Nodes        CacheStats
+		Accounts     CacheStats
+		Clusters     CacheStats
+		CdnNodes     CacheStats
+		ClusterLists CacheStats
+		NodeLists    CacheStats
 	}
 )
 
 func CreateDdcBucketContractCache(ddcBucketContract bucket.DdcBucketContract, parameters BucketCacheParameters) DdcBucketContractCache {
-	bucketCache := cache.New(
-		cacheDurationOrDefault(parameters.BucketCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.BucketCacheCleanUp, cleanupInterval))
-	nodeCache := cache.New(
-		cacheDurationOrDefault(parameters.NodeCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.NodeCacheCleanUp, cleanupInterval))
-	accountCache := cache.New(
-		cacheDurationOrDefault(parameters.AccountCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.AccountCacheCleanUp, cleanupInterval))
+	negativeExpiration := cacheDurationOrDefault(parameters.NegativeCacheExpiration, defaultNegativeExpiration)
+	bucketCache := newEntityCache(cacheSizeOrDefault(parameters.BucketCacheSize, defaultCacheSize), parameters.BucketCachePolicy,
+		cacheDurationOrDefault(parameters.BucketCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.BucketCacheCleanUp, cleanupInterval), negativeExpiration)
+	nodeCache := newEntityCache(cacheSizeOrDefault(parameters.NodeCacheSize, defaultCacheSize), parameters.NodeCachePolicy,
+		cacheDurationOrDefault(parameters.NodeCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.NodeCacheCleanUp, cleanupInterval), negativeExpiration)
+	accountCache := newEntityCache(cacheSizeOrDefault(parameters.AccountCacheSize, defaultCacheSize), parameters.AccountCachePolicy,
+		cacheDurationOrDefault(parameters.AccountCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.AccountCacheCleanUp, cleanupInterval), negativeExpiration)
+	clusterCache := newEntityCache(cacheSizeOrDefault(parameters.ClusterCacheSize, defaultCacheSize), parameters.ClusterCachePolicy,
+		cacheDurationOrDefault(parameters.ClusterCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.ClusterCacheCleanUp, cleanupInterval), negativeExpiration)
+	cdnNodeCache := newEntityCache(cacheSizeOrDefault(parameters.CdnNodeCacheSize, defaultCacheSize), parameters.CdnNodeCachePolicy,
+		cacheDurationOrDefault(parameters.CdnNodeCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.CdnNodeCacheCleanUp, cleanupInterval), negativeExpiration)
+	clusterListCache := newEntityCache(cacheSizeOrDefault(parameters.ListCacheSize, defaultListCacheSize), parameters.ListCachePolicy,
+		cacheDurationOrDefault(parameters.ListCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.ListCacheCleanUp, cleanupInterval), negativeExpiration)
+	nodeListCache := newEntityCache(cacheSizeOrDefault(parameters.ListCacheSize, defaultListCacheSize), parameters.ListCachePolicy,
+		cacheDurationOrDefault(parameters.ListCacheExpiration, defaultExpiration), cacheDurationOrDefault(parameters.ListCacheCleanUp, cleanupInterval), negativeExpiration)
+	for _, entityCache := range []*entityCache{bucketCache, nodeCache, accountCache, clusterCache, cdnNodeCache, clusterListCache, nodeListCache} {
+		entityCache.maxStaleness = parameters.MaxStaleness
+		entityCache.refreshAhead = parameters.RefreshAhead
+	}
 
 	return &ddcBucketContractCached{
 		ddcBucketContract: ddcBucketContract,
 		bucketCache:       bucketCache,
 		nodeCache:         nodeCache,
 		accountCache:      accountCache,
+		clusterCache:      clusterCache,
+		cdnNodeCache:      cdnNodeCache,
+		clusterListCache:  clusterListCache,
+		nodeListCache:     nodeListCache,
 	}
 }
 
 func (d *ddcBucketContractCached) HookContractEvents() error {
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketAllocatedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketAllocatedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.BucketAllocatedEvent)
 		d.ClearBucketById(args.BucketId)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.BucketAllocatedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketSettlePaymentEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketSettlePaymentEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.BucketSettlePaymentEvent)
 		d.ClearBucketById(args.BucketId)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.BucketSettlePaymentEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketAvailabilityUpdatedId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketAvailabilityUpdatedId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.BucketAvailabilityUpdatedEvent)
 		d.ClearBucketById(args.BucketId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.BucketAvailabilityUpdatedId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.DepositEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.DepositEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.DepositEvent)
 		d.ClearAccountById(args.AccountId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.DepositEventId)
 	}
 
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketCreatedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketCreatedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.BucketCreatedEvent)
 		d.ClearBucketById(args.BucketId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.BucketCreatedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketParamsSetEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.BucketParamsSetEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.BucketParamsSetEvent)
 		d.ClearBucketById(args.BucketId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.BucketParamsSetEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeAddedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterCreatedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
+		args := raw.(*bucket.ClusterCreatedEvent)
+		d.ClearClusterById(args.ClusterId)
+		d.clusterListCache.Flush()
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterCreatedEventId)
+	}
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterParamsSetEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
+		args := raw.(*bucket.ClusterParamsSetEvent)
+		d.ClearClusterById(args.ClusterId)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterParamsSetEventId)
+	}
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterRemovedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
+		args := raw.(*bucket.ClusterRemovedEvent)
+		d.clearClusterNodes(args.ClusterId)
+		d.clearClusterCdnNodes(args.ClusterId)
+		d.ClearClusterById(args.ClusterId)
+		d.clusterListCache.Flush()
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterRemovedEventId)
+	}
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeAddedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterNodeAddedEvent)
 		d.ClearNodeByKey(args.NodeKey)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterNodeAddedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeRemovedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeRemovedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterNodeRemovedEvent)
 		d.ClearNodeByKey(args.NodeKey)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterNodeRemovedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterCdnNodeAddedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterCdnNodeAddedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterCdnNodeAddedEvent)
-		d.ClearNodeByKey(args.CdnNodeKey)
+		d.ClearCdnNodeByKey(args.CdnNodeKey)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterCdnNodeAddedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterCdnNodeRemovedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterCdnNodeRemovedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterCdnNodeRemovedEvent)
-		d.ClearNodeByKey(args.CdnNodeKey)
+		d.ClearCdnNodeByKey(args.CdnNodeKey)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterCdnNodeRemovedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeStatusSetEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeStatusSetEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterNodeStatusSetEvent)
 		d.ClearNodeByKey(args.NodeKey)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterNodeStatusSetEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterCdnNodeStatusSetEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterCdnNodeStatusSetEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterCdnNodeStatusSetEvent)
-		d.ClearNodeByKey(args.CdnNodeKey)
+		d.ClearCdnNodeByKey(args.CdnNodeKey)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterCdnNodeStatusSetEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeReplacedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeReplacedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterNodeReplacedEvent)
+		// The event names the new node only, the nodes the virtual nodes are taken from are known from the cluster.
+		d.clearClusterNodes(args.ClusterId)
 		d.ClearNodeByKey(args.NodeKey)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterNodeReplacedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeResetEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterNodeResetEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterNodeResetEvent)
 		d.ClearNodeByKey(args.NodeKey)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterNodeResetEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeCreatedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeCreatedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.CdnNodeCreatedEvent)
-		d.ClearNodeByKey(args.CdnNodeKey)
+		d.ClearCdnNodeByKey(args.CdnNodeKey)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.CdnNodeCreatedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeRemovedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeRemovedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.CdnNodeRemovedEvent)
-		d.ClearNodeByKey(args.CdnNodeKey)
+		d.ClearCdnNodeByKey(args.CdnNodeKey)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.CdnNodeRemovedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeParamsSetEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeParamsSetEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.CdnNodeParamsSetEvent)
-		d.ClearNodeByKey(args.CdnNodeKey)
+		d.ClearCdnNodeByKey(args.CdnNodeKey)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.CdnNodeParamsSetEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeRemovedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeRemovedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.NodeRemovedEvent)
 		d.ClearNodeByKey(args.NodeKey)
+		d.nodeListCache.Flush()
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.NodeRemovedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeParamsSetEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeParamsSetEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.NodeParamsSetEvent)
 		d.ClearNodeByKey(args.NodeKey)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.NodeParamsSetEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeCreatedEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeCreatedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.NodeCreatedEvent)
 		d.ClearNodeByKey(args.NodeKey)
+		d.nodeListCache.Flush()
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.NodeCreatedEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.GrantPermissionEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.GrantPermissionEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.GrantPermissionEvent)
 		d.ClearAccountById(args.AccountId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.GrantPermissionEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.RevokePermissionEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.RevokePermissionEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.RevokePermissionEvent)
 		d.ClearAccountById(args.AccountId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.RevokePermissionEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeOwnershipTransferredEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.NodeOwnershipTransferredEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.NodeOwnershipTransferredEvent)
 		d.ClearNodeById(args.NodeKey)
 		d.ClearAccountById(args.AccountId)
+		// The node lists filtered by provider change.
+		d.nodeListCache.Flush()
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.NodeOwnershipTransferredEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeOwnershipTransferredEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.CdnNodeOwnershipTransferredEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.CdnNodeOwnershipTransferredEvent)
-		d.ClearNodeById(args.CdnNodeKey)
+		d.ClearCdnNodeByKey(args.CdnNodeKey)
 		d.ClearAccountById(args.AccountId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.CdnNodeOwnershipTransferredEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterReserveResourceEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterReserveResourceEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterReserveResourceEvent)
 		d.ClearNodeById(args.NodeKey)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterReserveResourceEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterDistributeRevenuesEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterDistributeRevenuesEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterDistributeRevenuesEvent)
 		d.ClearAccountById(args.AccountId)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterDistributeRevenuesEventId)
 	}
-	if err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterDistributeCdnRevenuesEventId, func(raw interface{}) {
+	if _, err := d.ddcBucketContract.AddContractEventHandler(bucket.ClusterDistributeCdnRevenuesEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
 		args := raw.(*bucket.ClusterDistributeCdnRevenuesEvent)
 		d.ClearAccountById(args.ProviderId)
+		d.clearClusterCdnNodes(args.ClusterId)
+		d.ClearClusterById(args.ClusterId)
+		return nil
 	}); err != nil {
 		return errors.Wrap(err, "Unable to hook event "+bucket.ClusterDistributeCdnRevenuesEventId)
 	}
 
+	// Track the block of the events applied for the snapshots, after the invalidations.
+	for event := range d.ddcBucketContract.GetEventDispatcher() {
+		if _, err := d.ddcBucketContract.AddContractEventHandler(event.Hex(), func(ctx pkg.ContractEventContext, _ interface{}) error {
+			d.setEventBlock(ctx.BlockNumber)
+			return nil
+		}); err != nil {
+			return errors.Wrap(err, "Unable to hook event "+event.Hex())
+		}
+	}
+
 	return nil
 }
 
 func (d *ddcBucketContractCached) ClusterGet(clusterId bucket.ClusterId) (*bucket.ClusterInfo, error) {
-	return d.ddcBucketContract.ClusterGet(clusterId)
+	return d.clusterGet(context.Background(), clusterId)
 }
 
-func (d *ddcBucketContractCached) NodeGet(nodeKey bucket.NodeKey) (*bucket.NodeInfo, error) {
+func (d *ddcBucketContractCached) clusterGet(ctx context.Context, clusterId bucket.ClusterId) (*bucket.ClusterInfo, error) {
+	key := toString(clusterId)
+	load := func() (interface{}, error) {
+		return d.ddcBucketContract.ClusterGet(clusterId)
+	}
+	result, err := sharedLoad(ctx, &d.clusterSingleFlight, key, func() (interface{}, error) {
+		return d.clusterCache.load(key, load, load, bucket.ErrClusterDoesNotExist)
+	})
 
-	result, err := d.nodeSingleFlight.Do(nodeKey.ToHexString(), func() (interface{}, error) {
-		if cached, ok := d.nodeCache.Get(nodeKey.ToHexString()); ok {
-			return cached, nil
-		}
+	resp, _ := result.(*bucket.ClusterInfo)
+	return resp, err
+}
 
-		value, err := d.ddcBucketContract.NodeGet(nodeKey)
-		if err != nil {
-			return nil, err
-		}
+func (d *ddcBucketContractCached) NodeGet(nodeKey bucket.NodeKey) (*bucket.NodeInfo, error) {
+	return d.nodeGet(context.Background(), nodeKey)
+}
 
-		d.nodeCache.SetDefault(nodeKey.ToHexString(), value)
-		return value, nil
+func (d *ddcBucketContractCached) nodeGet(ctx context.Context, nodeKey bucket.NodeKey) (*bucket.NodeInfo, error) {
+	load := func() (interface{}, error) {
+		return d.ddcBucketContract.NodeGet(nodeKey)
+	}
+	result, err := sharedLoad(ctx, &d.nodeSingleFlight, nodeKey.ToHexString(), func() (interface{}, error) {
+		return d.nodeCache.load(nodeKey.ToHexString(), load, load, bucket.ErrNodeDoesNotExist)
 	})
 
 	resp, _ := result.(*bucket.NodeInfo)
@@ -266,23 +444,32 @@ func (d *ddcBucketContractCached) NodeGet(nodeKey bucket.NodeKey) (*bucket.NodeI
 }
 
 func (d *ddcBucketContractCached) CdnNodeGet(nodeKey bucket.CdnNodeKey) (*bucket.CdnNodeInfo, error) {
-	return d.ddcBucketContract.CdnNodeGet(nodeKey)
+	return d.cdnNodeGet(context.Background(), nodeKey)
 }
 
-func (d *ddcBucketContractCached) BucketGet(bucketId bucket.BucketId) (*bucket.BucketInfo, error) {
-	key := toString(bucketId)
-	result, err := d.bucketSingleFlight.Do(key, func() (interface{}, error) {
-		if cached, ok := d.bucketCache.Get(key); ok {
-			return cached, nil
-		}
+func (d *ddcBucketContractCached) cdnNodeGet(ctx context.Context, nodeKey bucket.CdnNodeKey) (*bucket.CdnNodeInfo, error) {
+	load := func() (interface{}, error) {
+		return d.ddcBucketContract.CdnNodeGet(nodeKey)
+	}
+	result, err := sharedLoad(ctx, &d.cdnNodeSingleFlight, nodeKey.ToHexString(), func() (interface{}, error) {
+		return d.cdnNodeCache.load(nodeKey.ToHexString(), load, load, bucket.ErrCdnNodeDoesNotExist)
+	})
 
-		value, err := d.ddcBucketContract.BucketGet(bucketId)
-		if err != nil {
-			return nil, err
-		}
+	resp, _ := result.(*bucket.CdnNodeInfo)
+	return resp, err
+}
 
-		d.bucketCache.SetDefault(key, value)
-		return value, nil
+func (d *ddcBucketContractCached) BucketGet(bucketId bucket.BucketId) (*bucket.BucketInfo, error) {
+	return d.bucketGet(context.Background(), bucketId)
+}
+
+func (d *ddcBucketContractCached) bucketGet(ctx context.Context, bucketId bucket.BucketId) (*bucket.BucketInfo, error) {
+	key := toString(bucketId)
+	load := func() (interface{}, error) {
+		return d.ddcBucketContract.BucketGet(bucketId)
+	}
+	result, err := sharedLoad(ctx, &d.bucketSingleFlight, key, func() (interface{}, error) {
+		return d.bucketCache.load(key, load, load, bucket.ErrBucketDoesNotExist)
 	})
 
 	resp, _ := result.(*bucket.BucketInfo)
@@ -290,29 +477,119 @@ func (d *ddcBucketContractCached) BucketGet(bucketId bucket.BucketId) (*bucket.B
 }
 
 func (d *ddcBucketContractCached) AccountGet(account types.AccountID) (*bucket.Account, error) {
-	key := hex.EncodeToString(account[:])
-	result, err := d.accountSingleFlight.Do(key, func() (interface{}, error) {
-		if cached, ok := d.accountCache.Get(key); ok {
-			return cached, nil
-		}
-
-		value, err := d.ddcBucketContract.AccountGet(account)
-		if err != nil {
-			return &bucket.Account{}, err
-		}
+	return d.accountGet(context.Background(), account)
+}
 
-		d.accountCache.SetDefault(key, value)
-		return value, nil
+func (d *ddcBucketContractCached) accountGet(ctx context.Context, account types.AccountID) (*bucket.Account, error) {
+	key := hex.EncodeToString(account[:])
+	load := func() (interface{}, error) {
+		return d.ddcBucketContract.AccountGet(account)
+	}
+	result, err := sharedLoad(ctx, &d.accountSingleFlight, key, func() (interface{}, error) {
+		return d.accountCache.load(key, load, load, bucket.ErrAccountDoesNotExist)
 	})
+	if err != nil {
+		return &bucket.Account{}, err
+	}
 
 	resp, _ := result.(*bucket.Account)
 	return resp, err
 }
 
+// sharedLoad runs the load once for the concurrent callers of the key. The load is not bound to the ctx of any
+// caller, each caller stops waiting for it once its ctx is done.
+func sharedLoad(ctx context.Context, group *singleflight.Group, key string, load func() (interface{}, error)) (interface{}, error) {
+	if ctx.Done() == nil {
+		return group.Do(key, load)
+	}
+
+	type loaded struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan loaded, 1)
+	go func() {
+		value, err := group.Do(key, load)
+		done <- loaded{value: value, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reader serves the cached entities to the reads at the best block, the reads pinned to a block bypass the cache.
+func (d *ddcBucketContractCached) Reader(ctx context.Context, options ...pkg.ReadOption) bucket.DdcBucketContractReader {
+	reader := d.ddcBucketContract.Reader(ctx, options...)
+	readCall := pkg.ReadCall{}
+	for _, option := range options {
+		option(&readCall)
+	}
+	if readCall.At != nil {
+		return reader
+	}
+
+	return &ddcBucketContractCachedReader{DdcBucketContractReader: reader, ctx: ctx, cache: d}
+}
+
+func (r *ddcBucketContractCachedReader) BucketGet(bucketId bucket.BucketId) (*bucket.BucketInfo, error) {
+	return r.cache.bucketGet(r.ctx, bucketId)
+}
+
+func (r *ddcBucketContractCachedReader) NodeGet(nodeKey bucket.NodeKey) (*bucket.NodeInfo, error) {
+	return r.cache.nodeGet(r.ctx, nodeKey)
+}
+
+func (r *ddcBucketContractCachedReader) AccountGet(account types.AccountID) (*bucket.Account, error) {
+	return r.cache.accountGet(r.ctx, account)
+}
+
+func (r *ddcBucketContractCachedReader) ClusterGet(clusterId bucket.ClusterId) (*bucket.ClusterInfo, error) {
+	return r.cache.clusterGet(r.ctx, clusterId)
+}
+
+func (r *ddcBucketContractCachedReader) CdnNodeGet(nodeKey bucket.CdnNodeKey) (*bucket.CdnNodeInfo, error) {
+	return r.cache.cdnNodeGet(r.ctx, nodeKey)
+}
+
+func (r *ddcBucketContractCachedReader) ClusterList(offset types.U32, limit types.U32, filterManagerId types.OptionAccountID) (*bucket.ClusterListInfo, error) {
+	if limit == 0 {
+		return nil, errors.New("Invalid limit. Limit must be greater than zero.")
+	}
+
+	return r.cache.clusterList(r.ctx, offset, limit, filterManagerId)
+}
+
+func (r *ddcBucketContractCachedReader) NodeList(offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*bucket.NodeListInfo, error) {
+	if limit == 0 {
+		return nil, errors.New("Invalid limit. Limit must be greater than zero.")
+	}
+
+	return r.cache.nodeList(r.ctx, offset, limit, filterProviderId)
+}
+
+func (d *ddcBucketContractCached) Stats() ContractCacheStats {
+	return ContractCacheStats{
+		Buckets:      d.bucketCache.Stats(),
+		Nodes:        d.nodeCache.Stats(),
+		Accounts:     d.accountCache.Stats(),
+		Clusters:     d.clusterCache.Stats(),
+		CdnNodes:     d.cdnNodeCache.Stats(),
+		ClusterLists: d.clusterListCache.Stats(),
+		NodeLists:    d.nodeListCache.Stats(),
+	}
+}
+
 func (d *ddcBucketContractCached) Clear() {
 	d.ClearBuckets()
 	d.ClearNodes()
 	d.ClearAccounts()
+	d.ClearClusters()
+	d.ClearCdnNodes()
+	d.ClearLists()
 }
 
 func (d *ddcBucketContractCached) GetContractAddress() string {
@@ -323,7 +600,7 @@ func (d *ddcBucketContractCached) GetLastAccessTime() time.Time {
 	return d.ddcBucketContract.GetLastAccessTime()
 }
 
-func (d *ddcBucketContractCached) AddContractEventHandler(event string, handler func(interface{})) error {
+func (d *ddcBucketContractCached) AddContractEventHandler(event string, handler pkg.ContractEventHandler) (func(), error) {
 	return d.ddcBucketContract.AddContractEventHandler(event, handler)
 }
 
@@ -333,6 +610,7 @@ func (d *ddcBucketContractCached) GetEventDispatcher() map[types.Hash]pkg.Contra
 
 func (d *ddcBucketContractCached) ClearNodes() {
 	d.nodeCache.Flush()
+	d.nodeListCache.Flush()
 }
 
 func (d *ddcBucketContractCached) ClearBuckets() {
@@ -343,12 +621,70 @@ func (d *ddcBucketContractCached) ClearAccounts() {
 	d.accountCache.Flush()
 }
 
+func (d *ddcBucketContractCached) ClearClusters() {
+	d.clusterCache.Flush()
+	d.clusterListCache.Flush()
+}
+
+func (d *ddcBucketContractCached) ClearCdnNodes() {
+	d.cdnNodeCache.Flush()
+}
+
+func (d *ddcBucketContractCached) ClearLists() {
+	d.clusterListCache.Flush()
+	d.nodeListCache.Flush()
+}
+
 func (d *ddcBucketContractCached) ClearNodeById(key bucket.NodeKey) { //nolint:golint,unused
-	d.nodeCache.Delete(key.ToHexString())
+	d.ClearNodeByKey(key)
 }
 
 func (d *ddcBucketContractCached) ClearNodeByKey(nodeKey bucket.NodeKey) {
 	d.nodeCache.Delete(nodeKey.ToHexString())
+	for key, item := range d.nodeListCache.Items() {
+		if nodes, ok := item.(*bucket.NodeListInfo); ok && isNodeListed(nodes, nodeKey) {
+			d.nodeListCache.Delete(key)
+		}
+	}
+}
+
+func (d *ddcBucketContractCached) ClearClusterById(id bucket.ClusterId) {
+	d.clusterCache.Delete(toString(id))
+	for key, item := range d.clusterListCache.Items() {
+		if clusters, ok := item.(*bucket.ClusterListInfo); ok && isClusterListed(clusters, id) {
+			d.clusterListCache.Delete(key)
+		}
+	}
+}
+
+func (d *ddcBucketContractCached) ClearCdnNodeByKey(key bucket.CdnNodeKey) {
+	d.cdnNodeCache.Delete(key.ToHexString())
+}
+
+// clearClusterNodes clears the nodes of the cached cluster, all of them when the cluster is not cached.
+func (d *ddcBucketContractCached) clearClusterNodes(id bucket.ClusterId) {
+	cached, ok := d.clusterCache.Get(toString(id))
+	if !ok {
+		d.ClearNodes()
+		return
+	}
+
+	for _, nodeKey := range cached.(*bucket.ClusterInfo).Cluster.NodesKeys {
+		d.ClearNodeByKey(nodeKey)
+	}
+}
+
+// clearClusterCdnNodes clears the CDN nodes of the cached cluster, all of them when the cluster is not cached.
+func (d *ddcBucketContractCached) clearClusterCdnNodes(id bucket.ClusterId) {
+	cached, ok := d.clusterCache.Get(toString(id))
+	if !ok {
+		d.ClearCdnNodes()
+		return
+	}
+
+	for _, cdnNodeKey := range cached.(*bucket.ClusterInfo).Cluster.CdnNodesKeys {
+		d.ClearCdnNodeByKey(cdnNodeKey)
+	}
 }
 
 func (d *ddcBucketContractCached) ClearBucketById(id bucket.BucketId) {
@@ -367,6 +703,14 @@ func cacheDurationOrDefault(duration time.Duration, defaultDuration time.Duratio
 	return defaultDuration
 }
 
+func cacheSizeOrDefault(size int, defaultSize int) int {
+	if size > 0 {
+		return size
+	}
+
+	return defaultSize
+}
+
 func isNodeKeyPresent(nodeKeys []bucket.NodeKey, nodeKey bucket.NodeKey) bool {
 	for _, key := range nodeKeys {
 		if key == nodeKey {
@@ -376,10 +720,37 @@ func isNodeKeyPresent(nodeKeys []bucket.NodeKey, nodeKey bucket.NodeKey) bool {
 	return false
 }
 
+func isClusterListed(clusters *bucket.ClusterListInfo, clusterId bucket.ClusterId) bool {
+	for _, cluster := range clusters.Clusters {
+		if cluster.ClusterId == clusterId {
+			return true
+		}
+	}
+	return false
+}
+
+func isNodeListed(nodes *bucket.NodeListInfo, nodeKey bucket.NodeKey) bool {
+	for _, node := range nodes.Nodes {
+		if node.Key == nodeKey {
+			return true
+		}
+	}
+	return false
+}
+
 func toString(value bucket.BucketId) string {
 	return strconv.FormatUint(uint64(value), 10)
 }
 
+func listKey(offset types.U32, limit types.U32, filter types.OptionAccountID) string {
+	key := toString(offset) + ":" + toString(limit)
+	if hasFilter, accountId := filter.Unwrap(); hasFilter {
+		key += ":" + hex.EncodeToString(accountId[:])
+	}
+
+	return key
+}
+
 func validateCDNNodeParams(params bucket.CDNNodeParams) error {
 	if params.Url == "" {
 		return errors.New("Empty CDN node URL.")
@@ -401,6 +772,8 @@ func (d *ddcBucketContractCached) ClusterCreate(ctx context.Context, keyPair sig
 		return types.Hash{}, err
 	}
 
+	d.clusterListCache.Flush()
+
 	return blockHash, nil
 }
 
@@ -425,6 +798,7 @@ func (d *ddcBucketContractCached) ClusterAddNode(ctx context.Context, keyPair si
 
 	d.ClearBuckets()
 	d.ClearNodes()
+	d.ClearClusterById(clusterId)
 
 	return nil
 }
@@ -437,6 +811,7 @@ func (d *ddcBucketContractCached) ClusterRemoveNode(ctx context.Context, keyPair
 
 	// If the node removal from the contract was successful, clear the cached node status.e
 	d.ClearNodeByKey(nodeKey)
+	d.ClearClusterById(clusterId)
 
 	return nil
 }
@@ -458,6 +833,7 @@ func (d *ddcBucketContractCached) ClusterResetNode(ctx context.Context, keyPair
 	}
 
 	d.ClearNodeByKey(nodeKey)
+	d.ClearClusterById(clusterId)
 
 	return nil
 }
@@ -483,6 +859,7 @@ func (d *ddcBucketContractCached) ClusterReplaceNode(ctx context.Context, keyPai
 
 	d.ClearBuckets()
 	d.ClearNodes()
+	d.ClearClusterById(clusterId)
 
 	return nil
 }
@@ -503,7 +880,8 @@ func (d *ddcBucketContractCached) ClusterAddCdnNode(ctx context.Context, keyPair
 	}
 
 	d.ClearBuckets()
-	d.ClearNodes()
+	d.ClearCdnNodeByKey(cdnNodeKey)
+	d.ClearClusterById(clusterId)
 
 	return nil
 }
@@ -524,7 +902,8 @@ func (d *ddcBucketContractCached) ClusterRemoveCdnNode(ctx context.Context, keyP
 		return err
 	}
 
-	d.ClearNodeByKey(cdnNodeKey)
+	d.ClearCdnNodeByKey(cdnNodeKey)
+	d.ClearClusterById(clusterId)
 
 	return nil
 }
@@ -546,6 +925,7 @@ func (d *ddcBucketContractCached) ClusterSetParams(ctx context.Context, keyPair
 
 	d.ClearBuckets()
 	d.ClearNodes()
+	d.ClearClusterById(clusterId)
 
 	return nil
 }
@@ -563,6 +943,9 @@ func (d *ddcBucketContractCached) ClusterRemove(ctx context.Context, keyPair sig
 
 	d.ClearBuckets()
 	d.ClearNodes()
+	d.clearClusterCdnNodes(clusterId)
+	d.ClearClusterById(clusterId)
+	d.clusterListCache.Flush()
 
 	return nil
 }
@@ -611,7 +994,7 @@ func (d *ddcBucketContractCached) ClusterSetCdnNodeStatus(ctx context.Context, k
 		return err
 	}
 
-	d.ClearNodeByKey(cdnNodeKey)
+	d.ClearCdnNodeByKey(cdnNodeKey)
 
 	return nil
 }
@@ -621,16 +1004,20 @@ func (d *ddcBucketContractCached) ClusterList(offset types.U32, limit types.U32,
 		return nil, errors.New("Invalid limit. Limit must be greater than zero.")
 	}
 
-	clusters, err := d.ddcBucketContract.ClusterList(offset, limit, filterManagerId)
+	return d.clusterList(context.Background(), offset, limit, filterManagerId)
+}
 
-	if err != nil {
-		return nil, err
+func (d *ddcBucketContractCached) clusterList(ctx context.Context, offset types.U32, limit types.U32, filterManagerId types.OptionAccountID) (*bucket.ClusterListInfo, error) {
+	key := listKey(offset, limit, filterManagerId)
+	load := func() (interface{}, error) {
+		return d.ddcBucketContract.ClusterList(offset, limit, filterManagerId)
 	}
+	result, err := sharedLoad(ctx, &d.clusterListSingleFlight, key, func() (interface{}, error) {
+		return d.clusterListCache.load(key, load, load)
+	})
 
-	d.ClearBuckets()
-	d.ClearNodes()
-
-	return clusters, nil
+	resp, _ := result.(*bucket.ClusterListInfo)
+	return resp, err
 }
 
 func (d *ddcBucketContractCached) NodeCreate(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.NodeKey, params bucket.Params, capacity bucket.Resource, rent bucket.Rent) (blockHash types.Hash, err error) {
@@ -671,12 +1058,20 @@ func (d *ddcBucketContractCached) NodeList(offset types.U32, limit types.U32, fi
 		return nil, errors.New("Invalid limit. Limit must be greater than zero.")
 	}
 
-	nodes, err := d.ddcBucketContract.NodeList(offset, limit, filterProviderId)
-	if err != nil {
-		return nil, err
+	return d.nodeList(context.Background(), offset, limit, filterProviderId)
+}
+
+func (d *ddcBucketContractCached) nodeList(ctx context.Context, offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*bucket.NodeListInfo, error) {
+	key := listKey(offset, limit, filterProviderId)
+	load := func() (interface{}, error) {
+		return d.ddcBucketContract.NodeList(offset, limit, filterProviderId)
 	}
+	result, err := sharedLoad(ctx, &d.nodeListSingleFlight, key, func() (interface{}, error) {
+		return d.nodeListCache.load(key, load, load)
+	})
 
-	return nodes, nil
+	resp, _ := result.(*bucket.NodeListInfo)
+	return resp, err
 }
 
 func (d *ddcBucketContractCached) CdnNodeCreate(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.CdnNodeKey, params bucket.CDNNodeParams) error {
@@ -686,7 +1081,7 @@ func (d *ddcBucketContractCached) CdnNodeCreate(ctx context.Context, keyPair sig
 		return err
 	}
 
-	d.ClearNodes()
+	d.ClearCdnNodeByKey(nodeKey)
 
 	return err
 }
@@ -699,7 +1094,7 @@ func (d *ddcBucketContractCached) CdnNodeRemove(ctx context.Context, keyPair sig
 
 	// Clear the corresponding cache since the CDN node data has been modified.
 	d.ClearBuckets()
-	d.ClearNodes()
+	d.ClearCdnNodeByKey(nodeKey)
 
 	return nil
 }
@@ -714,7 +1109,7 @@ func (d *ddcBucketContractCached) CdnNodeSetParams(ctx context.Context, keyPair
 		return err
 	}
 
-	d.ClearNodes()
+	d.ClearCdnNodeByKey(nodeKey)
 
 	return nil
 }
@@ -796,6 +1191,7 @@ func (d *ddcBucketContractCached) AdminTransferCdnNodeOwnership(ctx context.Cont
 
 	d.ClearBuckets()
 	d.ClearNodes()
+	d.ClearCdnNodeByKey(cdnNodeKey)
 
 	return err
 }
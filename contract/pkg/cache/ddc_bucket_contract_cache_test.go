@@ -9,13 +9,13 @@ import (
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
 	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
 	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
-	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 type mockedDdcBucketContract struct {
 	mock.Mock
+	handlers map[types.Hash][]pkg.ContractEventHandler
 }
 
 func (m *mockedDdcBucketContract) GetContractAddress() string {
@@ -67,12 +67,35 @@ func (m *mockedDdcBucketContract) ClusterCreate(ctx context.Context, keyPair sig
 	return types.Hash{}, nil
 }
 
-func (d *mockedDdcBucketContract) AddContractEventHandler(event string, handler func(interface{})) error {
-	return nil
+func (d *mockedDdcBucketContract) AddContractEventHandler(event string, handler pkg.ContractEventHandler) (func(), error) {
+	if d.handlers == nil {
+		d.handlers = make(map[types.Hash][]pkg.ContractEventHandler)
+	}
+	key, err := types.NewHashFromHexString(event)
+	if err != nil {
+		return nil, err
+	}
+	d.handlers[key] = append(d.handlers[key], handler)
+	return func() {}, nil
+}
+
+func (d *mockedDdcBucketContract) emit(event string, args interface{}) {
+	d.emitAt(0, event, args)
+}
+
+func (d *mockedDdcBucketContract) emitAt(block types.BlockNumber, event string, args interface{}) {
+	key, _ := types.NewHashFromHexString(event)
+	for _, handler := range d.handlers[key] {
+		_ = handler(pkg.ContractEventContext{BlockNumber: block}, args)
+	}
 }
 
 func (d *mockedDdcBucketContract) GetEventDispatcher() map[types.Hash]pkg.ContractEventDispatchEntry {
-	return nil
+	dispatcher := make(map[types.Hash]pkg.ContractEventDispatchEntry)
+	for key := range d.handlers {
+		dispatcher[key] = pkg.ContractEventDispatchEntry{}
+	}
+	return dispatcher
 }
 
 func (m *mockedDdcBucketContract) AdminGrantPermission(ctx context.Context, keyPair signature.KeyringPair, grantee bucket.AccountId, permission string) error {
@@ -280,7 +303,7 @@ func (m *mockedDdcBucketContract) BucketRevokeReaderPerm(ctx context.Context, ke
 func TestBucketGet(t *testing.T) {
 	//given
 	ddcBucketContract := &mockedDdcBucketContract{}
-	testSubject := &ddcBucketContractCached{bucketCache: cache.New(defaultExpiration, cleanupInterval), ddcBucketContract: ddcBucketContract}
+	testSubject := &ddcBucketContractCached{bucketCache: newEntityCache(defaultCacheSize, LRU, defaultExpiration, cleanupInterval, defaultNegativeExpiration), ddcBucketContract: ddcBucketContract}
 	result := &bucket.BucketInfo{}
 	ddcBucketContract.On("BucketGet", types.NewU32(1)).Return(result, nil).Once()
 
@@ -296,7 +319,7 @@ func TestBucketGet(t *testing.T) {
 func TestBucketGetCached(t *testing.T) {
 	//given
 	ddcBucketContract := &mockedDdcBucketContract{}
-	testSubject := &ddcBucketContractCached{bucketCache: cache.New(defaultExpiration, cleanupInterval), ddcBucketContract: ddcBucketContract}
+	testSubject := &ddcBucketContractCached{bucketCache: newEntityCache(defaultCacheSize, LRU, defaultExpiration, cleanupInterval, defaultNegativeExpiration), ddcBucketContract: ddcBucketContract}
 	result := &bucket.BucketInfo{BucketId: types.NewU32(1)}
 	ddcBucketContract.On("BucketGet", types.NewU32(1)).Return(result, nil).Once()
 	_, _ = testSubject.BucketGet(types.NewU32(1))
@@ -311,10 +334,170 @@ func TestBucketGetCached(t *testing.T) {
 	ddcBucketContract.AssertNumberOfCalls(t, "BucketGet", 1)
 }
 
+func TestBucketGetReader(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := &ddcBucketContractCached{bucketCache: newEntityCache(defaultCacheSize, LRU, defaultExpiration, cleanupInterval, defaultNegativeExpiration), ddcBucketContract: ddcBucketContract}
+	result := &bucket.BucketInfo{BucketId: types.NewU32(1)}
+	ddcBucketContract.On("BucketGet", types.NewU32(1)).Return(result, nil).Twice()
+	_, _ = testSubject.Reader(context.Background()).BucketGet(types.NewU32(1))
+
+	//when
+	cached, errCached := testSubject.BucketGet(types.NewU32(1))
+	pinned, errPinned := testSubject.Reader(context.Background(), pkg.ReadAt(types.Hash{1})).BucketGet(types.NewU32(1))
+
+	//then
+	assert.NoError(t, errCached)
+	assert.NoError(t, errPinned)
+	assert.Equal(t, result, cached)
+	assert.Equal(t, result, pinned)
+	ddcBucketContract.AssertExpectations(t)
+	ddcBucketContract.AssertNumberOfCalls(t, "BucketGet", 2)
+}
+
+func TestClusterGetCached(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	result := &bucket.ClusterInfo{ClusterId: types.NewU32(1)}
+	ddcBucketContract.On("ClusterGet", types.NewU32(1)).Return(result, nil).Once()
+	_, _ = testSubject.ClusterGet(types.NewU32(1))
+
+	//when
+	cluster, err := testSubject.Reader(context.Background()).ClusterGet(types.NewU32(1))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, result, cluster)
+	ddcBucketContract.AssertNumberOfCalls(t, "ClusterGet", 1)
+}
+
+func TestClusterGetInvalidatedByEvent(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	assert.NoError(t, testSubject.HookContractEvents())
+	ddcBucketContract.On("ClusterGet", types.NewU32(1)).Return(&bucket.ClusterInfo{ClusterId: types.NewU32(1)}, nil).Twice()
+	ddcBucketContract.On("ClusterGet", types.NewU32(2)).Return(&bucket.ClusterInfo{ClusterId: types.NewU32(2)}, nil).Once()
+	_, _ = testSubject.ClusterGet(types.NewU32(1))
+	_, _ = testSubject.ClusterGet(types.NewU32(2))
+
+	//when
+	ddcBucketContract.emit(bucket.ClusterParamsSetEventId, &bucket.ClusterParamsSetEvent{ClusterId: types.NewU32(1)})
+	_, err1 := testSubject.ClusterGet(types.NewU32(1))
+	_, err2 := testSubject.ClusterGet(types.NewU32(2))
+
+	//then
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	ddcBucketContract.AssertExpectations(t)
+	ddcBucketContract.AssertNumberOfCalls(t, "ClusterGet", 3)
+}
+
+func TestClusterRemovedClearsMembers(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	assert.NoError(t, testSubject.HookContractEvents())
+	nodeKey, cdnNodeKey, otherKey := types.AccountID{1}, types.AccountID{2}, types.AccountID{3}
+	cluster := &bucket.ClusterInfo{ClusterId: types.NewU32(1), Cluster: bucket.Cluster{NodesKeys: []bucket.NodeKey{nodeKey}, CdnNodesKeys: []bucket.CdnNodeKey{cdnNodeKey}}}
+	ddcBucketContract.On("ClusterGet", types.NewU32(1)).Return(cluster, nil).Once()
+	ddcBucketContract.On("NodeGet", nodeKey).Return(&bucket.NodeInfo{Key: nodeKey}, nil).Twice()
+	ddcBucketContract.On("NodeGet", otherKey).Return(&bucket.NodeInfo{Key: otherKey}, nil).Once()
+	ddcBucketContract.On("CdnNodeGet", cdnNodeKey).Return(&bucket.CdnNodeInfo{Key: cdnNodeKey}, nil).Twice()
+	_, _ = testSubject.ClusterGet(types.NewU32(1))
+	_, _ = testSubject.NodeGet(nodeKey)
+	_, _ = testSubject.NodeGet(otherKey)
+	_, _ = testSubject.CdnNodeGet(cdnNodeKey)
+
+	//when
+	ddcBucketContract.emit(bucket.ClusterRemovedEventId, &bucket.ClusterRemovedEvent{ClusterId: types.NewU32(1)})
+	_, _ = testSubject.NodeGet(nodeKey)
+	_, _ = testSubject.NodeGet(otherKey)
+	_, _ = testSubject.CdnNodeGet(cdnNodeKey)
+
+	//then
+	ddcBucketContract.AssertExpectations(t)
+	ddcBucketContract.AssertNumberOfCalls(t, "NodeGet", 3)
+	ddcBucketContract.AssertNumberOfCalls(t, "CdnNodeGet", 2)
+}
+
+func TestCdnNodeGetInvalidatedByEvent(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	assert.NoError(t, testSubject.HookContractEvents())
+	cdnNodeKey := types.AccountID{1}
+	ddcBucketContract.On("CdnNodeGet", cdnNodeKey).Return(&bucket.CdnNodeInfo{Key: cdnNodeKey}, nil).Twice()
+	_, _ = testSubject.CdnNodeGet(cdnNodeKey)
+	_, _ = testSubject.CdnNodeGet(cdnNodeKey)
+
+	//when
+	ddcBucketContract.emit(bucket.CdnNodeParamsSetEventId, &bucket.CdnNodeParamsSetEvent{CdnNodeKey: cdnNodeKey})
+	node, err := testSubject.CdnNodeGet(cdnNodeKey)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, cdnNodeKey, node.Key)
+	ddcBucketContract.AssertExpectations(t)
+}
+
+func TestClusterListInvalidatedByEvent(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	assert.NoError(t, testSubject.HookContractEvents())
+	firstPage := &bucket.ClusterListInfo{Clusters: []bucket.ClusterInfo{{ClusterId: types.NewU32(1)}}, Total: 2}
+	secondPage := &bucket.ClusterListInfo{Clusters: []bucket.ClusterInfo{{ClusterId: types.NewU32(2)}}, Total: 2}
+	ddcBucketContract.On("ClusterList", types.NewU32(0), types.NewU32(1), types.OptionAccountID{}).Return(firstPage, nil).Twice()
+	ddcBucketContract.On("ClusterList", types.NewU32(1), types.NewU32(1), types.OptionAccountID{}).Return(secondPage, nil).Twice()
+	_, _ = testSubject.ClusterList(0, 1, types.OptionAccountID{})
+	_, _ = testSubject.ClusterList(1, 1, types.OptionAccountID{})
+
+	//when
+	ddcBucketContract.emit(bucket.ClusterParamsSetEventId, &bucket.ClusterParamsSetEvent{ClusterId: types.NewU32(2)})
+	_, _ = testSubject.ClusterList(0, 1, types.OptionAccountID{})
+	_, _ = testSubject.ClusterList(1, 1, types.OptionAccountID{})
+	ddcBucketContract.emit(bucket.ClusterCreatedEventId, &bucket.ClusterCreatedEvent{ClusterId: types.NewU32(3)})
+	clusters, err := testSubject.ClusterList(0, 1, types.OptionAccountID{})
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, firstPage, clusters)
+	ddcBucketContract.AssertExpectations(t)
+	ddcBucketContract.AssertNumberOfCalls(t, "ClusterList", 4)
+}
+
+func TestNodeListInvalidatedByEvent(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	assert.NoError(t, testSubject.HookContractEvents())
+	nodeKey := types.AccountID{1}
+	provider := types.NewOptionAccountID(types.AccountID{9})
+	nodes := &bucket.NodeListInfo{Nodes: []bucket.NodeInfo{{Key: nodeKey}}, Total: 1}
+	ddcBucketContract.On("NodeList", types.NewU32(0), types.NewU32(10), provider).Return(nodes, nil).Twice()
+	ddcBucketContract.On("NodeList", types.NewU32(0), types.NewU32(10), types.OptionAccountID{}).Return(nodes, nil).Twice()
+	_, _ = testSubject.NodeList(0, 10, provider)
+	_, _ = testSubject.NodeList(0, 10, provider)
+
+	//when
+	ddcBucketContract.emit(bucket.NodeParamsSetEventId, &bucket.NodeParamsSetEvent{NodeKey: nodeKey})
+	_, _ = testSubject.NodeList(0, 10, provider)
+	_, _ = testSubject.NodeList(0, 10, types.OptionAccountID{})
+	ddcBucketContract.emit(bucket.NodeCreatedEventId, &bucket.NodeCreatedEvent{NodeKey: types.AccountID{2}})
+	list, err := testSubject.NodeList(0, 10, types.OptionAccountID{})
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, nodes, list)
+	ddcBucketContract.AssertExpectations(t)
+}
+
 // func TestCDNNodeList(t *testing.T) {
 // 	//given
 //     ddcBucketContract := &mockedDdcBucketContract{}
-//     testSubject := &ddcBucketContractCached{bucketCache: cache.New(defaultExpiration, cleanupInterval), ddcBucketContract: ddcBucketContract}
+//     testSubject := &ddcBucketContractCached{bucketCache: newEntityCache(defaultCacheSize, LRU, defaultExpiration, cleanupInterval, defaultNegativeExpiration), ddcBucketContract: ddcBucketContract}
 //     result := []*bucket.CdnNodeInfo{}
 //     ddcBucketContract.On("CdnNodeGet", 0, 1, "filterManagerId").Return(result, nil).Once()
 
@@ -327,3 +510,80 @@ func TestBucketGetCached(t *testing.T) {
 //     ddcBucketContract.AssertExpectations(t)
 // }
 //
+
+func (m *mockedDdcBucketContract) Reader(ctx context.Context, options ...pkg.ReadOption) bucket.DdcBucketContractReader {
+	return m
+}
+
+func TestBucketGetDoesNotExistCached(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	assert.NoError(t, testSubject.HookContractEvents())
+	ddcBucketContract.On("BucketGet", types.NewU32(1)).Return(&bucket.BucketInfo{}, bucket.ErrBucketDoesNotExist).Once()
+	ddcBucketContract.On("BucketGet", types.NewU32(1)).Return(&bucket.BucketInfo{BucketId: types.NewU32(1)}, nil).Once()
+	_, _ = testSubject.BucketGet(types.NewU32(1))
+
+	//when
+	_, errMissing := testSubject.BucketGet(types.NewU32(1))
+	ddcBucketContract.emit(bucket.BucketCreatedEventId, &bucket.BucketCreatedEvent{BucketId: types.NewU32(1)})
+	created, errCreated := testSubject.BucketGet(types.NewU32(1))
+
+	//then
+	assert.ErrorIs(t, errMissing, bucket.ErrBucketDoesNotExist)
+	assert.NoError(t, errCreated)
+	assert.Equal(t, types.NewU32(1), created.BucketId)
+	assert.Equal(t, uint64(1), testSubject.Stats().Buckets.NegativeHits)
+	ddcBucketContract.AssertExpectations(t)
+}
+
+func TestBucketGetStaleWhileRevalidate(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{BucketCacheExpiration: time.Millisecond, MaxStaleness: time.Hour})
+	stale := &bucket.BucketInfo{BucketId: types.NewU32(1), Params: "stale"}
+	fresh := &bucket.BucketInfo{BucketId: types.NewU32(1), Params: "fresh"}
+	ddcBucketContract.On("BucketGet", types.NewU32(1)).Return(stale, nil).Once()
+	ddcBucketContract.On("BucketGet", types.NewU32(1)).Return(fresh, nil).Once()
+	_, _ = testSubject.BucketGet(types.NewU32(1))
+	time.Sleep(2 * time.Millisecond)
+
+	//when
+	served, err := testSubject.Reader(context.Background()).BucketGet(types.NewU32(1))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, stale, served)
+	assert.Eventually(t, func() bool {
+		return testSubject.Stats().Buckets.Loads == 2
+	}, time.Second, time.Millisecond)
+	ddcBucketContract.AssertExpectations(t)
+}
+
+func TestBucketGetReaderContextNotShared(t *testing.T) {
+	//given
+	ddcBucketContract := &mockedDdcBucketContract{}
+	testSubject := CreateDdcBucketContractCache(ddcBucketContract, BucketCacheParameters{})
+	result := &bucket.BucketInfo{BucketId: types.NewU32(1)}
+	release := make(chan time.Time)
+	ddcBucketContract.On("BucketGet", types.NewU32(1)).WaitUntil(release).Return(result, nil).Once()
+	ctx, cancel := context.WithCancel(context.Background())
+	loaded := make(chan *bucket.BucketInfo)
+	go func() {
+		info, _ := testSubject.Reader(context.Background()).BucketGet(types.NewU32(1))
+		loaded <- info
+	}()
+	assert.Eventually(t, func() bool {
+		return testSubject.Stats().Buckets.Misses == 1
+	}, time.Second, time.Millisecond)
+
+	//when
+	cancel()
+	_, errCancelled := testSubject.Reader(ctx).BucketGet(types.NewU32(1))
+	close(release)
+
+	//then
+	assert.ErrorIs(t, errCancelled, context.Canceled)
+	assert.Equal(t, result, <-loaded)
+	ddcBucketContract.AssertNumberOfCalls(t, "BucketGet", 1)
+}
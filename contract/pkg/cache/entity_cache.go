@@ -0,0 +1,434 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCacheSize          = 10_000
+	defaultListCacheSize      = 1_000
+	defaultNegativeExpiration = 10 * time.Second
+)
+
+const (
+	// LRU evicts the least recently used entry of a full cache.
+	LRU EvictionPolicy = iota
+	// LFU evicts the least frequently used entry of a full cache, the least recently used one among equals.
+	LFU
+)
+
+type (
+	EvictionPolicy int
+
+	// CacheStats are the counters of an entity cache since its creation.
+	CacheStats struct {
+		Size int
+		Hits uint64
+		// NegativeHits are the lookups answered by a cached "does not exist" result.
+		NegativeHits uint64
+		Misses       uint64
+		// Evictions are the entries removed to respect the size of the cache.
+		Evictions  uint64
+		Loads      uint64
+		LoadErrors uint64
+		// LoadTime is the total time spent loading the missed and refreshed entries from the contract.
+		LoadTime time.Duration
+		// StaleHits are the lookups answered by an expired entry while it is refreshed.
+		StaleHits uint64
+		// Refreshes are the background refreshes of the expired or soon expiring entries.
+		Refreshes uint64
+	}
+
+	// entityCache is a size-bounded cache of contract entities with an expiration, it also caches the "does not exist"
+	// results of the lookups for a short while.
+	entityCache struct {
+		// the counters come first to be aligned for the atomic operations
+		hits         uint64
+		negativeHits uint64
+		misses       uint64
+		evictions    uint64
+		loads        uint64
+		loadErrors   uint64
+		loadTime     int64
+		staleHits    uint64
+		refreshes    uint64
+
+		mutex              sync.Mutex
+		entries            map[string]*cacheEntry
+		order              evictionOrder
+		size               int
+		expiration         time.Duration
+		negativeExpiration time.Duration
+		cleanupInterval    time.Duration
+		nextCleanup        time.Time
+		// maxStaleness is how long the expired entries are served while refreshed, 0 disables it.
+		maxStaleness time.Duration
+		// refreshAhead is the time before the expiration the entries read are refreshed, 0 disables it.
+		refreshAhead time.Duration
+	}
+
+	cacheEntry struct {
+		key        string
+		value      interface{}
+		missing    error
+		expiration time.Time
+		frequency  int
+		element    *list.Element
+		refreshing bool
+		// version is bumped by every set, a refresh started before is outdated.
+		version uint64
+	}
+
+	evictionOrder interface {
+		add(entry *cacheEntry)
+		touch(entry *cacheEntry)
+		remove(entry *cacheEntry)
+		victim() *cacheEntry
+		reset()
+	}
+
+	lruOrder struct {
+		entries *list.List
+	}
+
+	// lfuOrder keeps a list per frequency, the most recently used entries first.
+	lfuOrder struct {
+		frequencies  map[int]*list.List
+		minFrequency int
+	}
+)
+
+func newEntityCache(size int, policy EvictionPolicy, expiration time.Duration, cleanupInterval time.Duration, negativeExpiration time.Duration) *entityCache {
+	var order evictionOrder
+	if policy == LFU {
+		order = &lfuOrder{frequencies: make(map[int]*list.List)}
+	} else {
+		order = &lruOrder{entries: list.New()}
+	}
+
+	return &entityCache{
+		entries:            make(map[string]*cacheEntry),
+		order:              order,
+		size:               size,
+		expiration:         expiration,
+		negativeExpiration: negativeExpiration,
+		cleanupInterval:    cleanupInterval,
+		nextCleanup:        time.Now().Add(cleanupInterval),
+	}
+}
+
+// load returns the cached value of the key or loads it, the errors matching notFound are cached as the value. The
+// expired entries within the staleness bound and the entries read shortly before their expiration are served as they are
+// and refreshed in the background with the refresher.
+func (c *entityCache) load(key string, loader func() (interface{}, error), refresher func() (interface{}, error), notFound ...error) (interface{}, error) {
+	if value, ok, missing := c.lookup(key, refresher, notFound); ok {
+		return value, missing
+	}
+
+	value, err := c.timed(loader)
+	if err != nil {
+		if isNotFound(err, notFound) {
+			c.setMissing(key, err)
+		}
+		return nil, err
+	}
+
+	c.SetDefault(key, value)
+	return value, nil
+}
+
+// lookup counts the hits and misses, unlike Get it returns the cached "does not exist" results.
+func (c *entityCache) lookup(key string, refresher func() (interface{}, error), notFound []error) (interface{}, bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	entry, ok := c.live(key)
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false, nil
+	}
+
+	c.order.touch(entry)
+	if entry.missing != nil {
+		atomic.AddUint64(&c.negativeHits, 1)
+		return nil, true, entry.missing
+	}
+
+	if now.After(entry.expiration) {
+		atomic.AddUint64(&c.staleHits, 1)
+		c.refresh(entry, refresher, notFound)
+	} else {
+		atomic.AddUint64(&c.hits, 1)
+		if c.refreshAhead > 0 && now.After(entry.expiration.Add(-c.refreshAhead)) {
+			c.refresh(entry, refresher, notFound)
+		}
+	}
+
+	return entry.value, true, nil
+}
+
+// refresh reloads the entry in the background unless it is already, the result is dropped if the entry is removed or
+// set meanwhile. The entry is kept as it is on failure.
+func (c *entityCache) refresh(entry *cacheEntry, refresher func() (interface{}, error), notFound []error) {
+	if entry.refreshing || refresher == nil {
+		return
+	}
+	entry.refreshing = true
+	version := entry.version
+	atomic.AddUint64(&c.refreshes, 1)
+
+	go func() {
+		value, err := c.timed(refresher)
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		entry.refreshing = false
+		if c.entries[entry.key] != entry || entry.version != version {
+			return
+		}
+		if err != nil {
+			if isNotFound(err, notFound) {
+				entry.value = nil
+				entry.missing = err
+				entry.expiration = time.Now().Add(c.negativeExpiration)
+			}
+			return
+		}
+
+		entry.value = value
+		entry.expiration = time.Now().Add(c.expiration)
+	}()
+}
+
+func (c *entityCache) timed(loader func() (interface{}, error)) (interface{}, error) {
+	start := time.Now()
+	value, err := loader()
+	atomic.AddInt64(&c.loadTime, int64(time.Since(start)))
+	atomic.AddUint64(&c.loads, 1)
+	if err != nil {
+		atomic.AddUint64(&c.loadErrors, 1)
+	}
+
+	return value, err
+}
+
+func (c *entityCache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.live(key)
+	if !ok || entry.missing != nil {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (c *entityCache) SetDefault(key string, value interface{}) {
+	c.set(key, value, nil, c.expiration)
+}
+
+func (c *entityCache) setMissing(key string, err error) {
+	c.set(key, nil, err, c.negativeExpiration)
+}
+
+func (c *entityCache) set(key string, value interface{}, missing error, expiration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if now.After(c.nextCleanup) {
+		c.deleteExpired(now)
+		c.nextCleanup = now.Add(c.cleanupInterval)
+	}
+
+	if entry, ok := c.entries[key]; ok {
+		entry.value = value
+		entry.missing = missing
+		entry.expiration = now.Add(expiration)
+		entry.version++
+		c.order.touch(entry)
+		return
+	}
+
+	for len(c.entries) >= c.size && c.size > 0 {
+		c.delete(c.order.victim())
+		atomic.AddUint64(&c.evictions, 1)
+	}
+
+	entry := &cacheEntry{key: key, value: value, missing: missing, expiration: now.Add(expiration)}
+	c.entries[key] = entry
+	c.order.add(entry)
+}
+
+func (c *entityCache) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		c.delete(entry)
+	}
+}
+
+func (c *entityCache) Flush() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[string]*cacheEntry)
+	c.order.reset()
+}
+
+// Items returns the values cached and not expired by key, including the stale ones still served.
+func (c *entityCache) Items() map[string]interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	items := make(map[string]interface{}, len(c.entries))
+	for key, entry := range c.entries {
+		if entry.missing == nil && !c.expired(entry, now) {
+			items[key] = entry.value
+		}
+	}
+
+	return items
+}
+
+func (c *entityCache) Stats() CacheStats {
+	c.mutex.Lock()
+	size := len(c.entries)
+	c.mutex.Unlock()
+
+	return CacheStats{
+		Size:         size,
+		Hits:         atomic.LoadUint64(&c.hits),
+		NegativeHits: atomic.LoadUint64(&c.negativeHits),
+		Misses:       atomic.LoadUint64(&c.misses),
+		Evictions:    atomic.LoadUint64(&c.evictions),
+		Loads:        atomic.LoadUint64(&c.loads),
+		LoadErrors:   atomic.LoadUint64(&c.loadErrors),
+		LoadTime:     time.Duration(atomic.LoadInt64(&c.loadTime)),
+		StaleHits:    atomic.LoadUint64(&c.staleHits),
+		Refreshes:    atomic.LoadUint64(&c.refreshes),
+	}
+}
+
+// live returns the entry unless it expired, the expired entries are still served within the staleness bound.
+func (c *entityCache) live(key string) (*cacheEntry, bool) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.expired(entry, time.Now()) {
+		c.delete(entry)
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (c *entityCache) expired(entry *cacheEntry, now time.Time) bool {
+	if entry.missing != nil {
+		return now.After(entry.expiration)
+	}
+
+	return now.After(entry.expiration.Add(c.maxStaleness))
+}
+
+func (c *entityCache) delete(entry *cacheEntry) {
+	delete(c.entries, entry.key)
+	c.order.remove(entry)
+}
+
+func (c *entityCache) deleteExpired(now time.Time) {
+	for _, entry := range c.entries {
+		if c.expired(entry, now) {
+			c.delete(entry)
+		}
+	}
+}
+
+func isNotFound(err error, notFound []error) bool {
+	for _, target := range notFound {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *lruOrder) add(entry *cacheEntry) {
+	entry.element = o.entries.PushFront(entry)
+}
+
+func (o *lruOrder) touch(entry *cacheEntry) {
+	o.entries.MoveToFront(entry.element)
+}
+
+func (o *lruOrder) remove(entry *cacheEntry) {
+	o.entries.Remove(entry.element)
+}
+
+func (o *lruOrder) victim() *cacheEntry {
+	return o.entries.Back().Value.(*cacheEntry)
+}
+
+func (o *lruOrder) reset() {
+	o.entries.Init()
+}
+
+func (o *lfuOrder) add(entry *cacheEntry) {
+	entry.frequency = 1
+	o.minFrequency = 1
+	entry.element = o.frequency(1).PushFront(entry)
+}
+
+func (o *lfuOrder) touch(entry *cacheEntry) {
+	o.remove(entry)
+	entry.frequency++
+	entry.element = o.frequency(entry.frequency).PushFront(entry)
+}
+
+func (o *lfuOrder) remove(entry *cacheEntry) {
+	entries := o.frequencies[entry.frequency]
+	entries.Remove(entry.element)
+	if entries.Len() == 0 {
+		delete(o.frequencies, entry.frequency)
+	}
+}
+
+func (o *lfuOrder) victim() *cacheEntry {
+	if _, ok := o.frequencies[o.minFrequency]; !ok {
+		o.minFrequency = 0
+		for frequency := range o.frequencies {
+			if o.minFrequency == 0 || frequency < o.minFrequency {
+				o.minFrequency = frequency
+			}
+		}
+	}
+
+	return o.frequencies[o.minFrequency].Back().Value.(*cacheEntry)
+}
+
+func (o *lfuOrder) reset() {
+	o.frequencies = make(map[int]*list.List)
+	o.minFrequency = 0
+}
+
+func (o *lfuOrder) frequency(frequency int) *list.List {
+	entries, ok := o.frequencies[frequency]
+	if !ok {
+		entries = list.New()
+		o.frequencies[frequency] = entries
+	}
+
+	return entries
+}
@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	"github.com/stretchr/testify/assert"
+)
+
+func loaded(value interface{}) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return value, nil
+	}
+}
+
+func TestEntityCacheLRU(t *testing.T) {
+	//given
+	testSubject := newEntityCache(2, LRU, time.Hour, time.Hour, time.Second)
+	testSubject.SetDefault("a", 1)
+	testSubject.SetDefault("b", 2)
+	_, _ = testSubject.load("a", loaded(0), nil)
+
+	//when
+	testSubject.SetDefault("c", 3)
+
+	//then
+	assert.Equal(t, map[string]interface{}{"a": 1, "c": 3}, testSubject.Items())
+	assert.Equal(t, CacheStats{Size: 2, Hits: 1, Evictions: 1}, testSubject.Stats())
+}
+
+func TestEntityCacheLFU(t *testing.T) {
+	//given
+	testSubject := newEntityCache(2, LFU, time.Hour, time.Hour, time.Second)
+	testSubject.SetDefault("a", 1)
+	testSubject.SetDefault("b", 2)
+	_, _ = testSubject.load("a", loaded(0), nil)
+	_, _ = testSubject.load("a", loaded(0), nil)
+	_, _ = testSubject.load("b", loaded(0), nil)
+
+	//when
+	testSubject.SetDefault("c", 3)
+	testSubject.SetDefault("d", 4)
+
+	//then
+	assert.Equal(t, map[string]interface{}{"a": 1, "d": 4}, testSubject.Items())
+	assert.Equal(t, uint64(2), testSubject.Stats().Evictions)
+}
+
+func TestEntityCacheExpiration(t *testing.T) {
+	//given
+	testSubject := newEntityCache(2, LRU, time.Millisecond, time.Hour, time.Second)
+	testSubject.SetDefault("a", 1)
+	time.Sleep(2 * time.Millisecond)
+
+	//when
+	value, err := testSubject.load("a", loaded(2), nil)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, CacheStats{Size: 1, Misses: 1, Loads: 1}, withoutLoadTime(testSubject.Stats()))
+}
+
+func TestEntityCacheNegative(t *testing.T) {
+	//given
+	testSubject := newEntityCache(10, LRU, time.Hour, time.Hour, time.Hour)
+	calls := 0
+	missing := func() (interface{}, error) {
+		calls++
+		return nil, bucket.ErrBucketDoesNotExist
+	}
+	failing := func() (interface{}, error) {
+		calls++
+		return nil, errors.New("connection closed")
+	}
+	_, _ = testSubject.load("missing", missing, nil, bucket.ErrBucketDoesNotExist)
+	_, _ = testSubject.load("failing", failing, nil, bucket.ErrBucketDoesNotExist)
+
+	//when
+	_, errMissing := testSubject.load("missing", missing, nil, bucket.ErrBucketDoesNotExist)
+	_, errFailing := testSubject.load("failing", failing, nil, bucket.ErrBucketDoesNotExist)
+
+	//then
+	assert.ErrorIs(t, errMissing, bucket.ErrBucketDoesNotExist)
+	assert.EqualError(t, errFailing, "connection closed")
+	assert.Equal(t, 3, calls)
+	assert.Empty(t, testSubject.Items())
+	assert.Equal(t, CacheStats{Size: 1, NegativeHits: 1, Misses: 3, Loads: 3, LoadErrors: 3}, withoutLoadTime(testSubject.Stats()))
+}
+
+func TestEntityCacheNegativeExpiration(t *testing.T) {
+	//given
+	testSubject := newEntityCache(10, LRU, time.Hour, time.Hour, time.Millisecond)
+	_, _ = testSubject.load("a", func() (interface{}, error) {
+		return nil, bucket.ErrNodeDoesNotExist
+	}, nil, bucket.ErrNodeDoesNotExist)
+	time.Sleep(2 * time.Millisecond)
+
+	//when
+	value, err := testSubject.load("a", loaded(1), nil, bucket.ErrNodeDoesNotExist)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestEntityCacheStaleWhileRevalidate(t *testing.T) {
+	//given
+	testSubject := newEntityCache(10, LRU, time.Millisecond, time.Hour, time.Second)
+	testSubject.maxStaleness = time.Hour
+	testSubject.SetDefault("a", 1)
+	time.Sleep(2 * time.Millisecond)
+	loader := func() (interface{}, error) {
+		t.Error("unexpected load")
+		return nil, nil
+	}
+
+	//when
+	value, err := testSubject.load("a", loader, loaded(2))
+	again, _ := testSubject.load("a", loader, loaded(3))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 1, again)
+	assert.Eventually(t, func() bool {
+		refreshed, _ := testSubject.Get("a")
+		return refreshed == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, CacheStats{Size: 1, StaleHits: 2, Refreshes: 1, Loads: 1}, withoutLoadTime(testSubject.Stats()))
+}
+
+func TestEntityCacheMaxStaleness(t *testing.T) {
+	//given
+	testSubject := newEntityCache(10, LRU, time.Millisecond, time.Hour, time.Second)
+	testSubject.maxStaleness = time.Millisecond
+	testSubject.SetDefault("a", 1)
+	time.Sleep(3 * time.Millisecond)
+
+	//when
+	value, err := testSubject.load("a", loaded(2), loaded(3))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, uint64(0), testSubject.Stats().Refreshes)
+}
+
+func TestEntityCacheRefreshAhead(t *testing.T) {
+	//given
+	testSubject := newEntityCache(10, LRU, time.Hour, time.Hour, time.Second)
+	testSubject.refreshAhead = 2 * time.Hour
+	testSubject.SetDefault("a", 1)
+
+	//when
+	value, err := testSubject.load("a", loaded(2), loaded(3))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Eventually(t, func() bool {
+		refreshed, _ := testSubject.Get("a")
+		return refreshed == 3
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(1), testSubject.Stats().Hits)
+}
+
+func TestEntityCacheRefreshOfRemovedEntry(t *testing.T) {
+	//given
+	testSubject := newEntityCache(10, LRU, time.Millisecond, time.Hour, time.Second)
+	testSubject.maxStaleness = time.Hour
+	testSubject.SetDefault("a", 1)
+	time.Sleep(2 * time.Millisecond)
+	release := make(chan struct{})
+	_, _ = testSubject.load("a", loaded(2), func() (interface{}, error) {
+		<-release
+		return 3, nil
+	})
+
+	//when
+	testSubject.Delete("a")
+	close(release)
+
+	//then
+	assert.Eventually(t, func() bool {
+		return testSubject.Stats().Loads == 1
+	}, time.Second, time.Millisecond)
+	_, ok := testSubject.Get("a")
+	assert.False(t, ok)
+}
+
+func TestEntityCacheRefreshOfEntrySetMeanwhile(t *testing.T) {
+	//given
+	testSubject := newEntityCache(10, LRU, time.Millisecond, time.Hour, time.Second)
+	testSubject.maxStaleness = time.Hour
+	testSubject.SetDefault("a", 1)
+	time.Sleep(2 * time.Millisecond)
+	release := make(chan struct{})
+	_, _ = testSubject.load("a", loaded(2), func() (interface{}, error) {
+		<-release
+		return 3, nil
+	})
+
+	//when
+	testSubject.SetDefault("a", 4)
+	close(release)
+
+	//then
+	assert.Eventually(t, func() bool {
+		return testSubject.Stats().Loads == 1
+	}, time.Second, time.Millisecond)
+	value, _ := testSubject.Get("a")
+	assert.Equal(t, 4, value)
+}
+
+func withoutLoadTime(stats CacheStats) CacheStats {
+	stats.LoadTime = 0
+	return stats
+}
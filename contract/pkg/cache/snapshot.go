@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	"github.com/pkg/errors"
+)
+
+const snapshotVersion = 1
+
+type (
+	// snapshot is the SCALE encoded content of a snapshot file.
+	snapshot struct {
+		Version  types.U8
+		Contract types.Text
+		// Block is the last block whose contract events were applied to the entries.
+		Block    types.U32
+		Buckets  []bucket.BucketInfo
+		Nodes    []bucket.NodeInfo
+		Accounts []snapshotAccount
+		Clusters []bucket.ClusterInfo
+	}
+
+	snapshotAccount struct {
+		AccountId bucket.AccountId
+		Account   bucket.Account
+	}
+)
+
+// SaveSnapshot writes the cached buckets, nodes, accounts and clusters to the file with the last block whose contract
+// events the client dispatched to the cache, so that the cache of a contract without recent events is warm started
+// too. The file is replaced atomically.
+func (d *ddcBucketContractCached) SaveSnapshot(client pkg.BlockchainClient, path string) error {
+	// the block is taken before the entries, its events may still be handled and are replayed on warm start
+	block, err := client.DispatchedEventBlock(d.GetContractAddress())
+	if err != nil {
+		return err
+	}
+	if last := d.LastEventBlock(); last > block {
+		block = last
+	}
+
+	content := snapshot{
+		Version:  snapshotVersion,
+		Contract: types.Text(d.GetContractAddress()),
+		Block:    types.U32(block),
+	}
+	for _, item := range d.bucketCache.Items() {
+		if value, ok := item.(*bucket.BucketInfo); ok {
+			content.Buckets = append(content.Buckets, *value)
+		}
+	}
+	for _, item := range d.nodeCache.Items() {
+		if value, ok := item.(*bucket.NodeInfo); ok {
+			content.Nodes = append(content.Nodes, *value)
+		}
+	}
+	for key, item := range d.accountCache.Items() {
+		value, ok := item.(*bucket.Account)
+		if !ok {
+			continue
+		}
+		accountId, err := hex.DecodeString(key)
+		if err != nil {
+			continue
+		}
+		entry := snapshotAccount{Account: *value}
+		copy(entry.AccountId[:], accountId)
+		content.Accounts = append(content.Accounts, entry)
+	}
+	for _, item := range d.clusterCache.Items() {
+		if value, ok := item.(*bucket.ClusterInfo); ok {
+			content.Clusters = append(content.Clusters, *value)
+		}
+	}
+
+	encoded, err := codec.Encode(content)
+	if err != nil {
+		return errors.Wrap(err, "Unable to encode the cache snapshot")
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(encoded); err != nil {
+		_ = file.Close()
+		return err
+	}
+	// the content must be on disk before the rename is, or a crash could leave a truncated snapshot
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir persists the entries of the directory, e.g. a file renamed into it.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+// LoadSnapshot fills the cache from the snapshot file and returns the block to replay the contract events from to
+// reconcile the entries. A missing file, or a snapshot taken before the client dispatched any block to the cache,
+// leaves the cache empty and returns the block 0.
+func (d *ddcBucketContractCached) LoadSnapshot(path string) (types.BlockNumber, error) {
+	encoded, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	content := snapshot{}
+	if err := codec.Decode(encoded, &content); err != nil {
+		return 0, errors.Wrap(err, "Unable to decode the cache snapshot "+path)
+	}
+	if content.Version != snapshotVersion {
+		return 0, errors.Errorf("Unsupported cache snapshot version %d", content.Version)
+	}
+	if string(content.Contract) != d.GetContractAddress() {
+		return 0, errors.Errorf("Cache snapshot of contract %s, expected %s", content.Contract, d.GetContractAddress())
+	}
+	if content.Block == 0 {
+		// nothing tells which events the entries miss
+		return 0, nil
+	}
+
+	for i := range content.Buckets {
+		d.bucketCache.SetDefault(toString(content.Buckets[i].BucketId), &content.Buckets[i])
+	}
+	for i := range content.Nodes {
+		d.nodeCache.SetDefault(content.Nodes[i].Key.ToHexString(), &content.Nodes[i])
+	}
+	for i := range content.Accounts {
+		d.accountCache.SetDefault(hex.EncodeToString(content.Accounts[i].AccountId[:]), &content.Accounts[i].Account)
+	}
+	for i := range content.Clusters {
+		d.clusterCache.SetDefault(toString(content.Clusters[i].ClusterId), &content.Clusters[i])
+	}
+	d.setEventBlock(types.BlockNumber(content.Block))
+
+	return types.BlockNumber(content.Block), nil
+}
+
+// WarmStart loads the snapshot file and registers the event dispatcher of the contract with the client, replaying the
+// events since the block of the snapshot to drop the entries changed meanwhile. HookContractEvents must be called
+// before. The replay runs in the background of the client event listener, until it is done the entries changed since
+// the snapshot may still be served.
+func (d *ddcBucketContractCached) WarmStart(client pkg.BlockchainClient, path string) error {
+	block, err := d.LoadSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	return client.SetEventDispatcherFrom(d.GetContractAddress(), d.GetEventDispatcher(), block)
+}
+
+// LastEventBlock is the block of the last contract event handled by the cache.
+func (d *ddcBucketContractCached) LastEventBlock() types.BlockNumber {
+	return types.BlockNumber(atomic.LoadUint32(&d.eventBlock))
+}
+
+func (d *ddcBucketContractCached) setEventBlock(block types.BlockNumber) {
+	for {
+		last := atomic.LoadUint32(&d.eventBlock)
+		if uint32(block) <= last || atomic.CompareAndSwapUint32(&d.eventBlock, last, uint32(block)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	"github.com/stretchr/testify/assert"
+)
+
+const snapshotContract = "5DTZfAcmZctJodfa4W88BW5QXVBxT4v7UEax91HZCArTih6U"
+
+type dispatcherClient struct {
+	pkg.BlockchainClient
+	contract        string
+	fromBlock       types.BlockNumber
+	dispatchedBlock types.BlockNumber
+}
+
+func (c *dispatcherClient) DispatchedEventBlock(string) (types.BlockNumber, error) {
+	return c.dispatchedBlock, nil
+}
+
+func (c *dispatcherClient) SetEventDispatcherFrom(contractAddressSS58 string, _ map[types.Hash]pkg.ContractEventDispatchEntry, fromBlock types.BlockNumber) error {
+	c.contract = contractAddressSS58
+	c.fromBlock = fromBlock
+	return nil
+}
+
+func balance(value int64) bucket.Balance {
+	return types.NewU128(*big.NewInt(value))
+}
+
+func createSnapshotContract(contract string) *mockedDdcBucketContract {
+	ddcBucketContract := &mockedDdcBucketContract{}
+	ddcBucketContract.On("GetContractAddress").Return(contract)
+	return ddcBucketContract
+}
+
+func TestSnapshot(t *testing.T) {
+	//given
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	accountId := types.AccountID{7}
+	bucketInfo := &bucket.BucketInfo{BucketId: 1, Bucket: bucket.Bucket{OwnerId: accountId, ClusterId: 2}, Params: "{}", WriterIds: []bucket.AccountId{accountId}}
+	nodeInfo := &bucket.NodeInfo{Key: types.AccountID{3}, Node: bucket.Node{RentPerMonth: balance(10), ClusterId: types.NewOptionU32(2), StatusInCluster: types.NewOptionU8(1)}, VNodes: []bucket.Token{1, 2}}
+	account := &bucket.Account{Deposit: balance(1), Bonded: balance(2), Negative: balance(3), UnboundedAmount: balance(4), PayableSchedule: bucket.Schedule{Rate: balance(5), Offset: balance(6)}}
+	clusterInfo := &bucket.ClusterInfo{ClusterId: 2, Cluster: bucket.Cluster{NodesKeys: []bucket.NodeKey{nodeInfo.Key}, Revenues: balance(1), TotalRent: balance(10), CdnRevenues: balance(1), CdnUsdPerGb: balance(1)}}
+
+	saved := createSnapshotContract(snapshotContract)
+	saved.On("BucketGet", bucketInfo.BucketId).Return(bucketInfo, nil).Once()
+	saved.On("NodeGet", nodeInfo.Key).Return(nodeInfo, nil).Once()
+	saved.On("AccountGet", accountId).Return(account, nil).Once()
+	saved.On("ClusterGet", clusterInfo.ClusterId).Return(clusterInfo, nil).Once()
+	savedCache := CreateDdcBucketContractCache(saved, BucketCacheParameters{})
+	assert.NoError(t, savedCache.HookContractEvents())
+	_, _ = savedCache.BucketGet(bucketInfo.BucketId)
+	_, _ = savedCache.NodeGet(nodeInfo.Key)
+	_, _ = savedCache.AccountGet(accountId)
+	_, _ = savedCache.ClusterGet(clusterInfo.ClusterId)
+	saved.emitAt(42, bucket.BucketParamsSetEventId, &bucket.BucketParamsSetEvent{BucketId: 9})
+
+	loaded := createSnapshotContract(snapshotContract)
+	loadedCache := CreateDdcBucketContractCache(loaded, BucketCacheParameters{})
+
+	//when
+	errSave := savedCache.SaveSnapshot(&dispatcherClient{dispatchedBlock: 40}, path)
+	block, errLoad := loadedCache.LoadSnapshot(path)
+
+	//then
+	assert.NoError(t, errSave)
+	assert.NoError(t, errLoad)
+	assert.Equal(t, types.BlockNumber(42), block)
+	assert.Equal(t, types.BlockNumber(42), loadedCache.LastEventBlock())
+	loadedBucket, _ := loadedCache.BucketGet(bucketInfo.BucketId)
+	assert.Equal(t, bucketInfo, loadedBucket)
+	loadedNode, _ := loadedCache.NodeGet(nodeInfo.Key)
+	assert.Equal(t, nodeInfo, loadedNode)
+	loadedAccount, _ := loadedCache.AccountGet(accountId)
+	assert.Equal(t, account, loadedAccount)
+	loadedCluster, _ := loadedCache.ClusterGet(clusterInfo.ClusterId)
+	assert.Equal(t, clusterInfo, loadedCluster)
+	saved.AssertExpectations(t)
+	loaded.AssertNotCalled(t, "BucketGet", bucketInfo.BucketId)
+}
+
+func TestSnapshotMissing(t *testing.T) {
+	//given
+	testSubject := CreateDdcBucketContractCache(createSnapshotContract(snapshotContract), BucketCacheParameters{})
+
+	//when
+	block, err := testSubject.LoadSnapshot(filepath.Join(t.TempDir(), "cache.snapshot"))
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, types.BlockNumber(0), block)
+}
+
+func TestSnapshotOfOtherContract(t *testing.T) {
+	//given
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	saved := CreateDdcBucketContractCache(createSnapshotContract("other"), BucketCacheParameters{})
+	assert.NoError(t, saved.SaveSnapshot(&dispatcherClient{}, path))
+	testSubject := CreateDdcBucketContractCache(createSnapshotContract(snapshotContract), BucketCacheParameters{})
+
+	//when
+	_, err := testSubject.LoadSnapshot(path)
+
+	//then
+	assert.EqualError(t, err, "Cache snapshot of contract other, expected "+snapshotContract)
+}
+
+func TestWarmStart(t *testing.T) {
+	//given
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	saved := createSnapshotContract(snapshotContract)
+	savedCache := CreateDdcBucketContractCache(saved, BucketCacheParameters{})
+	assert.NoError(t, savedCache.HookContractEvents())
+	saved.emitAt(42, bucket.DepositEventId, &bucket.DepositEvent{AccountId: types.AccountID{1}})
+	assert.NoError(t, savedCache.SaveSnapshot(&dispatcherClient{}, path))
+	client := &dispatcherClient{}
+	testSubject := CreateDdcBucketContractCache(createSnapshotContract(snapshotContract), BucketCacheParameters{})
+
+	//when
+	err := testSubject.WarmStart(client, path)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, snapshotContract, client.contract)
+	assert.Equal(t, types.BlockNumber(42), client.fromBlock)
+}
+
+func TestSnapshotWithoutEvents(t *testing.T) {
+	//given
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	accountId := types.AccountID{7}
+	account := &bucket.Account{Deposit: balance(1), Bonded: balance(0), Negative: balance(0), UnboundedAmount: balance(0), PayableSchedule: bucket.Schedule{Rate: balance(0), Offset: balance(0)}}
+	saved := createSnapshotContract(snapshotContract)
+	saved.On("AccountGet", accountId).Return(account, nil).Once()
+	savedCache := CreateDdcBucketContractCache(saved, BucketCacheParameters{})
+	_, _ = savedCache.AccountGet(accountId)
+	loadedCache := CreateDdcBucketContractCache(createSnapshotContract(snapshotContract), BucketCacheParameters{})
+
+	//when
+	errSave := savedCache.SaveSnapshot(&dispatcherClient{dispatchedBlock: 100}, path)
+	block, errLoad := loadedCache.LoadSnapshot(path)
+
+	//then
+	assert.NoError(t, errSave)
+	assert.NoError(t, errLoad)
+	assert.Equal(t, types.BlockNumber(100), block)
+	loadedAccount, _ := loadedCache.AccountGet(accountId)
+	assert.Equal(t, account, loadedAccount)
+}
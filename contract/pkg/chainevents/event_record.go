@@ -442,7 +442,19 @@ type EventRecords struct {
 // If this method returns an error like `unable to decode Phase for event #x: EOF`, it is likely that you have defined
 // a custom event record with a wrong type. For example your custom event record has a field with a length prefixed
 // type, such as types.Bytes, where your event in reallity contains a fixed width type, such as a types.U32.
-func (e EventRecordsRaw) DecodeEventRecords(m *types.Metadata, t interface{}) error { //nolint:funlen
+func (e EventRecordsRaw) DecodeEventRecords(m *types.Metadata, t interface{}) error {
+	return e.decodeEventRecords(m, t, nil)
+}
+
+// DecodeEventRecordsIndexed decodes the events records like DecodeEventRecords and also returns the positions of the
+// events in the block keyed by the target field name, in the order of the field slice.
+func (e EventRecordsRaw) DecodeEventRecordsIndexed(m *types.Metadata, t interface{}) (map[string][]uint32, error) {
+	indexes := make(map[string][]uint32)
+	err := e.decodeEventRecords(m, t, indexes)
+	return indexes, err
+}
+
+func (e EventRecordsRaw) decodeEventRecords(m *types.Metadata, t interface{}, indexes map[string][]uint32) error { //nolint:funlen
 	log.Debug(fmt.Sprintf("will decode event records from raw hex: %#x", e))
 
 	// ensure t is a pointer
@@ -506,7 +518,8 @@ func (e EventRecordsRaw) DecodeEventRecords(m *types.Metadata, t interface{}) er
 		log.Debug(fmt.Sprintf("event #%v is in module %v with event name %v", i, moduleName, eventName))
 
 		// check whether name for eventID exists in t
-		field := val.FieldByName(fmt.Sprintf("%v_%v", moduleName, eventName))
+		fieldName := fmt.Sprintf("%v_%v", moduleName, eventName)
+		field := val.FieldByName(fieldName)
 		if !field.IsValid() {
 			return fmt.Errorf("unable to find field %v_%v for event #%v with EventID %v", moduleName, eventName, i, id)
 		}
@@ -545,6 +558,9 @@ func (e EventRecordsRaw) DecodeEventRecords(m *types.Metadata, t interface{}) er
 
 		// add the decoded event to the slice
 		field.Set(reflect.Append(field, holder.Elem()))
+		if indexes != nil {
+			indexes[fieldName] = append(indexes[fieldName], uint32(i))
+		}
 
 		log.Debug(fmt.Sprintf("decoded event #%v", i))
 	}
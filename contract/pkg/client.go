@@ -3,40 +3,99 @@ package pkg
 import (
 	"bytes"
 	"context"
-	"os/signal"
+	"encoding/json"
+	"fmt"
+	"math/big"
 	"reflect"
 	"sync"
-	"syscall"
 	"time"
 
 	gsrpc "github.com/centrifuge/go-substrate-rpc-client/v4"
+	gethrpc "github.com/centrifuge/go-substrate-rpc-client/v4/gethrpc"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/rpc"
 	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
 	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/chainevents"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
 )
 
 const (
 	CERE = 10_000_000_000
 	MGAS = 1_000_000
+
+	DEFAULT_GAS_MARGIN_PERCENT        = 20
+	DEFAULT_READ_GAS_LIMIT     uint64 = 500_000 * MGAS
+
+	revertFlag = 1
+	// eventReorgDepth is how many blocks behind the next one the dispatched blocks are remembered.
+	eventReorgDepth = 256
 )
 
 type (
 	BlockchainClient interface {
 		CallToReadEncoded(contractAddressSS58 string, fromAddress string, method []byte, args ...interface{}) (string, error)
+		CallToRead(ctx context.Context, readCall ReadCall) (string, error)
 		CallToExec(ctx context.Context, contractCall ContractCall) (types.Hash, error)
+		// CallToExecWithResult fails with ErrExtrinsicFailed if the call is included but fails on chain, the result of
+		// the included extrinsic is returned along with the error.
+		CallToExecWithResult(ctx context.Context, contractCall ContractCall) (ExtrinsicResult, error)
+		// Deploy uploads the code and instantiates the contract in one extrinsic, its Value and GasLimit are in CERE.
 		Deploy(ctx context.Context, deployCall DeployCall) (types.AccountID, error)
+		UploadCode(ctx context.Context, uploadCall UploadCodeCall) (UploadCodeResult, error)
+		InstantiateFromHash(ctx context.Context, instantiateCall InstantiateCall) (DeployResult, error)
+		SetCode(ctx context.Context, setCodeCall SetCodeCall) (ExtrinsicResult, error)
+		// CallBatch dispatches contract and pallet calls in one Utility.batch_all extrinsic.
+		CallBatch(ctx context.Context, batchCall BatchCall) (BatchResult, error)
 		SetEventDispatcher(contractAddressSS58 string, dispatcher map[types.Hash]ContractEventDispatchEntry) error
+		SetEventDispatcherFrom(contractAddressSS58 string, dispatcher map[types.Hash]ContractEventDispatchEntry, fromBlock types.BlockNumber) error
+		RemoveEventDispatcher(contractAddressSS58 string) error
+		// DispatchedEventBlock is the last block whose events were dispatched to the contract, the events of that block
+		// may still be handled. It is 0 until the listener dispatches a block to the contract.
+		DispatchedEventBlock(contractAddressSS58 string) (types.BlockNumber, error)
+		Close() error
 	}
 
 	blockchainClient struct {
 		*gsrpc.SubstrateAPI
-		eventContractAccount types.AccountID
-		eventDispatcher      map[types.Hash]ContractEventDispatchEntry
-		eventContextCancel   context.CancelFunc
-		connectMutex         sync.Mutex
+		// ctx is the lifetime of the client, done once it is closed.
+		ctx            context.Context
+		cancel         context.CancelFunc
+		eventMutex     sync.Mutex
+		eventContracts map[types.AccountID]*contractEvents
+		// eventNextBlock is the number of the next block to dispatch events of, 0 until the first one is known.
+		eventNextBlock types.BlockNumber
+		// eventDispatched are the recent blocks dispatched by hash, the subscription follows the best blocks so a block
+		// of a number already dispatched is another fork after a reorg.
+		eventDispatched map[types.Hash]types.BlockNumber
+		// eventReplay wakes the listener up to replay the events of the contracts registered since.
+		eventReplay        chan struct{}
+		eventContextCancel context.CancelFunc
+		// eventListenerDone is closed once the last listener started stopped.
+		eventListenerDone chan struct{}
+		// eventRestarting is set while a reconnect waits for the listener of the previous connection to stop before
+		// starting the listener of the new one.
+		eventRestarting   bool
+		eventListeners    sync.WaitGroup
+		eventErrorHandler ContractEventErrorHandler
+		connectMutex      sync.Mutex
+		gasMarginPercent  uint64
+		readGasLimit      Weight
+		mortality         uint64
+		waitPolicy        WaitPolicy
+		submitTimeout     time.Duration
+		weightV2          *bool
+		nonces            *nonceManager
+	}
+
+	ClientOption func(*blockchainClient)
+
+	// rpcClient is the gsrpc client of a connection dialed with a context.
+	rpcClient struct {
+		*gethrpc.Client
+		url string
 	}
 
 	ContractCall struct {
@@ -44,19 +103,41 @@ type (
 		ContractAddressSS58 string
 		From                signature.KeyringPair
 		Value               uint64
-		GasLimit            uint64
+		// GasLimit and ProofSizeLimit (Weight v2 only) are estimated with a dry run when set to 0.
+		GasLimit       uint64
+		ProofSizeLimit uint64
+		// StorageDepositLimit is estimated with a dry run when set to 0.
+		StorageDepositLimit uint64
+		Method              []byte
+		Args                []interface{}
+		// ErrorDecoder converts the output of a reverted dry run into the contract error.
+		ErrorDecoder func(data []byte) error
+	}
+
+	// ReadCall is a read only contract call.
+	ReadCall struct {
+		ContractAddressSS58 string
+		From                string
 		Method              []byte
 		Args                []interface{}
+		// At is the block to read the contract state at, the best block is read when unset.
+		At *types.Hash
 	}
 
+	// ReadOption adjusts a read only contract call.
+	ReadOption func(*ReadCall)
+
 	DeployCall struct {
-		Code     []byte
-		Salt     []byte
-		From     signature.KeyringPair
-		Value    uint64
-		GasLimit uint64
-		Method   []byte
-		Args     []interface{}
+		Code           []byte
+		Salt           []byte
+		From           signature.KeyringPair
+		Value          uint64
+		GasLimit       uint64
+		ProofSizeLimit uint64
+		// StorageDepositLimit is not limited when set to 0.
+		StorageDepositLimit uint64
+		Method              []byte
+		Args                []interface{}
 	}
 
 	ContractEventDispatchEntry struct {
@@ -64,75 +145,336 @@ type (
 		Handler      ContractEventHandler
 	}
 
-	ContractEventHandler func(interface{})
+	ContractEventHandler func(event ContractEventContext, args interface{}) error
+
+	// contractEvents is the dispatcher of a contract, the live events are dispatched since the block sinceBlock as
+	// the former ones are replayed by the listener from the block replayFrom, 0 once done.
+	contractEvents struct {
+		dispatcher map[types.Hash]ContractEventDispatchEntry
+		sinceBlock types.BlockNumber
+		replayFrom types.BlockNumber
+	}
 
 	Response struct {
-		DebugMessage string `json:"debugMessage"`
-		GasConsumed  int    `json:"gasConsumed"`
-		Result       struct {
+		DebugMessage   string         `json:"debugMessage"`
+		GasConsumed    Weight         `json:"gasConsumed"`
+		GasRequired    Weight         `json:"gasRequired"`
+		StorageDeposit StorageDeposit `json:"storageDeposit"`
+		Result         struct {
 			Ok struct {
 				Data  string `json:"data"`
 				Flags int    `json:"flags"`
 			} `json:"Ok"`
+			Err json.RawMessage `json:"Err"`
 		} `json:"result"`
 	}
 
+	StorageDeposit struct {
+		Charge *NumberOrHex `json:"charge"`
+		Refund *NumberOrHex `json:"refund"`
+	}
+
+	// NumberOrHex is a balance returned by the node either as a JSON number or as a hex string.
+	NumberOrHex struct {
+		big.Int
+	}
+
 	Request struct {
-		Origin    string `json:"origin"`
-		Dest      string `json:"dest"`
-		GasLimit  uint   `json:"gasLimit"`
-		InputData string `json:"inputData"`
-		Value     int    `json:"value"`
+		Origin    string      `json:"origin"`
+		Dest      string      `json:"dest"`
+		GasLimit  interface{} `json:"gasLimit"`
+		InputData string      `json:"inputData"`
+		Value     int         `json:"value"`
 	}
 )
 
-func CreateBlockchainClient(apiUrl string) BlockchainClient {
-	substrateAPI, err := gsrpc.NewSubstrateAPI(apiUrl)
+var (
+	ErrContractReverted = errors.New("contract call reverted")
+	ErrDryRunFailed     = errors.New("contract call dry run failed")
+	ErrClientClosed     = errors.New("blockchain client closed")
+)
+
+// CreateBlockchainClient connects like ConnectBlockchainClient but exits the process if the node is unreachable.
+//
+// Deprecated: use ConnectBlockchainClient.
+func CreateBlockchainClient(apiUrl string, options ...ClientOption) BlockchainClient {
+	client, err := ConnectBlockchainClient(context.Background(), apiUrl, options...)
 	if err != nil {
 		log.WithError(err).WithField("apiUrl", apiUrl).Fatal("Can't connect to blockchainClient")
 	}
 
-	return &blockchainClient{
-		SubstrateAPI: substrateAPI,
+	return client
+}
+
+// ConnectBlockchainClient connects to the node, ctx limits the time to establish the connection. The client keeps
+// the connection and the event subscription until Close.
+func ConnectBlockchainClient(ctx context.Context, apiUrl string, options ...ClientOption) (BlockchainClient, error) {
+	substrateAPI, err := connect(ctx, apiUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connect to %s", apiUrl)
+	}
+
+	lifetime, cancel := context.WithCancel(context.Background())
+	client := &blockchainClient{
+		SubstrateAPI:      substrateAPI,
+		ctx:               lifetime,
+		cancel:            cancel,
+		eventContracts:    make(map[types.AccountID]*contractEvents),
+		eventDispatched:   make(map[types.Hash]types.BlockNumber),
+		eventReplay:       make(chan struct{}, 1),
+		eventErrorHandler: logContractEventError,
+		gasMarginPercent:  DEFAULT_GAS_MARGIN_PERCENT,
+		readGasLimit:      Weight{RefTime: DEFAULT_READ_GAS_LIMIT, ProofSize: DEFAULT_PROOF_SIZE_LIMIT},
+		mortality:         DEFAULT_MORTALITY,
+		waitPolicy:        WaitInBlock,
+		submitTimeout:     DEFAULT_SUBMIT_TIMEOUT,
+	}
+	client.nonces = newNonceManager(client.accountNextIndex)
+	for _, option := range options {
+		option(client)
+	}
+
+	return client, nil
+}
+
+// connect dials the node with the context, unlike gsrpc.NewSubstrateAPI.
+func connect(ctx context.Context, apiUrl string) (*gsrpc.SubstrateAPI, error) {
+	c, err := gethrpc.DialContext(ctx, apiUrl)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	cl := &rpcClient{Client: c, url: apiUrl}
+	newRPC, err := rpc.NewRPC(cl)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return &gsrpc.SubstrateAPI{RPC: newRPC, Client: cl}, nil
+}
+
+func (c *rpcClient) URL() string {
+	return c.url
+}
+
+// Close stops the event subscription and its watchdog, waiting for the events being dispatched, and closes the
+// connection. The client can't be used afterwards.
+func (b *blockchainClient) Close() error {
+	b.connectMutex.Lock()
+	if b.ctx.Err() != nil {
+		b.connectMutex.Unlock()
+		return nil
+	}
+	// reconnects fail from now on, so the connection is not replaced
+	b.cancel()
+	b.connectMutex.Unlock()
+
+	b.eventMutex.Lock()
+	b.eventContextCancel = nil
+	b.eventMutex.Unlock()
+	b.eventListeners.Wait()
+
+	b.closeConnection()
+	return nil
+}
+
+func (b *blockchainClient) closeConnection() {
+	if cl, ok := b.Client.(*rpcClient); ok {
+		cl.Close()
+	}
+}
+
+// WithGasMargin sets the safety margin in percent added to the gas and the storage deposit estimated by a dry run.
+func WithGasMargin(percent uint64) ClientOption {
+	return func(b *blockchainClient) {
+		b.gasMarginPercent = percent
+	}
+}
+
+// WithReadGasLimit sets the gas limit of the contract reads and of the dry runs estimating the gas of calls.
+func WithReadGasLimit(limit Weight) ClientOption {
+	return func(b *blockchainClient) {
+		b.readGasLimit = limit
+	}
+}
+
+// ReadAt reads the contract state at the block.
+func ReadAt(blockHash types.Hash) ReadOption {
+	return func(readCall *ReadCall) {
+		readCall.At = &blockHash
 	}
 }
 
 func (b *blockchainClient) SetEventDispatcher(contractAddressSS58 string, dispatcher map[types.Hash]ContractEventDispatchEntry) error {
+	return b.SetEventDispatcherFrom(contractAddressSS58, dispatcher, 0)
+}
+
+// SetEventDispatcherFrom registers the event dispatcher of the contract, replacing the previous one of the same
+// contract. The events emitted since the block fromBlock are replayed before the live ones, the block 0 skips the
+// replay. All the contracts share a single subscription to System.Events, its listener runs the replay and the handlers.
+func (b *blockchainClient) SetEventDispatcherFrom(contractAddressSS58 string, dispatcher map[types.Hash]ContractEventDispatchEntry, fromBlock types.BlockNumber) error {
 	contract, err := DecodeAccountIDFromSS58(contractAddressSS58)
 	if err != nil {
 		return err
 	}
-	b.eventContractAccount = contract
-	b.eventDispatcher = dispatcher
-	err = b.listenContractEvents()
+
+	var head *types.Header
+	if fromBlock > 0 {
+		// the block the live events start from if the listener has not dispatched any yet
+		if head, err = b.RPC.Chain.GetHeaderLatest(); err != nil {
+			return err
+		}
+	}
+
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+
+	if len(b.eventContracts) == 0 {
+		// the first contract, the listener replays its events before going live
+		b.eventContracts[contract] = &contractEvents{dispatcher: dispatcher}
+		b.eventNextBlock = fromBlock
+		if b.eventRestarting {
+			return nil
+		}
+		err = b.listenContractEvents()
+		if err != nil {
+			delete(b.eventContracts, contract)
+			return err
+		}
+		return nil
+	}
+
+	events := &contractEvents{dispatcher: dispatcher}
+	if fromBlock > 0 {
+		// The listener replays up to the block it continues from before dispatching the live events to the contract.
+		events.replayFrom = fromBlock
+		events.sinceBlock = b.eventNextBlock
+		if events.sinceBlock == 0 {
+			events.sinceBlock = head.Number + 1
+		}
+		if events.replayFrom >= events.sinceBlock {
+			events.replayFrom = 0
+		}
+	}
+	b.eventContracts[contract] = events
+	if b.eventContextCancel == nil && !b.eventRestarting {
+		// the listener failed to restart on reconnect
+		return b.listenContractEvents()
+	}
+	if events.replayFrom > 0 {
+		select {
+		case b.eventReplay <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// RemoveEventDispatcher stops dispatching the events of the contract, the subscription is closed with the last one.
+func (b *blockchainClient) RemoveEventDispatcher(contractAddressSS58 string) error {
+	contract, err := DecodeAccountIDFromSS58(contractAddressSS58)
 	if err != nil {
 		return err
 	}
+
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+
+	delete(b.eventContracts, contract)
+	if len(b.eventContracts) == 0 && b.eventContextCancel != nil {
+		b.eventContextCancel()
+		b.eventContextCancel = nil
+	}
+
 	return nil
 }
 
-func (b *blockchainClient) listenContractEvents() error {
+func (b *blockchainClient) DispatchedEventBlock(contractAddressSS58 string) (types.BlockNumber, error) {
+	contract, err := DecodeAccountIDFromSS58(contractAddressSS58)
+	if err != nil {
+		return 0, err
+	}
+
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+
+	events, ok := b.eventContracts[contract]
+	switch {
+	case !ok || b.eventNextBlock == 0:
+		return 0, nil
+	case events.replayFrom > 0:
+		// the blocks from replayFrom are not replayed yet
+		return events.replayFrom - 1, nil
+	case events.sinceBlock >= b.eventNextBlock:
+		return 0, nil
+	}
+
+	return b.eventNextBlock - 1, nil
+}
+
+func (b *blockchainClient) eventsStorageKey() (*types.Metadata, types.StorageKey, error) {
 	meta, err := b.RPC.State.GetMetadataLatest()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	key, err := types.CreateStorageKey(meta, "System", "Events", nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return meta, key, nil
+}
+
+// listenContractEvents subscribes to System.Events, it must be called holding the event mutex.
+func (b *blockchainClient) listenContractEvents() error {
+	if b.ctx.Err() != nil {
+		return ErrClientClosed
+	}
+
+	meta, key, err := b.eventsStorageKey()
 	if err != nil {
 		return err
 	}
 
+	// Subscribe before the replay so no block is missed in between, the blocks replayed are not dispatched again.
 	sub, err := b.RPC.State.SubscribeStorageRaw([]types.StorageKey{key})
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	ctx, cancel := context.WithCancel(b.ctx)
 	b.eventContextCancel = cancel
+	done := make(chan struct{})
+	b.eventListenerDone = done
+	b.eventListeners.Add(1)
 	watchdog := time.NewTicker(time.Minute)
 	eventArrived := true
+	dispatch := func(number types.BlockNumber, blockHash types.Hash, changes []types.KeyValueOption) {
+		if dispatchers, ok := b.blockDispatchers(number, blockHash); ok {
+			b.dispatchContractEvents(meta, key, number, blockHash, changes, dispatchers)
+		}
+	}
 	go func() {
-		defer sub.Unsubscribe()
+		defer b.eventListeners.Done()
+		defer close(done)
+		defer watchdog.Stop()
+		defer func() { sub.Unsubscribe() }()
+		if next := b.nextEventBlock(); next > 0 {
+			head, err := b.RPC.Chain.GetHeaderLatest()
+			if err != nil {
+				log.WithError(err).Warn("Can't get the head block to replay contract events")
+			} else {
+				b.replayContractEvents(ctx, key, next, head.Number, dispatch)
+			}
+		}
+		b.replayPendingContracts(ctx, meta, key)
+
 		for {
 			select {
 			case <-ctx.Done():
@@ -154,6 +496,9 @@ func (b *blockchainClient) listenContractEvents() error {
 			case err := <-sub.Err():
 				log.WithError(err).Warn("Subscription signaled an error")
 
+			case <-b.eventReplay:
+				b.replayPendingContracts(ctx, meta, key)
+
 			case evt := <-sub.Chan():
 				if evt.Changes == nil {
 					log.WithField("block", evt.Block.Hex()).Warn("Received nil event")
@@ -161,70 +506,251 @@ func (b *blockchainClient) listenContractEvents() error {
 				}
 				eventArrived = true
 
-				// parse all events for this block
-				for _, chng := range evt.Changes {
-					if !bytes.Equal(chng.StorageKey[:], key) || !chng.HasStorageData {
-						// skip, we are only interested in events with content
-						continue
-					}
-
-					events := chainevents.EventRecords{}
-					err = chainevents.EventRecordsRaw(chng.StorageData).DecodeEventRecords(meta, &events)
-					if err != nil {
-						log.WithError(err).Warnf("Error parsing event %x", chng.StorageData[:])
-						continue
-					}
+				header, err := b.RPC.Chain.GetHeader(evt.Block)
+				if err != nil {
+					log.WithError(err).WithField("block", evt.Block.Hex()).
+						Warn("Can't get the block header, the block events are replayed with the next block")
+					break
+				}
 
-					for _, e := range events.Contracts_ContractEmitted {
-						if !b.eventContractAccount.Equal(&e.Contract) {
-							continue
-						}
-
-						// Identify the event by matching one of its topics against known signatures. The topics are sorted so
-						// the the needed one may be in the arbitrary position.
-						var dispatchEntry ContractEventDispatchEntry
-						found := false
-						for _, topic := range e.Topics {
-							dispatchEntry, found = b.eventDispatcher[topic]
-							if found {
-								break
-							}
-						}
-						if !found {
-							log.WithField("block", evt.Block.Hex()).
-								Warnf("Unknown event emitted by our contract: %x", e.Data[:16])
-							continue
-						}
-
-						if dispatchEntry.Handler == nil {
-							log.WithField("block", evt.Block.Hex()).WithField("event", dispatchEntry.ArgumentType.Name()).
-								Debug("Event unhandeled")
-							continue
-						}
-						args := reflect.New(dispatchEntry.ArgumentType).Interface()
-						if err := codec.Decode(e.Data[1:], args); err != nil {
-							log.WithError(err).WithField("block", evt.Block.Hex()).
-								WithField("event", dispatchEntry.ArgumentType.Name()).
-								Errorf("Cannot decode event data %x", e.Data)
-						}
-						log.WithField("block", evt.Block.Hex()).WithField("event", dispatchEntry.ArgumentType.Name()).
-							Debugf("Event args: %x", e.Data)
-						dispatchEntry.Handler(args)
+				b.replayPendingContracts(ctx, meta, key)
+				next := b.nextEventBlock()
+				if next > 0 && header.Number > next {
+					// fill the gap left by a resubscription or a reconnect
+					if !b.replayContractEvents(ctx, key, next, header.Number-1, dispatch) {
+						break
 					}
 				}
+				dispatch(header.Number, evt.Block, evt.Changes)
 			}
 		}
 	}()
 	return nil
 }
 
+func (b *blockchainClient) nextEventBlock() types.BlockNumber {
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+
+	return b.eventNextBlock
+}
+
+// blockDispatchers returns the dispatchers of the contracts listening to the block and moves the next block on. It
+// returns false if the block was already dispatched.
+func (b *blockchainClient) blockDispatchers(number types.BlockNumber, blockHash types.Hash) (map[types.AccountID]map[types.Hash]ContractEventDispatchEntry, bool) {
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+
+	if _, ok := b.eventDispatched[blockHash]; ok {
+		return nil, false
+	}
+
+	dispatchers := make(map[types.AccountID]map[types.Hash]ContractEventDispatchEntry, len(b.eventContracts))
+	for contract, events := range b.eventContracts {
+		if events.sinceBlock > number {
+			continue
+		}
+		if events.replayFrom > 0 {
+			// the replay of the contract is not done, it takes the block over
+			events.sinceBlock = number + 1
+			continue
+		}
+		dispatchers[contract] = events.dispatcher
+	}
+	if number >= b.eventNextBlock {
+		b.eventNextBlock = number + 1
+		for hash, dispatched := range b.eventDispatched {
+			if dispatched+eventReorgDepth < b.eventNextBlock {
+				delete(b.eventDispatched, hash)
+			}
+		}
+	}
+	b.eventDispatched[blockHash] = number
+
+	return dispatchers, true
+}
+
+// replayPendingContracts replays the events of the contracts registered with a starting block up to the block their
+// live events are dispatched from. The blocks left by an interrupted replay are retried with the next live event.
+func (b *blockchainClient) replayPendingContracts(ctx context.Context, meta *types.Metadata, key types.StorageKey) {
+	for contract, pending := range b.pendingReplays() {
+		contracts := map[types.AccountID]map[types.Hash]ContractEventDispatchEntry{contract: pending.dispatcher}
+		next := pending.replayFrom
+		replayed := b.replayContractEvents(ctx, key, pending.replayFrom, pending.sinceBlock-1,
+			func(number types.BlockNumber, blockHash types.Hash, changes []types.KeyValueOption) {
+				b.dispatchContractEvents(meta, key, number, blockHash, changes, contracts)
+				next = number + 1
+			})
+		if !replayed {
+			log.WithField("block", next).Warnf("Events replay of contract %x interrupted", contract[:])
+		}
+		b.replayDone(contract, pending.events, next, replayed)
+	}
+}
+
+type pendingReplay struct {
+	events     *contractEvents
+	dispatcher map[types.Hash]ContractEventDispatchEntry
+	replayFrom types.BlockNumber
+	sinceBlock types.BlockNumber
+}
+
+func (b *blockchainClient) pendingReplays() map[types.AccountID]pendingReplay {
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+
+	pending := make(map[types.AccountID]pendingReplay)
+	for contract, events := range b.eventContracts {
+		if events.replayFrom > 0 {
+			pending[contract] = pendingReplay{events: events, dispatcher: events.dispatcher, replayFrom: events.replayFrom, sinceBlock: events.sinceBlock}
+		}
+	}
+
+	return pending
+}
+
+// replayDone records the progress of the replay unless the dispatcher of the contract was replaced meanwhile.
+func (b *blockchainClient) replayDone(contract types.AccountID, events *contractEvents, next types.BlockNumber, replayed bool) {
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+
+	if b.eventContracts[contract] != events {
+		return
+	}
+	if replayed {
+		events.replayFrom = 0
+	} else {
+		events.replayFrom = next
+	}
+}
+
+// replayContractEvents dispatches the events of the blocks from..to reading them with QueryStorageAt. It returns
+// false if the replay was interrupted, the blocks left are retried with the next live event.
+func (b *blockchainClient) replayContractEvents(ctx context.Context, key types.StorageKey, from types.BlockNumber, to types.BlockNumber,
+	dispatch func(number types.BlockNumber, blockHash types.Hash, changes []types.KeyValueOption)) bool {
+	if from > to {
+		return true
+	}
+	log.WithField("from", from).WithField("to", to).Info("Replaying contract events")
+
+	for number := from; number <= to; number++ {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		hash, err := b.RPC.Chain.GetBlockHash(uint64(number))
+		if err != nil {
+			log.WithError(err).WithField("block", number).Warn("Can't get the block hash to replay contract events")
+			return false
+		}
+		changeSets, err := b.RPC.State.QueryStorageAt([]types.StorageKey{key}, hash)
+		if err != nil {
+			log.WithError(err).WithField("block", number).Warn("Can't query the block events to replay contract events")
+			return false
+		}
+		for _, changeSet := range changeSets {
+			dispatch(number, hash, changeSet.Changes)
+		}
+	}
+
+	return true
+}
+
+// dispatchContractEvents parses the System.Events changes of the block and passes the events emitted by the contracts
+// to the handlers of their dispatchers.
+func (b *blockchainClient) dispatchContractEvents(meta *types.Metadata, key types.StorageKey, blockNumber types.BlockNumber, blockHash types.Hash,
+	changes []types.KeyValueOption, dispatchers map[types.AccountID]map[types.Hash]ContractEventDispatchEntry) {
+	for _, chng := range changes {
+		if !bytes.Equal(chng.StorageKey[:], key) || !chng.HasStorageData {
+			// skip, we are only interested in events with content
+			continue
+		}
+
+		events := chainevents.EventRecords{}
+		indexes, err := chainevents.EventRecordsRaw(chng.StorageData).DecodeEventRecordsIndexed(meta, &events)
+		if err != nil {
+			log.WithError(err).Warnf("Error parsing event %x", chng.StorageData[:])
+			continue
+		}
+
+		for i, e := range events.Contracts_ContractEmitted {
+			dispatcher, ok := dispatchers[e.Contract]
+			if !ok {
+				continue
+			}
+
+			// Identify the event by matching one of its topics against known signatures. The topics are sorted so
+			// the the needed one may be in the arbitrary position.
+			var dispatchEntry ContractEventDispatchEntry
+			found := false
+			for _, topic := range e.Topics {
+				dispatchEntry, found = dispatcher[topic]
+				if found {
+					break
+				}
+			}
+			if !found {
+				log.WithField("block", blockHash.Hex()).
+					Warnf("Unknown event emitted by contract %x: %x", e.Contract[:], e.Data[:16])
+				continue
+			}
+
+			if dispatchEntry.Handler == nil {
+				log.WithField("block", blockHash.Hex()).WithField("event", dispatchEntry.ArgumentType.Name()).
+					Debug("Event unhandeled")
+				continue
+			}
+
+			event := ContractEventContext{
+				BlockHash:      blockHash,
+				BlockNumber:    blockNumber,
+				ExtrinsicIndex: e.Phase.AsApplyExtrinsic,
+				EventIndex:     indexes["Contracts_ContractEmitted"][i],
+				Contract:       e.Contract,
+				Data:           e.Data,
+			}
+			args := reflect.New(dispatchEntry.ArgumentType).Interface()
+			if err := codec.Decode(e.Data[1:], args); err != nil {
+				b.eventErrorHandler(&ContractEventError{
+					ContractEventContext: event,
+					Event:                dispatchEntry.ArgumentType.Name(),
+					Err:                  fmt.Errorf("%w: %v", ErrContractEventDecoding, err),
+				})
+				continue
+			}
+			log.WithField("block", blockHash.Hex()).WithField("event", dispatchEntry.ArgumentType.Name()).
+				Debugf("Event args: %x", e.Data)
+			if err := dispatchEntry.Handler(event, args); err != nil {
+				b.eventErrorHandler(&ContractEventError{
+					ContractEventContext: event,
+					Event:                dispatchEntry.ArgumentType.Name(),
+					Err:                  err,
+				})
+			}
+		}
+	}
+}
+
 func (b *blockchainClient) CallToReadEncoded(contractAddressSS58 string, fromAddress string, method []byte, args ...interface{}) (string, error) {
-	data, err := GetContractData(method, args...)
+	return b.CallToRead(context.Background(), ReadCall{
+		ContractAddressSS58: contractAddressSS58,
+		From:                fromAddress,
+		Method:              method,
+		Args:                args,
+	})
+}
+
+func (b *blockchainClient) CallToRead(ctx context.Context, readCall ReadCall) (string, error) {
+	data, err := GetContractData(readCall.Method, readCall.Args...)
 	if err != nil {
 		return "", errors.Wrap(err, "getMessagesData")
 	}
 
-	res, err := b.callToRead(contractAddressSS58, fromAddress, data)
+	res, err := b.dryRun(ctx, Request{
+		Origin:    readCall.From,
+		Dest:      readCall.ContractAddressSS58,
+		InputData: codec.HexEncodeToString(data),
+	}, readCall.At)
 	if err != nil {
 		return "", err
 	}
@@ -232,18 +758,20 @@ func (b *blockchainClient) CallToReadEncoded(contractAddressSS58 string, fromAdd
 	return res.Result.Ok.Data, nil
 }
 
-func (b *blockchainClient) callToRead(contractAddressSS58 string, fromAddress string, data []byte) (Response, error) {
-
-	params := Request{
-		Origin:    fromAddress,
-		Dest:      contractAddressSS58,
-		GasLimit:  500_000_000_000,
-		InputData: codec.HexEncodeToString(data),
-	}
-
+// dryRun calls contracts_call at the block, the best block is used when at is nil.
+func (b *blockchainClient) dryRun(ctx context.Context, params Request, at *types.Hash) (Response, error) {
 	res, err := withRetryOnClosedNetwork(b, func() (Response, error) {
+		weightV2, err := b.isWeightV2()
+		if err != nil {
+			return Response{}, err
+		}
+		params.GasLimit = b.readGasLimit.rpc(weightV2)
+
 		res := Response{}
-		return res, b.Client.Call(&res, "contracts_call", params)
+		if at != nil {
+			return res, b.callContext(ctx, &res, "contracts_call", params, at.Hex())
+		}
+		return res, b.callContext(ctx, &res, "contracts_call", params)
 	})
 	if err != nil {
 		return Response{}, errors.Wrap(err, "call")
@@ -252,32 +780,139 @@ func (b *blockchainClient) callToRead(contractAddressSS58 string, fromAddress st
 	return res, nil
 }
 
+// callContext is Client.Call cancelled with the context.
+func (b *blockchainClient) callContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if cl, ok := b.Client.(*rpcClient); ok {
+		return cl.CallContext(ctx, result, method, args...)
+	}
+
+	return b.Client.Call(result, method, args...)
+}
+
 func (b *blockchainClient) CallToExec(ctx context.Context, contractCall ContractCall) (types.Hash, error) {
-	data, err := GetContractData(contractCall.Method, contractCall.Args...)
+	result, err := b.CallToExecWithResult(ctx, contractCall)
 	if err != nil {
 		return types.Hash{}, err
 	}
 
+	return result.BlockHash, nil
+}
+
+func (b *blockchainClient) CallToExecWithResult(ctx context.Context, contractCall ContractCall) (ExtrinsicResult, error) {
+	data, err := GetContractData(contractCall.Method, contractCall.Args...)
+	if err != nil {
+		return ExtrinsicResult{}, err
+	}
+
+	gasLimit, storageDepositLimit, err := b.estimateContractCall(ctx, contractCall, data)
+	if err != nil {
+		return ExtrinsicResult{}, err
+	}
+
 	dest := types.MultiAddress{IsID: true, AsID: contractCall.ContractAddress}
 	value := types.NewUCompactFromUInt(contractCall.Value)
-	gasLimit := types.NewUCompactFromUInt(contractCall.GasLimit)
-	storageDepositLimit := types.NewOptionBoolEmpty()
 
 	extrinsic, err := withRetryOnClosedNetwork(b, func() (types.Extrinsic, error) {
-		return b.createExtrinsic("Contracts.call", contractCall.From, dest, value, gasLimit, storageDepositLimit, data)
+		weightV2, err := b.isWeightV2()
+		if err != nil {
+			return types.Extrinsic{}, err
+		}
+		return b.createExtrinsic("Contracts.call", contractCall.From, dest, value, gasLimit.encode(weightV2), storageDepositLimit, data)
 	})
 	if err != nil {
-		return types.Hash{}, err
+		return ExtrinsicResult{}, err
 	}
 
-	hash, err := withRetryOnClosedNetwork(b, func() (types.Hash, error) {
-		return b.submitAndWaitExtrinsic(ctx, extrinsic)
+	result, err := withRetryOnClosedNetwork(b, func() (ExtrinsicResult, error) {
+		return b.submitAndWaitExtrinsic(ctx, extrinsic, contractCall.From.Address)
 	})
 	if err != nil {
-		return types.Hash{}, err
+		return ExtrinsicResult{}, err
+	}
+
+	// the call may revert on chain though its dry run succeeded
+	return result, b.checkExtrinsic(result)
+}
+
+// checkExtrinsic returns the dispatch error of the included extrinsic wrapped in ErrExtrinsicFailed if it failed.
+func (b *blockchainClient) checkExtrinsic(result ExtrinsicResult) error {
+	if result.ExtrinsicIndex == 0 {
+		return errors.Wrap(ErrExtrinsicIndexUnknown, "block "+result.BlockHash.Hex())
+	}
+
+	events, err := withRetryOnClosedNetwork(b, func() (*chainevents.EventRecords, error) {
+		return b.blockEvents(result.BlockHash)
+	})
+	if err != nil {
+		return err
+	}
+
+	return extrinsicFailure(events, result.ExtrinsicIndex)
+}
+
+// estimateContractCall dry runs the call from its From address, the limits set on the call override the estimated ones.
+func (b *blockchainClient) estimateContractCall(ctx context.Context, contractCall ContractCall, data []byte) (Weight, types.Option[types.UCompact], error) {
+	res, err := b.dryRun(ctx, Request{
+		Origin:    contractCall.From.Address,
+		Dest:      contractCall.ContractAddressSS58,
+		InputData: codec.HexEncodeToString(data),
+		Value:     int(contractCall.Value),
+	}, nil)
+	if err != nil {
+		return Weight{}, types.NewEmptyOption[types.UCompact](), err
+	}
+
+	gasLimit, storageDepositLimit, err := estimateCall(res, contractCall.ErrorDecoder, b.gasMarginPercent)
+	if err != nil {
+		return Weight{}, storageDepositLimit, err
+	}
+	if contractCall.GasLimit > 0 {
+		gasLimit.RefTime = contractCall.GasLimit
+	}
+	if contractCall.ProofSizeLimit > 0 {
+		gasLimit.ProofSize = contractCall.ProofSizeLimit
+	}
+	if contractCall.StorageDepositLimit > 0 {
+		storageDepositLimit = types.NewOption(types.NewUCompactFromUInt(contractCall.StorageDepositLimit))
+	}
+
+	return gasLimit, storageDepositLimit, nil
+}
+
+// estimateCall checks the dry run result and derives the gas and storage deposit limits with the safety margin applied.
+func estimateCall(res Response, errorDecoder func([]byte) error, marginPercent uint64) (Weight, types.Option[types.UCompact], error) {
+	noDepositLimit := types.NewEmptyOption[types.UCompact]()
+
+	if len(res.Result.Err) > 0 && string(res.Result.Err) != "null" {
+		return Weight{}, noDepositLimit, errors.Wrapf(ErrDryRunFailed, "%s %s", res.Result.Err, res.DebugMessage)
+	}
+
+	if res.Result.Ok.Flags&revertFlag != 0 {
+		if errorDecoder != nil {
+			output, err := codec.HexDecodeString(res.Result.Ok.Data)
+			if err != nil {
+				return Weight{}, noDepositLimit, errors.Wrap(err, "decode reverted output")
+			}
+			if err := errorDecoder(output); err != nil {
+				return Weight{}, noDepositLimit, errors.Wrap(err, "dry run")
+			}
+		}
+		return Weight{}, noDepositLimit, errors.Wrapf(ErrContractReverted, "output %s %s", res.Result.Ok.Data, res.DebugMessage)
+	}
+
+	gasLimit := Weight{
+		RefTime:   res.GasRequired.RefTime + res.GasRequired.RefTime*marginPercent/100,
+		ProofSize: res.GasRequired.ProofSize + res.GasRequired.ProofSize*marginPercent/100,
 	}
 
-	return hash, err
+	storageDepositLimit := noDepositLimit
+	if charge := res.StorageDeposit.Charge; charge != nil && charge.Sign() > 0 {
+		limit := new(big.Int).Mul(&charge.Int, big.NewInt(int64(100+marginPercent)))
+		limit.Div(limit, big.NewInt(100))
+		storageDepositLimit = types.NewOption(types.NewUCompact(limit))
+	}
+
+	return gasLimit, storageDepositLimit, nil
 }
 
 func (b *blockchainClient) Deploy(ctx context.Context, deployCall DeployCall) (types.AccountID, error) {
@@ -291,13 +926,26 @@ func (b *blockchainClient) Deploy(ctx context.Context, deployCall DeployCall) (t
 		return types.AccountID{}, err
 	}
 
+	gasLimit := Weight{RefTime: deployCall.GasLimit * CERE, ProofSize: deployCall.ProofSizeLimit}
+	if gasLimit.ProofSize == 0 {
+		gasLimit.ProofSize = DEFAULT_PROOF_SIZE_LIMIT
+	}
+	storageDepositLimit := types.NewEmptyOption[types.UCompact]()
+	if deployCall.StorageDepositLimit > 0 {
+		storageDepositLimit = types.NewOption(types.NewUCompactFromUInt(deployCall.StorageDepositLimit))
+	}
+
 	extrinsic, err := withRetryOnClosedNetwork(b, func() (types.Extrinsic, error) {
+		weightV2, err := b.isWeightV2()
+		if err != nil {
+			return types.Extrinsic{}, err
+		}
 		return b.createExtrinsic(
 			"Contracts.instantiate_with_code",
 			deployCall.From,
 			types.NewUCompactFromUInt(uint64(deployCall.Value*CERE)),
-			types.NewUCompactFromUInt(uint64(deployCall.GasLimit*CERE)),
-			types.NewOptionBoolEmpty(),
+			gasLimit.encode(weightV2),
+			storageDepositLimit,
 			deployCall.Code,
 			data,
 			deployCall.Salt)
@@ -306,53 +954,30 @@ func (b *blockchainClient) Deploy(ctx context.Context, deployCall DeployCall) (t
 		return types.AccountID{}, err
 	}
 
-	hash, err := withRetryOnClosedNetwork(b, func() (types.Hash, error) {
-		return b.submitAndWaitExtrinsic(ctx, extrinsic)
+	result, err := withRetryOnClosedNetwork(b, func() (ExtrinsicResult, error) {
+		return b.submitAndWaitExtrinsic(ctx, extrinsic, deployCall.From.Address)
 	})
 	if err != nil {
 		return types.AccountID{}, err
 	}
 
 	return withRetryOnClosedNetwork(b, func() (types.AccountID, error) {
-		return b.grabContractInstantiated(hash, deployer)
+		return b.grabContractInstantiated(result.BlockHash, deployer)
 	})
 }
 
 func (b *blockchainClient) grabContractInstantiated(hash types.Hash, deployer *types.AccountID) (types.AccountID, error) {
-	meta, err := b.RPC.State.GetMetadataLatest()
+	events, err := b.blockEvents(hash)
 	if err != nil {
-		return types.AccountID{}, errors.Wrap(err, "get metadata lastest")
-	}
-
-	key, err := types.CreateStorageKey(meta, "System", "Events", nil, nil)
-	if err != nil {
-		return types.AccountID{}, errors.Wrap(err, "create storage key")
-	}
-
-	storage, err := b.RPC.State.QueryStorageAt([]types.StorageKey{key}, hash)
-	if err != nil {
-		return types.AccountID{}, errors.Wrap(err, "query storage at block "+hash.Hex())
+		return types.AccountID{}, err
 	}
 
-	for _, st := range storage {
-		for _, chng := range st.Changes {
-			events := chainevents.EventRecords{}
-			err = chainevents.EventRecordsRaw(chng.StorageData).DecodeEventRecords(meta, &events)
-			if err != nil {
-				log.WithError(err).Warnf("Error parsing event %x", chng.StorageData[:])
-				continue
-			}
-			for _, e := range events.Contracts_Instantiated {
-				if !e.Deployer.Equal(deployer) {
-					log.Warnf("Deployers mismatch %s and %s", e.Deployer.ToHexString(), deployer.ToHexString())
-					continue
-				}
-				return e.Contract, nil
-			}
-		}
+	contract, ok := findInstantiated(events, 0, deployer)
+	if !ok {
+		return types.AccountID{}, errors.New("Contract not instantiated at block " + hash.Hex())
 	}
 
-	return types.AccountID{}, errors.New("Contract not instantiated at block " + hash.Hex())
+	return contract, nil
 }
 
 func (b *blockchainClient) createExtrinsic(cmd string, authKey signature.KeyringPair, args ...interface{}) (types.Extrinsic, error) {
@@ -371,61 +996,156 @@ func (b *blockchainClient) createExtrinsic(cmd string, authKey signature.Keyring
 		return types.Extrinsic{}, errors.Wrap(err, "get runtime version lastest error")
 	}
 
-	key, err := types.CreateStorageKey(meta, "System", "Account", authKey.PublicKey, nil)
+	era := types.ExtrinsicEra{IsMortalEra: false}
+	eraBlockHash := genesisHash
+	if b.mortality > 0 {
+		eraBlockHash, err = b.RPC.Chain.GetFinalizedHead()
+		if err != nil {
+			return types.Extrinsic{}, errors.Wrap(err, "get finalized head error")
+		}
+		header, err := b.RPC.Chain.GetHeader(eraBlockHash)
+		if err != nil {
+			return types.Extrinsic{}, errors.Wrap(err, "get finalized header error")
+		}
+		era = mortalEra(b.mortality, uint64(header.Number))
+	}
+
+	call, err := types.NewCall(meta, cmd, args...)
 	if err != nil {
-		return types.Extrinsic{}, errors.Wrap(err, "create storage key error")
+		return types.Extrinsic{}, errors.Wrap(err, "new call error")
 	}
+	ext := types.NewExtrinsic(call)
 
-	var accountInfo types.AccountInfo
-	ok, err := b.RPC.State.GetStorageLatest(key, &accountInfo)
+	nonce, err := b.nonces.next(authKey.Address)
 	if err != nil {
-		return types.Extrinsic{}, errors.Wrapf(err, "create storage key error by %s", authKey.Address)
-	} else if !ok {
-		return types.Extrinsic{}, errors.Errorf("no accountInfo found by %s", authKey.Address)
+		return types.Extrinsic{}, errors.Wrapf(err, "get nonce error by %s", authKey.Address)
 	}
 
 	o := types.SignatureOptions{
-		BlockHash:          genesisHash,
-		Era:                types.ExtrinsicEra{IsMortalEra: false},
+		BlockHash:          eraBlockHash,
+		Era:                era,
 		GenesisHash:        genesisHash,
-		Nonce:              types.NewUCompactFromUInt(uint64(accountInfo.Nonce)),
+		Nonce:              types.NewUCompactFromUInt(nonce),
 		SpecVersion:        rv.SpecVersion,
 		Tip:                types.NewUCompactFromUInt(0),
 		TransactionVersion: rv.TransactionVersion,
 	}
 
-	call, err := types.NewCall(meta, cmd, args...)
-	if err != nil {
-		return types.Extrinsic{}, errors.Wrap(err, "new call error")
-	}
-	ext := types.NewExtrinsic(call)
-
 	if err := ext.Sign(authKey, o); err != nil {
+		b.nonces.resync(authKey.Address)
 		return types.Extrinsic{}, errors.Wrap(err, "sign extrinsic error")
 	}
 
 	return ext, nil
 }
 
-func (b *blockchainClient) submitAndWaitExtrinsic(ctx context.Context, extrinsic types.Extrinsic) (types.Hash, error) {
+func (b *blockchainClient) submitAndWaitExtrinsic(ctx context.Context, extrinsic types.Extrinsic, signer string) (ExtrinsicResult, error) {
+	if b.submitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.submitTimeout)
+		defer cancel()
+	}
+
 	sub, err := b.RPC.Author.SubmitAndWatchExtrinsic(extrinsic)
 	if err != nil {
-		return types.Hash{}, errors.Wrap(err, "submit error")
+		b.nonces.resync(signer)
+		return ExtrinsicResult{}, errors.Wrap(err, "submit error")
 	}
 	defer sub.Unsubscribe()
 
 	for {
 		select {
 		case status := <-sub.Chan():
-			if status.IsInBlock || status.IsFinalized {
-				return status.AsInBlock, nil
+			blockHash, done, err := checkStatus(status, b.waitPolicy)
+			if err != nil {
+				b.nonces.resync(signer)
+				return ExtrinsicResult{}, err
+			}
+			if done {
+				return b.extrinsicResult(extrinsic, blockHash, status.IsFinalized)
 			}
 		case err := <-sub.Err():
-			return types.Hash{}, errors.Wrap(err, "subscribe error")
+			b.nonces.resync(signer)
+			return ExtrinsicResult{}, errors.Wrap(err, "subscribe error")
 		case <-ctx.Done():
-			return types.Hash{}, ctx.Err()
+			b.nonces.resync(signer)
+			return ExtrinsicResult{}, ctx.Err()
+		}
+	}
+}
+
+// extrinsicResult locates the extrinsic in the block it was included in.
+func (b *blockchainClient) extrinsicResult(extrinsic types.Extrinsic, blockHash types.Hash, finalized bool) (ExtrinsicResult, error) {
+	encoded, err := codec.Encode(extrinsic)
+	if err != nil {
+		return ExtrinsicResult{}, err
+	}
+
+	result := ExtrinsicResult{
+		ExtrinsicHash: blake2b.Sum256(encoded),
+		BlockHash:     blockHash,
+		Finalized:     finalized,
+	}
+
+	block, err := b.RPC.Chain.GetBlock(blockHash)
+	if err != nil {
+		log.WithError(err).WithField("block", blockHash.Hex()).Warn("Can't get the block of the extrinsic")
+		return result, nil
+	}
+	result.BlockNumber = block.Block.Header.Number
+
+	for i, e := range block.Block.Extrinsics {
+		data, err := codec.Encode(e)
+		if err == nil && bytes.Equal(data, encoded) {
+			result.ExtrinsicIndex = uint32(i)
+			break
 		}
 	}
+
+	return result, nil
+}
+
+func (n *NumberOrHex) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return n.Int.UnmarshalJSON(data)
+	}
+
+	if _, ok := n.SetString(s, 0); !ok {
+		return errors.Errorf("invalid number %s", s)
+	}
+
+	return nil
+}
+
+func (b *blockchainClient) accountNextIndex(address string) (uint64, error) {
+	return withRetryOnClosedNetwork(b, func() (uint64, error) {
+		var nonce uint64
+		return nonce, b.Client.Call(&nonce, "system_accountNextIndex", address)
+	})
+}
+
+// isWeightV2 detects the weight version of pallet-contracts once per connection.
+func (b *blockchainClient) isWeightV2() (bool, error) {
+	b.connectMutex.Lock()
+	defer b.connectMutex.Unlock()
+
+	if b.weightV2 != nil {
+		return *b.weightV2, nil
+	}
+
+	meta, err := b.RPC.State.GetMetadataLatest()
+	if err != nil {
+		return false, errors.Wrap(err, "get metadata lastest")
+	}
+
+	weightV2, err := isWeightV2(meta)
+	if err != nil {
+		return false, err
+	}
+	b.weightV2 = &weightV2
+
+	return weightV2, nil
 }
 
 func withRetryOnClosedNetwork[T any](b *blockchainClient, f func() (T, error)) (T, error) {
@@ -443,26 +1163,55 @@ func withRetryOnClosedNetwork[T any](b *blockchainClient, f func() (T, error)) (
 func (b *blockchainClient) reconnect() error {
 	b.connectMutex.Lock()
 	defer b.connectMutex.Unlock()
+	if b.ctx.Err() != nil {
+		return ErrClientClosed
+	}
 	_, err := b.RPC.State.GetRuntimeVersionLatest()
 	if !isClosedNetworkError(err) {
 		return nil
 	}
 
-	if b.eventContextCancel != nil {
-		b.eventContextCancel()
-	}
-	substrateAPI, err := gsrpc.NewSubstrateAPI(b.Client.URL())
+	substrateAPI, err := connect(b.ctx, b.Client.URL())
 	if err != nil {
+		// the listener is kept, its watchdog resubscribes
 		log.WithError(err).Warningf("Blockchain client can't reconnect to %s", b.Client.URL())
 		return err
 	}
+
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+	if b.eventContextCancel != nil {
+		b.eventContextCancel()
+		b.eventContextCancel = nil
+	}
+	b.closeConnection()
 	b.SubstrateAPI = substrateAPI
-	if b.eventDispatcher != nil {
-		err = b.listenContractEvents()
-		if err != nil {
-			return err
-		}
+	b.weightV2 = nil
+	if !b.eventRestarting && (len(b.eventContracts) > 0 || b.eventListenerDone != nil) {
+		b.eventRestarting = true
+		b.eventListeners.Add(1)
+		go b.restartContractEvents(b.eventListenerDone)
 	}
 
 	return nil
 }
+
+// restartContractEvents starts the listener of the new connection once the listener of the previous one stopped, so
+// they never dispatch at the same time. The stopping listener may need the event mutex or the connect mutex, e.g. in a
+// handler calling the client, so it is waited for without holding them.
+func (b *blockchainClient) restartContractEvents(stopped <-chan struct{}) {
+	defer b.eventListeners.Done()
+	if stopped != nil {
+		<-stopped
+	}
+
+	b.eventMutex.Lock()
+	defer b.eventMutex.Unlock()
+	b.eventRestarting = false
+	if len(b.eventContracts) == 0 || b.eventContextCancel != nil {
+		return
+	}
+	if err := b.listenContractEvents(); err != nil && !errors.Is(err, ErrClientClosed) {
+		log.WithError(err).Warn("Can't restart the contract events listener, it is restarted with the next dispatcher set or reconnect")
+	}
+}
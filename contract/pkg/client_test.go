@@ -0,0 +1,226 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateCall(t *testing.T) {
+	errDecoded := errors.New("decoded")
+	decoder := func(data []byte) error {
+		if len(data) > 1 && data[0] == 0x01 {
+			return errDecoded
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name        string
+		response    string
+		wantGas     Weight
+		wantDeposit types.Option[types.UCompact]
+		wantErr     error
+	}{
+		{
+			name:        "Success without storage deposit",
+			response:    `{"gasConsumed": 900, "gasRequired": 1000, "storageDeposit": {"refund": 0}, "result": {"Ok": {"flags": 0, "data": "0x00"}}}`,
+			wantGas:     Weight{RefTime: 1200},
+			wantDeposit: types.NewEmptyOption[types.UCompact](),
+		},
+		{
+			name:        "Success with storage deposit",
+			response:    `{"gasConsumed": 900, "gasRequired": 1000, "storageDeposit": {"charge": "0x3e8"}, "result": {"Ok": {"flags": 0, "data": "0x00"}}}`,
+			wantGas:     Weight{RefTime: 1200},
+			wantDeposit: types.NewOption(types.NewUCompact(big.NewInt(1200))),
+		},
+		{
+			name:        "Success with Weight v2",
+			response:    `{"gasConsumed": {"refTime": 900, "proofSize": 90}, "gasRequired": {"refTime": 1000, "proofSize": 100}, "storageDeposit": {"charge": 0}, "result": {"Ok": {"flags": 0, "data": "0x00"}}}`,
+			wantGas:     Weight{RefTime: 1200, ProofSize: 120},
+			wantDeposit: types.NewEmptyOption[types.UCompact](),
+		},
+		{
+			name:     "Reverted with contract error",
+			response: `{"gasConsumed": 900, "gasRequired": 1000, "storageDeposit": {"charge": 0}, "result": {"Ok": {"flags": 1, "data": "0x0100"}}}`,
+			wantErr:  errDecoded,
+		},
+		{
+			name:     "Reverted with unknown output",
+			response: `{"gasConsumed": 900, "gasRequired": 1000, "storageDeposit": {"charge": 0}, "result": {"Ok": {"flags": 1, "data": "0x"}}}`,
+			wantErr:  ErrContractReverted,
+		},
+		{
+			name:     "Dispatch error",
+			response: `{"gasConsumed": 900, "gasRequired": 1000, "storageDeposit": {"charge": 0}, "result": {"Err": {"Module": {"index": 8, "error": 11}}}}`,
+			wantErr:  ErrDryRunFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			res := Response{}
+			assert.NoError(t, json.Unmarshal([]byte(tt.response), &res))
+
+			//when
+			gas, deposit, err := estimateCall(res, decoder, DEFAULT_GAS_MARGIN_PERCENT)
+
+			//then
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantGas, gas)
+			assert.Equal(t, tt.wantDeposit, deposit)
+		})
+	}
+}
+
+func TestBlockDispatchers(t *testing.T) {
+	//given
+	bucketDispatcher := map[types.Hash]ContractEventDispatchEntry{{1}: {}}
+	captureDispatcher := map[types.Hash]ContractEventDispatchEntry{{2}: {}}
+	client := &blockchainClient{
+		eventContracts: map[types.AccountID]*contractEvents{
+			{1}: {dispatcher: bucketDispatcher},
+			{2}: {dispatcher: captureDispatcher, sinceBlock: 12},
+		},
+		eventNextBlock:  10,
+		eventDispatched: map[types.Hash]types.BlockNumber{{9}: 9},
+	}
+
+	//when
+	replayed, okReplayed := client.blockDispatchers(11, types.Hash{11})
+	live, okLive := client.blockDispatchers(12, types.Hash{12})
+	_, okDispatched := client.blockDispatchers(12, types.Hash{12})
+	fork, okFork := client.blockDispatchers(12, types.Hash{0xf})
+
+	//then
+	assert.True(t, okReplayed)
+	assert.Equal(t, map[types.AccountID]map[types.Hash]ContractEventDispatchEntry{{1}: bucketDispatcher}, replayed)
+	assert.True(t, okLive)
+	assert.Equal(t, map[types.AccountID]map[types.Hash]ContractEventDispatchEntry{{1}: bucketDispatcher, {2}: captureDispatcher}, live)
+	assert.False(t, okDispatched)
+	assert.True(t, okFork)
+	assert.Equal(t, live, fork)
+	assert.Equal(t, types.BlockNumber(13), client.eventNextBlock)
+}
+
+func TestBlockDispatchersForgetsOldBlocks(t *testing.T) {
+	//given
+	client := &blockchainClient{
+		eventContracts:  map[types.AccountID]*contractEvents{},
+		eventNextBlock:  10,
+		eventDispatched: map[types.Hash]types.BlockNumber{{9}: 9},
+	}
+
+	//when
+	client.blockDispatchers(10+eventReorgDepth, types.Hash{1})
+
+	//then
+	assert.Equal(t, map[types.Hash]types.BlockNumber{{1}: 10 + eventReorgDepth}, client.eventDispatched)
+}
+
+func TestBlockDispatchersPendingReplay(t *testing.T) {
+	//given
+	dispatcher := map[types.Hash]ContractEventDispatchEntry{{1}: {}}
+	events := &contractEvents{dispatcher: dispatcher, sinceBlock: 12, replayFrom: 5}
+	client := &blockchainClient{
+		eventContracts:  map[types.AccountID]*contractEvents{{1}: events},
+		eventNextBlock:  12,
+		eventDispatched: map[types.Hash]types.BlockNumber{},
+	}
+
+	//when
+	whileReplaying, _ := client.blockDispatchers(12, types.Hash{12})
+	pending := client.pendingReplays()
+	client.replayDone(types.AccountID{1}, events, 8, false)
+	interrupted := client.pendingReplays()
+	client.replayDone(types.AccountID{1}, events, 13, true)
+	afterReplay, _ := client.blockDispatchers(13, types.Hash{13})
+
+	//then
+	assert.Empty(t, whileReplaying)
+	assert.Equal(t, map[types.AccountID]pendingReplay{{1}: {events: events, dispatcher: dispatcher, replayFrom: 5, sinceBlock: 13}}, pending)
+	assert.Equal(t, types.BlockNumber(8), interrupted[types.AccountID{1}].replayFrom)
+	assert.Empty(t, client.pendingReplays())
+	assert.Equal(t, map[types.AccountID]map[types.Hash]ContractEventDispatchEntry{{1}: dispatcher}, afterReplay)
+}
+
+func TestConnectBlockchainClientCancelled(t *testing.T) {
+	//given
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	//when
+	client, err := ConnectBlockchainClient(ctx, "ws://127.0.0.1:9944")
+
+	//then
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, client)
+}
+
+func TestRestartContractEventsWaitsForStoppedListener(t *testing.T) {
+	//given
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := &blockchainClient{
+		ctx:             ctx,
+		eventContracts:  map[types.AccountID]*contractEvents{{1}: {}},
+		eventRestarting: true,
+	}
+	stopped := make(chan struct{})
+	client.eventListeners.Add(1)
+	go client.restartContractEvents(stopped)
+
+	//when
+	time.Sleep(10 * time.Millisecond)
+	client.eventMutex.Lock()
+	restartingBeforeStop := client.eventRestarting
+	client.eventMutex.Unlock()
+	close(stopped)
+	client.eventListeners.Wait()
+
+	//then
+	assert.True(t, restartingBeforeStop)
+	assert.False(t, client.eventRestarting)
+	assert.Nil(t, client.eventContextCancel)
+}
+
+func TestDispatchedEventBlock(t *testing.T) {
+	//given
+	live, replaying, registered, unknown := "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL", "5DTZfAcmZctJodfa4W88BW5QXVBxT4v7UEax91HZCArTih6U",
+		"5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", "5FHneW46xGXgs5mUiveU4sbTyGBzmstUspZC92UhjJM694ty"
+	accountId := func(address string) types.AccountID {
+		id, _ := DecodeAccountIDFromSS58(address)
+		return id
+	}
+	client := &blockchainClient{
+		eventContracts: map[types.AccountID]*contractEvents{
+			accountId(live):       {},
+			accountId(replaying):  {replayFrom: 5, sinceBlock: 12},
+			accountId(registered): {sinceBlock: 12},
+		},
+		eventNextBlock: 12,
+	}
+
+	//when
+	liveBlock, errLive := client.DispatchedEventBlock(live)
+	replayingBlock, _ := client.DispatchedEventBlock(replaying)
+	registeredBlock, _ := client.DispatchedEventBlock(registered)
+	unknownBlock, _ := client.DispatchedEventBlock(unknown)
+
+	//then
+	assert.NoError(t, errLive)
+	assert.Equal(t, types.BlockNumber(11), liveBlock)
+	assert.Equal(t, types.BlockNumber(4), replayingBlock)
+	assert.Equal(t, types.BlockNumber(0), registeredBlock)
+	assert.Equal(t, types.BlockNumber(0), unknownBlock)
+}
@@ -0,0 +1,311 @@
+package pkg
+
+import (
+	"context"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/chainevents"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+type (
+	// UploadCodeCall stores a contract code on chain to instantiate it later from its hash.
+	UploadCodeCall struct {
+		Code []byte
+		From signature.KeyringPair
+		// StorageDepositLimit is not limited when set to 0.
+		StorageDepositLimit uint64
+	}
+
+	// InstantiateCall instantiates a contract from a code already stored on chain. Unlike DeployCall the amounts are
+	// in the raw units of the chain.
+	InstantiateCall struct {
+		CodeHash types.Hash
+		Salt     []byte
+		From     signature.KeyringPair
+		Value    uint64
+		GasLimit uint64
+		// ProofSizeLimit is DEFAULT_PROOF_SIZE_LIMIT when set to 0, it is ignored by Weight v1 runtimes.
+		ProofSizeLimit uint64
+		// StorageDepositLimit is not limited when set to 0.
+		StorageDepositLimit uint64
+		Method              []byte
+		Args                []interface{}
+	}
+
+	// SetCodeCall replaces the code of a contract. Contracts.set_code requires the root origin, so the call is
+	// dispatched with Sudo.sudo and From must be the sudo key.
+	SetCodeCall struct {
+		ContractAddress types.AccountID
+		CodeHash        types.Hash
+		From            signature.KeyringPair
+	}
+
+	UploadCodeResult struct {
+		ExtrinsicResult
+		CodeHash types.Hash
+		// Events are all the events of the block the code was uploaded in.
+		Events *chainevents.EventRecords
+	}
+
+	DeployResult struct {
+		ExtrinsicResult
+		ContractAddress types.AccountID
+		// Events are all the events of the block the contract was instantiated in.
+		Events *chainevents.EventRecords
+	}
+)
+
+// CodeHash returns the hash a contract code is stored under.
+func CodeHash(code []byte) types.Hash {
+	return blake2b.Sum256(code)
+}
+
+func (b *blockchainClient) UploadCode(ctx context.Context, uploadCall UploadCodeCall) (UploadCodeResult, error) {
+	storageDepositLimit := types.NewEmptyOption[types.UCompact]()
+	if uploadCall.StorageDepositLimit > 0 {
+		storageDepositLimit = types.NewOption(types.NewUCompactFromUInt(uploadCall.StorageDepositLimit))
+	}
+
+	extrinsic, err := withRetryOnClosedNetwork(b, func() (types.Extrinsic, error) {
+		meta, err := b.RPC.State.GetMetadataLatest()
+		if err != nil {
+			return types.Extrinsic{}, errors.Wrap(err, "get metadata lastest")
+		}
+		args := []interface{}{uploadCall.Code, storageDepositLimit}
+		withDeterminism, err := callHasField(meta, "Contracts", "upload_code", "determinism")
+		if err != nil {
+			return types.Extrinsic{}, err
+		}
+		if withDeterminism {
+			// Determinism::Enforced, the code must not use floats or other non deterministic instructions.
+			args = append(args, types.U8(0))
+		}
+		return b.createExtrinsic("Contracts.upload_code", uploadCall.From, args...)
+	})
+	if err != nil {
+		return UploadCodeResult{}, err
+	}
+
+	result, err := withRetryOnClosedNetwork(b, func() (ExtrinsicResult, error) {
+		return b.submitAndWaitExtrinsic(ctx, extrinsic, uploadCall.From.Address)
+	})
+	if err != nil {
+		return UploadCodeResult{}, err
+	}
+
+	events, err := withRetryOnClosedNetwork(b, func() (*chainevents.EventRecords, error) {
+		return b.blockEvents(result.BlockHash)
+	})
+	if err != nil {
+		return UploadCodeResult{}, err
+	}
+
+	codeHash, ok, err := findCodeStored(events, result.ExtrinsicIndex)
+	if err != nil {
+		return UploadCodeResult{}, err
+	}
+	if !ok {
+		// the code was already stored
+		codeHash = CodeHash(uploadCall.Code)
+	}
+
+	return UploadCodeResult{ExtrinsicResult: result, CodeHash: codeHash, Events: events}, nil
+}
+
+func (b *blockchainClient) InstantiateFromHash(ctx context.Context, instantiateCall InstantiateCall) (DeployResult, error) {
+	deployer, err := types.NewAccountID(instantiateCall.From.PublicKey)
+	if err != nil {
+		return DeployResult{}, err
+	}
+
+	data, err := GetContractData(instantiateCall.Method, instantiateCall.Args...)
+	if err != nil {
+		return DeployResult{}, err
+	}
+
+	gasLimit := Weight{RefTime: instantiateCall.GasLimit, ProofSize: instantiateCall.ProofSizeLimit}
+	if gasLimit.ProofSize == 0 {
+		gasLimit.ProofSize = DEFAULT_PROOF_SIZE_LIMIT
+	}
+	storageDepositLimit := types.NewEmptyOption[types.UCompact]()
+	if instantiateCall.StorageDepositLimit > 0 {
+		storageDepositLimit = types.NewOption(types.NewUCompactFromUInt(instantiateCall.StorageDepositLimit))
+	}
+
+	extrinsic, err := withRetryOnClosedNetwork(b, func() (types.Extrinsic, error) {
+		weightV2, err := b.isWeightV2()
+		if err != nil {
+			return types.Extrinsic{}, err
+		}
+		return b.createExtrinsic(
+			"Contracts.instantiate",
+			instantiateCall.From,
+			types.NewUCompactFromUInt(instantiateCall.Value),
+			gasLimit.encode(weightV2),
+			storageDepositLimit,
+			instantiateCall.CodeHash,
+			data,
+			instantiateCall.Salt)
+	})
+	if err != nil {
+		return DeployResult{}, err
+	}
+
+	result, err := withRetryOnClosedNetwork(b, func() (ExtrinsicResult, error) {
+		return b.submitAndWaitExtrinsic(ctx, extrinsic, instantiateCall.From.Address)
+	})
+	if err != nil {
+		return DeployResult{}, err
+	}
+
+	events, err := withRetryOnClosedNetwork(b, func() (*chainevents.EventRecords, error) {
+		return b.blockEvents(result.BlockHash)
+	})
+	if err != nil {
+		return DeployResult{}, err
+	}
+
+	contract, ok := findInstantiated(events, result.ExtrinsicIndex, deployer)
+	if !ok {
+		return DeployResult{}, errors.New("Contract not instantiated at block " + result.BlockHash.Hex())
+	}
+
+	return DeployResult{ExtrinsicResult: result, ContractAddress: contract, Events: events}, nil
+}
+
+func (b *blockchainClient) SetCode(ctx context.Context, setCodeCall SetCodeCall) (ExtrinsicResult, error) {
+	dest := types.MultiAddress{IsID: true, AsID: setCodeCall.ContractAddress}
+
+	extrinsic, err := withRetryOnClosedNetwork(b, func() (types.Extrinsic, error) {
+		meta, err := b.RPC.State.GetMetadataLatest()
+		if err != nil {
+			return types.Extrinsic{}, errors.Wrap(err, "get metadata lastest")
+		}
+		setCode, err := types.NewCall(meta, "Contracts.set_code", dest, setCodeCall.CodeHash)
+		if err != nil {
+			return types.Extrinsic{}, errors.Wrap(err, "new call error")
+		}
+		return b.createExtrinsic("Sudo.sudo", setCodeCall.From, setCode)
+	})
+	if err != nil {
+		return ExtrinsicResult{}, err
+	}
+
+	result, err := withRetryOnClosedNetwork(b, func() (ExtrinsicResult, error) {
+		return b.submitAndWaitExtrinsic(ctx, extrinsic, setCodeCall.From.Address)
+	})
+	if err != nil {
+		return ExtrinsicResult{}, err
+	}
+
+	events, err := withRetryOnClosedNetwork(b, func() (*chainevents.EventRecords, error) {
+		return b.blockEvents(result.BlockHash)
+	})
+	if err != nil {
+		return ExtrinsicResult{}, err
+	}
+
+	// Sudo.sudo succeeds even when the dispatched call fails, the code update is confirmed by its event.
+	for _, e := range events.Contracts_ContractCodeUpdated {
+		if e.Contract == setCodeCall.ContractAddress && e.NewCodeHash == setCodeCall.CodeHash {
+			return result, nil
+		}
+	}
+
+	return ExtrinsicResult{}, errors.New("Contract code not updated at block " + result.BlockHash.Hex())
+}
+
+// blockEvents decodes the system events of the block.
+func (b *blockchainClient) blockEvents(hash types.Hash) (*chainevents.EventRecords, error) {
+	meta, err := b.RPC.State.GetMetadataLatest()
+	if err != nil {
+		return nil, errors.Wrap(err, "get metadata lastest")
+	}
+
+	key, err := types.CreateStorageKey(meta, "System", "Events", nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create storage key")
+	}
+
+	raw, err := b.RPC.State.GetStorageRaw(key, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "query storage at block "+hash.Hex())
+	}
+
+	events := &chainevents.EventRecords{}
+	if err := chainevents.EventRecordsRaw(*raw).DecodeEventRecords(meta, events); err != nil {
+		return nil, errors.Wrap(err, "decode events of block "+hash.Hex())
+	}
+
+	return events, nil
+}
+
+// findInstantiated returns the contract instantiated by the extrinsic. The extrinsic index 0 means it is unknown, then
+// the first contract of the deployer is returned.
+func findInstantiated(events *chainevents.EventRecords, extrinsicIndex uint32, deployer *types.AccountID) (types.AccountID, bool) {
+	for _, e := range events.Contracts_Instantiated {
+		if extrinsicIndex > 0 && (!e.Phase.IsApplyExtrinsic || e.Phase.AsApplyExtrinsic != extrinsicIndex) {
+			continue
+		}
+		if e.Deployer.Equal(deployer) {
+			return e.Contract, true
+		}
+	}
+
+	return types.AccountID{}, false
+}
+
+// findCodeStored returns the hash of the code stored by the extrinsic or its dispatch error. The upload of a code
+// already stored succeeds without storing it again, then ok is false. The extrinsic index 0 means it is unknown, then
+// the first code stored in the block is returned.
+func findCodeStored(events *chainevents.EventRecords, extrinsicIndex uint32) (types.Hash, bool, error) {
+	if extrinsicIndex > 0 {
+		if err := extrinsicFailure(events, extrinsicIndex); err != nil {
+			return types.Hash{}, false, err
+		}
+	}
+	for _, e := range events.Contracts_CodeStored {
+		if extrinsicIndex > 0 && (!e.Phase.IsApplyExtrinsic || e.Phase.AsApplyExtrinsic != extrinsicIndex) {
+			continue
+		}
+		return e.CodeHash, true, nil
+	}
+
+	return types.Hash{}, false, nil
+}
+
+// callHasField tells whether the call of the pallet has the argument, to follow the changes of the call signatures
+// between runtime versions.
+func callHasField(meta *types.Metadata, palletName string, callName string, fieldName string) (bool, error) {
+	if meta.Version < 14 {
+		return false, nil
+	}
+
+	lookup := meta.AsMetadataV14.EfficientLookup
+	for _, pallet := range meta.AsMetadataV14.Pallets {
+		if string(pallet.Name) != palletName || !pallet.HasCalls {
+			continue
+		}
+
+		calls, ok := lookup[pallet.Calls.Type.Int64()]
+		if !ok {
+			return false, errors.Errorf("%s calls type not found", palletName)
+		}
+		for _, variant := range calls.Def.Variant.Variants {
+			if string(variant.Name) != callName {
+				continue
+			}
+			for _, field := range variant.Fields {
+				if string(field.Name) == fieldName {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	}
+
+	return false, errors.Errorf("%s.%s not found in runtime metadata", palletName, callName)
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/chainevents"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeHash(t *testing.T) {
+	//when
+	hash := CodeHash([]byte{})
+
+	//then
+	assert.Equal(t, "0x0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8", hash.Hex())
+}
+
+func TestFindInstantiated(t *testing.T) {
+	//given
+	deployer := types.AccountID{1}
+	other := types.AccountID{2}
+	inExtrinsic := func(index uint32) chainevents.Phase {
+		return chainevents.Phase{IsApplyExtrinsic: true, AsApplyExtrinsic: index}
+	}
+	events := &chainevents.EventRecords{Contracts_Instantiated: []chainevents.EventContractsInstantiated{
+		{Phase: inExtrinsic(1), Deployer: deployer, Contract: types.AccountID{10}},
+		{Phase: inExtrinsic(2), Deployer: other, Contract: types.AccountID{20}},
+		{Phase: inExtrinsic(3), Deployer: deployer, Contract: types.AccountID{30}},
+	}}
+
+	//when
+	byIndex, okByIndex := findInstantiated(events, 3, &deployer)
+	byDeployer, okByDeployer := findInstantiated(events, 0, &deployer)
+	_, okOther := findInstantiated(events, 3, &other)
+
+	//then
+	assert.True(t, okByIndex)
+	assert.Equal(t, types.AccountID{30}, byIndex)
+	assert.True(t, okByDeployer)
+	assert.Equal(t, types.AccountID{10}, byDeployer)
+	assert.False(t, okOther)
+}
+
+func TestFindCodeStored(t *testing.T) {
+	//given
+	inExtrinsic := func(index uint32) chainevents.Phase {
+		return chainevents.Phase{IsApplyExtrinsic: true, AsApplyExtrinsic: index}
+	}
+	events := &chainevents.EventRecords{
+		Contracts_CodeStored: []chainevents.EventContractsCodeStored{
+			{Phase: inExtrinsic(1), CodeHash: types.Hash{10}},
+			{Phase: inExtrinsic(2), CodeHash: types.Hash{20}},
+		},
+		System_ExtrinsicFailed: []chainevents.EventSystemExtrinsicFailed{
+			{Phase: inExtrinsic(3), DispatchError: types.DispatchError{IsModule: true, ModuleError: types.ModuleError{Index: 8, Error: [4]types.U8{20}}}},
+		},
+	}
+
+	//when
+	stored, okStored, errStored := findCodeStored(events, 2)
+	_, _, errFailed := findCodeStored(events, 3)
+	_, okExisting, errExisting := findCodeStored(events, 4)
+
+	//then
+	assert.NoError(t, errStored)
+	assert.True(t, okStored)
+	assert.Equal(t, types.Hash{20}, stored)
+	assert.ErrorIs(t, errFailed, ErrExtrinsicFailed)
+	assert.EqualError(t, errFailed, "module 8 error [20 0 0 0]: extrinsic failed")
+	assert.NoError(t, errExisting)
+	assert.False(t, okExisting)
+}
+
+func TestCallHasField(t *testing.T) {
+	//given
+	meta := &types.Metadata{Version: 14}
+	meta.AsMetadataV14.Pallets = []types.PalletMetadataV14{
+		{Name: "Contracts", HasCalls: true, Calls: types.FunctionMetadataV14{Type: lookupId(1)}},
+	}
+	meta.AsMetadataV14.EfficientLookup = map[int64]*types.Si1Type{
+		1: {Def: types.Si1TypeDef{IsVariant: true, Variant: types.Si1TypeDefVariant{Variants: []types.Si1Variant{
+			{Name: "upload_code", Fields: []types.Si1Field{
+				{HasName: true, Name: "code", Type: lookupId(2)},
+				{HasName: true, Name: "storage_deposit_limit", Type: lookupId(3)},
+				{HasName: true, Name: "determinism", Type: lookupId(4)},
+			}},
+		}}}},
+	}
+
+	//when
+	determinism, errDeterminism := callHasField(meta, "Contracts", "upload_code", "determinism")
+	salt, errSalt := callHasField(meta, "Contracts", "upload_code", "salt")
+	_, errMissing := callHasField(meta, "Contracts", "instantiate", "salt")
+
+	//then
+	assert.NoError(t, errDeterminism)
+	assert.True(t, determinism)
+	assert.NoError(t, errSalt)
+	assert.False(t, salt)
+	assert.Error(t, errMissing)
+}
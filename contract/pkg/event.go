@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// ContractEventHandlers fans a contract event out to any number of handlers, which can be added and removed while
+	// the events are dispatched. Use its Handle method as the handler of the dispatch entry.
+	ContractEventHandlers struct {
+		mutex    sync.RWMutex
+		handlers []registeredHandler
+		nextId   uint64
+	}
+
+	registeredHandler struct {
+		id      uint64
+		handler ContractEventHandler
+	}
+
+	// ContractEventContext tells where a contract event comes from. The block hash and the event index identify the
+	// event, the block number and the event index order the events.
+	ContractEventContext struct {
+		BlockHash   types.Hash
+		BlockNumber types.BlockNumber
+		// ExtrinsicIndex is the position in the block of the extrinsic calling the contract.
+		ExtrinsicIndex uint32
+		// EventIndex is the position of the event among all the events of the block.
+		EventIndex uint32
+		Contract   types.AccountID
+		// Data is the SCALE encoded event, starting with the index of the event in the contract.
+		Data []byte
+	}
+
+	// ContractEventError reports an event which couldn't be decoded or was rejected by a handler.
+	ContractEventError struct {
+		ContractEventContext
+		Event string
+		Err   error
+	}
+
+	ContractEventErrorHandler func(err *ContractEventError)
+
+	// handlerErrors are the errors returned by several handlers of the same event.
+	handlerErrors []error
+)
+
+var ErrContractEventDecoding = errors.New("cannot decode contract event")
+
+// WithContractEventErrorHandler sets the callback of the events failed to decode or handle, they are logged by default.
+func WithContractEventErrorHandler(handler ContractEventErrorHandler) ClientOption {
+	return func(b *blockchainClient) {
+		b.eventErrorHandler = handler
+	}
+}
+
+// Add registers the handler and returns the function removing it.
+func (h *ContractEventHandlers) Add(handler ContractEventHandler) (unregister func()) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	id := h.nextId
+	h.nextId++
+	h.handlers = append(h.handlers, registeredHandler{id: id, handler: handler})
+
+	return func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+
+		for i, registered := range h.handlers {
+			if registered.id == id {
+				h.handlers = append(h.handlers[:i:i], h.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Handle passes the event to all the handlers in the order of registration, a failing handler doesn't stop the rest.
+func (h *ContractEventHandlers) Handle(event ContractEventContext, args interface{}) error {
+	h.mutex.RLock()
+	handlers := h.handlers
+	h.mutex.RUnlock()
+
+	var errs handlerErrors
+	for _, registered := range handlers {
+		if err := registered.handler(event, args); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errs
+	}
+}
+
+func (e *ContractEventError) Error() string {
+	return fmt.Sprintf("contract %x event %s in block %s: %v", e.Contract[:], e.Event, e.BlockHash.Hex(), e.Err)
+}
+
+func (e *ContractEventError) Unwrap() error {
+	return e.Err
+}
+
+func (e handlerErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d handlers failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e handlerErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func logContractEventError(err *ContractEventError) {
+	log.WithError(err.Err).WithField("block", err.BlockHash.Hex()).WithField("eventIndex", err.EventIndex).
+		WithField("event", err.Event).Errorf("Contract event failed, data %x", err.Data)
+}
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractEventHandlers(t *testing.T) {
+	//given
+	handlers := &ContractEventHandlers{}
+	var calls []string
+	handlers.Add(func(event ContractEventContext, args interface{}) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	unregister := handlers.Add(func(event ContractEventContext, args interface{}) error {
+		calls = append(calls, "second")
+		return nil
+	})
+	handlers.Add(func(event ContractEventContext, args interface{}) error {
+		calls = append(calls, "third")
+		return nil
+	})
+
+	//when
+	errBefore := handlers.Handle(ContractEventContext{}, nil)
+	unregister()
+	unregister()
+	errAfter := handlers.Handle(ContractEventContext{}, nil)
+
+	//then
+	assert.NoError(t, errBefore)
+	assert.NoError(t, errAfter)
+	assert.Equal(t, []string{"first", "second", "third", "first", "third"}, calls)
+}
+
+func TestContractEventHandlersErrors(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	tests := []struct {
+		name     string
+		errs     []error
+		wantErrs []error
+	}{
+		{name: "No error", errs: []error{nil, nil}},
+		{name: "Single error", errs: []error{nil, errFirst}, wantErrs: []error{errFirst}},
+		{name: "Several errors", errs: []error{errFirst, errSecond}, wantErrs: []error{errFirst, errSecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			handlers := &ContractEventHandlers{}
+			called := 0
+			for _, err := range tt.errs {
+				err := err
+				handlers.Add(func(event ContractEventContext, args interface{}) error {
+					called++
+					return err
+				})
+			}
+
+			//when
+			err := handlers.Handle(ContractEventContext{}, nil)
+
+			//then
+			assert.Equal(t, len(tt.errs), called)
+			if tt.wantErrs == nil {
+				assert.NoError(t, err)
+			}
+			for _, wantErr := range tt.wantErrs {
+				assert.ErrorIs(t, err, wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"math/bits"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/chainevents"
+	"github.com/pkg/errors"
+)
+
+const (
+	WaitInBlock WaitPolicy = iota
+	WaitFinalized
+)
+
+const (
+	DEFAULT_MORTALITY      = 64
+	DEFAULT_SUBMIT_TIMEOUT = 5 * time.Minute
+)
+
+type (
+	// WaitPolicy defines when a submitted extrinsic is considered done.
+	WaitPolicy uint8
+
+	ExtrinsicResult struct {
+		ExtrinsicHash types.Hash
+		BlockHash     types.Hash
+		BlockNumber   types.BlockNumber
+		// ExtrinsicIndex is the position of the extrinsic in the block, 0 if it couldn't be resolved as the first
+		// extrinsic of a block is always the timestamp inherent.
+		ExtrinsicIndex uint32
+		Finalized      bool
+	}
+)
+
+var (
+	ErrExtrinsicDropped         = errors.New("extrinsic dropped from the pool")
+	ErrExtrinsicInvalid         = errors.New("extrinsic is invalid")
+	ErrExtrinsicUsurped         = errors.New("extrinsic usurped by another one with the same nonce")
+	ErrExtrinsicFinalityTimeout = errors.New("extrinsic block was not finalized in time")
+	ErrExtrinsicFailed          = errors.New("extrinsic failed")
+	// ErrExtrinsicIndexUnknown is returned when the extrinsic was included but couldn't be located in its block, so its
+	// outcome is unknown.
+	ErrExtrinsicIndexUnknown = errors.New("extrinsic index in the block is unknown")
+)
+
+// WithMortality makes extrinsics valid for the period of blocks starting from the finalized head, 0 makes them immortal.
+func WithMortality(period uint64) ClientOption {
+	return func(b *blockchainClient) {
+		b.mortality = period
+	}
+}
+
+func WithWaitPolicy(policy WaitPolicy) ClientOption {
+	return func(b *blockchainClient) {
+		b.waitPolicy = policy
+	}
+}
+
+// WithSubmitTimeout limits the time to wait for an extrinsic to reach the wait policy state, 0 disables the limit.
+func WithSubmitTimeout(timeout time.Duration) ClientOption {
+	return func(b *blockchainClient) {
+		b.submitTimeout = timeout
+	}
+}
+
+// mortalEra encodes the era of a transaction valid for period blocks since the block current, following
+// `Era::mortal` of Substrate.
+func mortalEra(period uint64, current uint64) types.ExtrinsicEra {
+	if period < 4 {
+		period = 4
+	}
+	if period > 1<<16 {
+		period = 1 << 16
+	}
+	if bits.OnesCount64(period) != 1 {
+		period = 1 << bits.Len64(period)
+	}
+
+	phase := current % period
+	quantizeFactor := period >> 12
+	if quantizeFactor < 1 {
+		quantizeFactor = 1
+	}
+	quantizedPhase := phase / quantizeFactor * quantizeFactor
+
+	low := uint64(bits.TrailingZeros64(period)) - 1
+	if low < 1 {
+		low = 1
+	}
+	if low > 15 {
+		low = 15
+	}
+	encoded := uint16(low) | uint16(quantizedPhase/quantizeFactor)<<4
+
+	return types.ExtrinsicEra{
+		IsMortalEra: true,
+		AsMortalEra: types.MortalEra{First: byte(encoded), Second: byte(encoded >> 8)},
+	}
+}
+
+// checkStatus tells whether the extrinsic reached the state required by the wait policy and returns the block it is
+// included in. Statuses the extrinsic can't recover from are returned as errors.
+func checkStatus(status types.ExtrinsicStatus, policy WaitPolicy) (blockHash types.Hash, done bool, err error) {
+	switch {
+	case status.IsInBlock:
+		return status.AsInBlock, policy == WaitInBlock, nil
+	case status.IsFinalized:
+		return status.AsFinalized, true, nil
+	case status.IsFinalityTimeout:
+		return types.Hash{}, false, errors.Wrapf(ErrExtrinsicFinalityTimeout, "block %s", status.AsFinalityTimeout.Hex())
+	case status.IsUsurped:
+		return types.Hash{}, false, errors.Wrapf(ErrExtrinsicUsurped, "by %s", status.AsUsurped.Hex())
+	case status.IsDropped:
+		return types.Hash{}, false, ErrExtrinsicDropped
+	case status.IsInvalid:
+		return types.Hash{}, false, ErrExtrinsicInvalid
+	}
+
+	// Future, Ready, Broadcast and Retracted are intermediate, the extrinsic is still in the pool.
+	return types.Hash{}, false, nil
+}
+
+// extrinsicFailure returns the dispatch error of the extrinsic wrapped in ErrExtrinsicFailed if it failed.
+func extrinsicFailure(events *chainevents.EventRecords, extrinsicIndex uint32) error {
+	for _, e := range events.System_ExtrinsicFailed {
+		if e.Phase.IsApplyExtrinsic && e.Phase.AsApplyExtrinsic == extrinsicIndex {
+			return errors.Wrap(ErrExtrinsicFailed, dispatchError(e.DispatchError).Error())
+		}
+	}
+
+	return nil
+}
+
+// dispatchError describes the error of a dispatched call, the module errors are identified by the index of the
+// pallet and of the error.
+func dispatchError(e types.DispatchError) error {
+	switch {
+	case e.IsModule:
+		return errors.Errorf("module %d error %v", e.ModuleError.Index, e.ModuleError.Error)
+	case e.IsCannotLookup:
+		return errors.New("cannot lookup")
+	case e.IsBadOrigin:
+		return errors.New("bad origin")
+	case e.IsConsumerRemaining:
+		return errors.New("consumer remaining")
+	case e.IsNoProviders:
+		return errors.New("no providers")
+	case e.IsTooManyConsumers:
+		return errors.New("too many consumers")
+	case e.IsToken:
+		return errors.Errorf("token error %+v", e.TokenError)
+	case e.IsArithmetic:
+		return errors.Errorf("arithmetic error %+v", e.ArithmeticError)
+	case e.IsTransactional:
+		return errors.Errorf("transactional error %+v", e.TransactionalError)
+	default:
+		return errors.New("other dispatch error")
+	}
+}
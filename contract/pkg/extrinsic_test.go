@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/chainevents"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMortalEra(t *testing.T) {
+	tests := []struct {
+		name    string
+		period  uint64
+		current uint64
+		want    []byte
+	}{
+		{name: "Power of two period", period: 64, current: 42, want: []byte{0xa5, 0x02}},
+		{name: "Period rounded up", period: 50, current: 42, want: []byte{0xa5, 0x02}},
+		{name: "Phase wraps", period: 64, current: 1000, want: []byte{0x85, 0x02}},
+		{name: "Short period", period: 1, current: 7, want: []byte{0x31, 0x00}},
+		{name: "Long period quantized", period: 1 << 20, current: 65535, want: []byte{0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			era := mortalEra(tt.period, tt.current)
+
+			//then
+			encoded, err := codec.Encode(era)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, encoded)
+		})
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	block := types.NewHash([]byte{0x01})
+	tests := []struct {
+		name      string
+		status    types.ExtrinsicStatus
+		policy    WaitPolicy
+		wantBlock types.Hash
+		wantDone  bool
+		wantErr   error
+	}{
+		{name: "Ready", status: types.ExtrinsicStatus{IsReady: true}, policy: WaitInBlock},
+		{name: "Broadcast", status: types.ExtrinsicStatus{IsBroadcast: true}, policy: WaitInBlock},
+		{name: "In block", status: types.ExtrinsicStatus{IsInBlock: true, AsInBlock: block}, policy: WaitInBlock, wantBlock: block, wantDone: true},
+		{name: "In block waiting finality", status: types.ExtrinsicStatus{IsInBlock: true, AsInBlock: block}, policy: WaitFinalized, wantBlock: block},
+		{name: "Retracted", status: types.ExtrinsicStatus{IsRetracted: true, AsRetracted: block}, policy: WaitFinalized},
+		{name: "Finalized", status: types.ExtrinsicStatus{IsFinalized: true, AsFinalized: block}, policy: WaitFinalized, wantBlock: block, wantDone: true},
+		{name: "Finality timeout", status: types.ExtrinsicStatus{IsFinalityTimeout: true}, policy: WaitFinalized, wantErr: ErrExtrinsicFinalityTimeout},
+		{name: "Usurped", status: types.ExtrinsicStatus{IsUsurped: true}, policy: WaitInBlock, wantErr: ErrExtrinsicUsurped},
+		{name: "Dropped", status: types.ExtrinsicStatus{IsDropped: true}, policy: WaitInBlock, wantErr: ErrExtrinsicDropped},
+		{name: "Invalid", status: types.ExtrinsicStatus{IsInvalid: true}, policy: WaitInBlock, wantErr: ErrExtrinsicInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//when
+			blockHash, done, err := checkStatus(tt.status, tt.policy)
+
+			//then
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantDone, done)
+			assert.Equal(t, tt.wantBlock, blockHash)
+		})
+	}
+}
+
+func TestExtrinsicFailure(t *testing.T) {
+	//given
+	events := &chainevents.EventRecords{
+		System_ExtrinsicFailed: []chainevents.EventSystemExtrinsicFailed{
+			{Phase: chainevents.Phase{IsApplyExtrinsic: true, AsApplyExtrinsic: 2}, DispatchError: types.DispatchError{IsModule: true, ModuleError: types.ModuleError{Index: 8, Error: [4]types.U8{11}}}},
+		},
+	}
+
+	//when
+	errFailed := extrinsicFailure(events, 2)
+	errSucceeded := extrinsicFailure(events, 3)
+
+	//then
+	assert.ErrorIs(t, errFailed, ErrExtrinsicFailed)
+	assert.EqualError(t, errFailed, "module 8 error [11 0 0 0]: extrinsic failed")
+	assert.NoError(t, errSucceeded)
+}
@@ -219,8 +219,8 @@ func (d *ddcBucketContractMock) GetContractAddress() string {
 	return "mock_ddc_bucket"
 }
 
-func (d *ddcBucketContractMock) AddContractEventHandler(event string, handler func(interface{})) error {
-	return nil
+func (d *ddcBucketContractMock) AddContractEventHandler(event string, handler pkg.ContractEventHandler) (func(), error) {
+	return func() {}, nil
 }
 
 func CreateBucket(bucketId bucket.BucketId, clusterId uint32, bucketParams string, writerIds []types.AccountID) *bucket.BucketInfo {
@@ -500,3 +500,7 @@ func (d *ddcBucketContractMock) BucketRevokeReaderPerm(ctx context.Context, keyP
 	//TODO implement me
 	panic("implement me")
 }
+
+func (d *ddcBucketContractMock) Reader(ctx context.Context, options ...pkg.ReadOption) bucket.DdcBucketContractReader {
+	return d
+}
@@ -0,0 +1,1597 @@
+package mock
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	SUPER_ADMIN_PERMISSION       = "SuperAdmin"
+	SET_EXCHANGE_RATE_PERMISSION = "SetExchangeRate"
+	VALIDATOR_PERMISSION         = "Validator"
+
+	DEFAULT_BONDING_PERIOD = 7 * 24 * time.Hour
+	MS_PER_MONTH           = bucket.MS_PER_MONTH
+	// PARAMS_MAX_LEN is the size limit of the params of clusters, nodes and buckets.
+	PARAMS_MAX_LEN = 100_000
+)
+
+// The indexes of the Permission enum of the contract, sent with the permission events.
+const (
+	trustedManagerPermissionIndex byte = iota
+	setExchangeRatePermissionIndex
+	superAdminPermissionIndex
+	validatorPermissionIndex
+)
+
+var permissionIndexes = map[string]byte{
+	SUPER_ADMIN_PERMISSION:       superAdminPermissionIndex,
+	SET_EXCHANGE_RATE_PERMISSION: setExchangeRatePermissionIndex,
+	VALIDATOR_PERMISSION:         validatorPermissionIndex,
+}
+
+type (
+	SimulatorOption func(*ddcBucketContractSimulator)
+
+	transferredValueKey struct{}
+
+	// ddcBucketContractSimulator keeps the state of the DDC bucket contract in memory. Each successful call is a block
+	// of its own, a failed call changes nothing like a reverted contract call.
+	ddcBucketContractSimulator struct {
+		// events is a contract without a chain client keeping the event handlers.
+		events          bucket.DdcBucketContract
+		contractAddress types.AccountID
+		now             func() time.Time
+		bondingPeriod   time.Duration
+
+		mutex          sync.Mutex
+		lastAccessTime time.Time
+		blockNumber    types.BlockNumber
+		eventQueue     []simulatedEvent
+		dispatching    bool
+
+		permissions   map[bucket.AccountId]map[string]bool
+		trustedBy     map[bucket.AccountId]map[bucket.AccountId]bool
+		usdPerCere    bucket.Balance
+		accounts      map[bucket.AccountId]*bucket.Account
+		accountIds    []bucket.AccountId
+		buckets       []*simulatedBucket
+		clusters      map[bucket.ClusterId]*simulatedCluster
+		clusterIds    []bucket.ClusterId
+		nextClusterId bucket.ClusterId
+		nodes         map[bucket.NodeKey]*bucket.Node
+		nodeKeys      []bucket.NodeKey
+		cdnNodes      map[bucket.CdnNodeKey]*bucket.CdnNode
+		cdnNodeKeys   []bucket.CdnNodeKey
+	}
+
+	simulatedBucket struct {
+		info bucket.BucketInfo
+		// rate is the rent per millisecond the owner pays to the cluster, paid until paidUntilMs.
+		rate        *big.Int
+		paidUntilMs uint64
+	}
+
+	simulatedCluster struct {
+		cluster    bucket.Cluster
+		vNodes     map[bucket.Token]bucket.NodeKey
+		nodeVNodes map[bucket.NodeKey][]bucket.Token
+	}
+
+	transaction struct {
+		caller bucket.AccountId
+		value  *big.Int
+		nowMs  uint64
+		events []emittedEvent
+	}
+
+	emittedEvent struct {
+		eventId string
+		args    interface{}
+	}
+
+	simulatedEvent struct {
+		emittedEvent
+		context pkg.ContractEventContext
+	}
+)
+
+// WithTransferredValue sets the value transferred to the payable calls of the simulator made with the context:
+// AccountDeposit, AccountBond and BucketCreate.
+func WithTransferredValue(ctx context.Context, value bucket.Balance) context.Context {
+	return context.WithValue(ctx, transferredValueKey{}, value)
+}
+
+// WithClock sets the time source of the rent and the bonding period.
+func WithClock(now func() time.Time) SimulatorOption {
+	return func(s *ddcBucketContractSimulator) {
+		s.now = now
+	}
+}
+
+// WithBondingPeriod sets the time after unbonding before the unbonded amount can be withdrawn.
+func WithBondingPeriod(period time.Duration) SimulatorOption {
+	return func(s *ddcBucketContractSimulator) {
+		s.bondingPeriod = period
+	}
+}
+
+// CreateDdcBucketContractSimulator creates an in-memory DDC bucket contract instantiated by the super admin. It
+// enforces the rules of the contract, returns its errors and dispatches its events to the handlers added with
+// AddContractEventHandler, so services can be tested end to end without a chain.
+func CreateDdcBucketContractSimulator(contractAddressSS58 string, superAdmin bucket.AccountId, options ...SimulatorOption) (bucket.DdcBucketContract, error) {
+	contractAddress, err := pkg.DecodeAccountIDFromSS58(contractAddressSS58)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ddcBucketContractSimulator{
+		events:          bucket.CreateDdcBucketContract(nil, contractAddressSS58),
+		contractAddress: contractAddress,
+		now:             time.Now,
+		bondingPeriod:   DEFAULT_BONDING_PERIOD,
+		permissions:     map[bucket.AccountId]map[string]bool{superAdmin: {SUPER_ADMIN_PERMISSION: true}},
+		trustedBy:       make(map[bucket.AccountId]map[bucket.AccountId]bool),
+		usdPerCere:      zero(),
+		accounts:        make(map[bucket.AccountId]*bucket.Account),
+		clusters:        make(map[bucket.ClusterId]*simulatedCluster),
+		nextClusterId:   1,
+		nodes:           make(map[bucket.NodeKey]*bucket.Node),
+		cdnNodes:        make(map[bucket.CdnNodeKey]*bucket.CdnNode),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.lastAccessTime = s.now()
+
+	return s, nil
+}
+
+func (s *ddcBucketContractSimulator) GetContractAddress() string {
+	return s.events.GetContractAddress()
+}
+
+func (s *ddcBucketContractSimulator) GetLastAccessTime() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lastAccessTime
+}
+
+// Reader returns the simulator itself, it keeps no history so the reads are always at the latest state.
+func (s *ddcBucketContractSimulator) Reader(ctx context.Context, options ...pkg.ReadOption) bucket.DdcBucketContractReader {
+	return s
+}
+
+func (s *ddcBucketContractSimulator) AddContractEventHandler(event string, handler pkg.ContractEventHandler) (func(), error) {
+	return s.events.AddContractEventHandler(event, handler)
+}
+
+func (s *ddcBucketContractSimulator) GetEventDispatcher() map[types.Hash]pkg.ContractEventDispatchEntry {
+	return s.events.GetEventDispatcher()
+}
+
+// exec runs the call in a block of its own. The state is changed and the events are emitted only if the call
+// succeeds, so the calls must check all their conditions before changing anything.
+func (s *ddcBucketContractSimulator) exec(ctx context.Context, keyPair signature.KeyringPair, call func(tx *transaction) error) (types.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return types.Hash{}, err
+	}
+	caller, err := types.NewAccountID(keyPair.PublicKey)
+	if err != nil {
+		return types.Hash{}, err
+	}
+
+	tx := &transaction{caller: *caller, value: big.NewInt(0)}
+	if value, ok := ctx.Value(transferredValueKey{}).(bucket.Balance); ok {
+		tx.value = balance(value)
+	}
+
+	s.mutex.Lock()
+	tx.nowMs = uint64(s.now().UnixMilli())
+	if err := call(tx); err != nil {
+		s.mutex.Unlock()
+		return types.Hash{}, err
+	}
+
+	s.blockNumber++
+	var number [4]byte
+	binary.LittleEndian.PutUint32(number[:], uint32(s.blockNumber))
+	blockHash := types.Hash(blake2b.Sum256(number[:]))
+	for i, event := range tx.events {
+		s.eventQueue = append(s.eventQueue, simulatedEvent{
+			emittedEvent: event,
+			context: pkg.ContractEventContext{
+				BlockHash:      blockHash,
+				BlockNumber:    s.blockNumber,
+				ExtrinsicIndex: 1,
+				EventIndex:     uint32(i),
+				Contract:       s.contractAddress,
+			},
+		})
+	}
+	s.lastAccessTime = s.now()
+	s.mutex.Unlock()
+
+	s.dispatchEvents()
+
+	return blockHash, nil
+}
+
+// dispatchEvents passes the queued events to the handlers in the order of the calls. The handlers run without the
+// lock, they may read the simulator or make calls, whose events are dispatched once the current ones are.
+func (s *ddcBucketContractSimulator) dispatchEvents() {
+	s.mutex.Lock()
+	if s.dispatching {
+		s.mutex.Unlock()
+		return
+	}
+	s.dispatching = true
+
+	dispatcher := s.events.GetEventDispatcher()
+	for len(s.eventQueue) > 0 {
+		event := s.eventQueue[0]
+		s.eventQueue = s.eventQueue[1:]
+		s.mutex.Unlock()
+
+		eventKey, err := types.NewHashFromHexString(event.eventId)
+		if err == nil {
+			if entry, ok := dispatcher[eventKey]; ok {
+				err = entry.Handler(event.context, event.args)
+			}
+		}
+		if err != nil {
+			log.WithError(err).WithField("block", event.context.BlockNumber).WithField("event", event.eventId).
+				Error("Simulated contract event failed")
+		}
+
+		s.mutex.Lock()
+	}
+
+	s.dispatching = false
+	s.mutex.Unlock()
+}
+
+func (t *transaction) emit(eventId string, args interface{}) {
+	t.events = append(t.events, emittedEvent{eventId: eventId, args: args})
+}
+
+func (s *ddcBucketContractSimulator) read() func() {
+	s.mutex.Lock()
+	s.lastAccessTime = s.now()
+	return s.mutex.Unlock
+}
+
+// Permissions
+
+func (s *ddcBucketContractSimulator) HasPermission(account bucket.AccountId, permission string) (bool, error) {
+	defer s.read()()
+
+	return s.permissions[account][permission], nil
+}
+
+// isAllowed tells whether the account has the permission, which the super admin has implicitly.
+func (s *ddcBucketContractSimulator) isAllowed(account bucket.AccountId, permission string) bool {
+	return s.permissions[account][permission] || s.permissions[account][SUPER_ADMIN_PERMISSION]
+}
+
+func (s *ddcBucketContractSimulator) GrantTrustedManagerPermission(ctx context.Context, keyPair signature.KeyringPair, managerId bucket.AccountId) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		if s.trustedBy[managerId] == nil {
+			s.trustedBy[managerId] = make(map[bucket.AccountId]bool)
+		}
+		s.trustedBy[managerId][tx.caller] = true
+		tx.emit(bucket.GrantPermissionEventId, &bucket.GrantPermissionEvent{AccountId: managerId, Permission: trustedManagerPermissionIndex})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) RevokeTrustedManagerPermission(ctx context.Context, keyPair signature.KeyringPair, managerId bucket.AccountId) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		delete(s.trustedBy[managerId], tx.caller)
+		tx.emit(bucket.RevokePermissionEventId, &bucket.RevokePermissionEvent{AccountId: managerId, Permission: trustedManagerPermissionIndex})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AdminGrantPermission(ctx context.Context, keyPair signature.KeyringPair, grantee bucket.AccountId, permission string) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		index, err := s.checkAdminPermission(tx.caller, permission)
+		if err != nil {
+			return err
+		}
+		if s.permissions[grantee] == nil {
+			s.permissions[grantee] = make(map[string]bool)
+		}
+		s.permissions[grantee][permission] = true
+		tx.emit(bucket.GrantPermissionEventId, &bucket.GrantPermissionEvent{AccountId: grantee, Permission: index})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AdminRevokePermission(ctx context.Context, keyPair signature.KeyringPair, grantee bucket.AccountId, permission string) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		index, err := s.checkAdminPermission(tx.caller, permission)
+		if err != nil {
+			return err
+		}
+		delete(s.permissions[grantee], permission)
+		tx.emit(bucket.RevokePermissionEventId, &bucket.RevokePermissionEvent{AccountId: grantee, Permission: index})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) checkAdminPermission(caller bucket.AccountId, permission string) (byte, error) {
+	if !s.permissions[caller][SUPER_ADMIN_PERMISSION] {
+		return 0, bucket.ErrOnlySuperAdmin
+	}
+	index, ok := permissionIndexes[permission]
+	if !ok {
+		return 0, fmt.Errorf("unknown permission %s", permission)
+	}
+
+	return index, nil
+}
+
+func (s *ddcBucketContractSimulator) AdminTransferNodeOwnership(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.NodeKey, newOwner bucket.AccountId) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		if !s.permissions[tx.caller][SUPER_ADMIN_PERMISSION] {
+			return bucket.ErrOnlySuperAdmin
+		}
+		node, err := s.node(nodeKey)
+		if err != nil {
+			return err
+		}
+		if !s.permissions[node.ProviderId][SUPER_ADMIN_PERMISSION] {
+			return bucket.ErrNodeProviderIsNotSuperAdmin
+		}
+		node.ProviderId = newOwner
+		tx.emit(bucket.NodeOwnershipTransferredEventId, &bucket.NodeOwnershipTransferredEvent{AccountId: newOwner, NodeKey: nodeKey})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AdminTransferCdnNodeOwnership(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.CdnNodeKey, newOwner bucket.AccountId) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		if !s.permissions[tx.caller][SUPER_ADMIN_PERMISSION] {
+			return bucket.ErrOnlySuperAdmin
+		}
+		node, err := s.cdnNode(nodeKey)
+		if err != nil {
+			return err
+		}
+		if !s.permissions[node.ProviderId][SUPER_ADMIN_PERMISSION] {
+			return bucket.ErrCdnNodeOwnerIsNotSuperAdmin
+		}
+		node.ProviderId = newOwner
+		tx.emit(bucket.CdnNodeOwnershipTransferredEventId, &bucket.CdnNodeOwnershipTransferredEvent{AccountId: newOwner, CdnNodeKey: nodeKey})
+		return nil
+	})
+	return err
+}
+
+// Nodes
+
+func (s *ddcBucketContractSimulator) node(nodeKey bucket.NodeKey) (*bucket.Node, error) {
+	node, ok := s.nodes[nodeKey]
+	if !ok {
+		return nil, bucket.ErrNodeDoesNotExist
+	}
+
+	return node, nil
+}
+
+func (s *ddcBucketContractSimulator) NodeCreate(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.NodeKey, params bucket.Params, capacity bucket.Resource, rent bucket.Rent) (types.Hash, error) {
+	if err := bucket.ValidateNodeParams(params); err != nil {
+		return types.Hash{}, err
+	}
+	return s.exec(ctx, keyPair, func(tx *transaction) error {
+		if _, ok := s.nodes[nodeKey]; ok {
+			return bucket.ErrNodeAlreadyExists
+		}
+		if err := checkParams(params); err != nil {
+			return err
+		}
+
+		s.nodes[nodeKey] = &bucket.Node{
+			ProviderId:    tx.caller,
+			RentPerMonth:  types.NewU128(*balance(rent)),
+			FreeResources: capacity,
+			Params:        params,
+		}
+		s.nodeKeys = append(s.nodeKeys, nodeKey)
+		tx.emit(bucket.NodeCreatedEventId, &bucket.NodeCreatedEvent{NodeKey: nodeKey, ProviderId: tx.caller, RentPerMonth: rent, NodeParams: params})
+		return nil
+	})
+}
+
+func (s *ddcBucketContractSimulator) NodeRemove(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.NodeKey) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		node, err := s.node(nodeKey)
+		if err != nil {
+			return err
+		}
+		if node.ProviderId != tx.caller {
+			return bucket.ErrOnlyNodeProvider
+		}
+		if hasCluster, _ := node.ClusterId.Unwrap(); hasCluster {
+			return bucket.ErrNodeIsAddedToCluster
+		}
+
+		delete(s.nodes, nodeKey)
+		s.nodeKeys = removeKey(s.nodeKeys, nodeKey)
+		tx.emit(bucket.NodeRemovedEventId, &bucket.NodeRemovedEvent{NodeKey: nodeKey})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) NodeSetParams(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.NodeKey, params bucket.Params) error {
+	if err := bucket.ValidateNodeParams(params); err != nil {
+		return err
+	}
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		node, err := s.node(nodeKey)
+		if err != nil {
+			return err
+		}
+		if node.ProviderId != tx.caller {
+			return bucket.ErrOnlyNodeProvider
+		}
+		if err := checkParams(params); err != nil {
+			return err
+		}
+
+		node.Params = params
+		tx.emit(bucket.NodeParamsSetEventId, &bucket.NodeParamsSetEvent{NodeKey: nodeKey, NodeParams: params})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) NodeGet(nodeKey bucket.NodeKey) (*bucket.NodeInfo, error) {
+	defer s.read()()
+
+	node, err := s.node(nodeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.nodeInfo(nodeKey, node), nil
+}
+
+func (s *ddcBucketContractSimulator) NodeList(offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*bucket.NodeListInfo, error) {
+	defer s.read()()
+
+	result := &bucket.NodeListInfo{Total: types.U32(len(s.nodeKeys))}
+	for _, nodeKey := range page(s.nodeKeys, offset, limit) {
+		node := s.nodes[nodeKey]
+		if matches(filterProviderId, node.ProviderId) {
+			result.Nodes = append(result.Nodes, *s.nodeInfo(nodeKey, node))
+		}
+	}
+
+	return result, nil
+}
+
+func (s *ddcBucketContractSimulator) nodeInfo(nodeKey bucket.NodeKey, node *bucket.Node) *bucket.NodeInfo {
+	info := &bucket.NodeInfo{Key: nodeKey, Node: *node}
+	info.Node.RentPerMonth = types.NewU128(*balance(node.RentPerMonth))
+	if hasCluster, clusterId := node.ClusterId.Unwrap(); hasCluster {
+		info.VNodes = append([]bucket.Token{}, s.clusters[bucket.ClusterId(clusterId)].nodeVNodes[nodeKey]...)
+	}
+
+	return info
+}
+
+func (s *ddcBucketContractSimulator) cdnNode(nodeKey bucket.CdnNodeKey) (*bucket.CdnNode, error) {
+	node, ok := s.cdnNodes[nodeKey]
+	if !ok {
+		return nil, bucket.ErrCdnNodeDoesNotExist
+	}
+
+	return node, nil
+}
+
+func (s *ddcBucketContractSimulator) CdnNodeCreate(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.CdnNodeKey, params bucket.CDNNodeParams) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		if _, ok := s.cdnNodes[nodeKey]; ok {
+			return bucket.ErrCdnNodeAlreadyExists
+		}
+		encoded, err := encodeCdnNodeParams(params)
+		if err != nil {
+			return err
+		}
+
+		s.cdnNodes[nodeKey] = &bucket.CdnNode{
+			ProviderId:           tx.caller,
+			UndistributedPayment: zero(),
+			Params:               encoded,
+		}
+		s.cdnNodeKeys = append(s.cdnNodeKeys, nodeKey)
+		tx.emit(bucket.CdnNodeCreatedEventId, &bucket.CdnNodeCreatedEvent{CdnNodeKey: nodeKey, AccountId: tx.caller, Payment: zero()})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) CdnNodeRemove(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.CdnNodeKey) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		node, err := s.cdnNode(nodeKey)
+		if err != nil {
+			return err
+		}
+		if node.ProviderId != tx.caller {
+			return bucket.ErrOnlyCdnNodeProvider
+		}
+		if hasCluster, _ := node.ClusterId.Unwrap(); hasCluster {
+			return bucket.ErrCdnNodeIsAddedToCluster
+		}
+
+		delete(s.cdnNodes, nodeKey)
+		s.cdnNodeKeys = removeKey(s.cdnNodeKeys, nodeKey)
+		tx.emit(bucket.CdnNodeRemovedEventId, &bucket.CdnNodeRemovedEvent{CdnNodeKey: nodeKey})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) CdnNodeSetParams(ctx context.Context, keyPair signature.KeyringPair, nodeKey bucket.CdnNodeKey, params bucket.CDNNodeParams) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		node, err := s.cdnNode(nodeKey)
+		if err != nil {
+			return err
+		}
+		if node.ProviderId != tx.caller {
+			return bucket.ErrOnlyCdnNodeProvider
+		}
+		encoded, err := encodeCdnNodeParams(params)
+		if err != nil {
+			return err
+		}
+
+		node.Params = encoded
+		tx.emit(bucket.CdnNodeParamsSetEventId, &bucket.CdnNodeParamsSetEvent{CdnNodeKey: nodeKey, CdnNodeParams: encoded})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) CdnNodeGet(nodeKey bucket.CdnNodeKey) (*bucket.CdnNodeInfo, error) {
+	defer s.read()()
+
+	node, err := s.cdnNode(nodeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bucket.CdnNodeInfo{Key: nodeKey, Node: *node}, nil
+}
+
+func (s *ddcBucketContractSimulator) CdnNodeList(offset types.U32, limit types.U32, filterProviderId types.OptionAccountID) (*bucket.CdnNodeListInfo, error) {
+	defer s.read()()
+
+	result := &bucket.CdnNodeListInfo{Total: types.U32(len(s.cdnNodeKeys))}
+	for _, nodeKey := range page(s.cdnNodeKeys, offset, limit) {
+		node := s.cdnNodes[nodeKey]
+		if matches(filterProviderId, node.ProviderId) {
+			result.Nodes = append(result.Nodes, bucket.CdnNodeInfo{Key: nodeKey, Node: *node})
+		}
+	}
+
+	return result, nil
+}
+
+// Clusters
+
+func (s *ddcBucketContractSimulator) cluster(clusterId bucket.ClusterId) (*simulatedCluster, error) {
+	cluster, ok := s.clusters[clusterId]
+	if !ok {
+		return nil, bucket.ErrClusterDoesNotExist
+	}
+
+	return cluster, nil
+}
+
+func (s *ddcBucketContractSimulator) managedCluster(clusterId bucket.ClusterId, caller bucket.AccountId) (*simulatedCluster, error) {
+	cluster, err := s.cluster(clusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.cluster.ManagerId != caller {
+		return nil, bucket.ErrOnlyClusterManager
+	}
+
+	return cluster, nil
+}
+
+// clusterNode returns the node added to the cluster.
+func (s *ddcBucketContractSimulator) clusterNode(clusterId bucket.ClusterId, nodeKey bucket.NodeKey) (*bucket.Node, error) {
+	node, err := s.node(nodeKey)
+	if err != nil {
+		return nil, err
+	}
+	if hasCluster, nodeClusterId := node.ClusterId.Unwrap(); !hasCluster || bucket.ClusterId(nodeClusterId) != clusterId {
+		return nil, bucket.ErrNodeIsNotAddedToCluster
+	}
+
+	return node, nil
+}
+
+func (s *ddcBucketContractSimulator) ClusterCreate(ctx context.Context, keyPair signature.KeyringPair, params bucket.Params, resourcePerVNode bucket.Resource) (types.Hash, error) {
+	if err := bucket.ValidateClusterParams(params); err != nil {
+		return types.Hash{}, err
+	}
+	return s.exec(ctx, keyPair, func(tx *transaction) error {
+		if err := checkParams(params); err != nil {
+			return err
+		}
+
+		clusterId := s.nextClusterId
+		s.nextClusterId++
+		s.clusters[clusterId] = &simulatedCluster{
+			cluster: bucket.Cluster{
+				ManagerId:        tx.caller,
+				Params:           params,
+				ResourcePerVNode: resourcePerVNode,
+				Revenues:         zero(),
+				TotalRent:        zero(),
+				CdnRevenues:      zero(),
+				CdnUsdPerGb:      zero(),
+			},
+			vNodes:     make(map[bucket.Token]bucket.NodeKey),
+			nodeVNodes: make(map[bucket.NodeKey][]bucket.Token),
+		}
+		s.clusterIds = append(s.clusterIds, clusterId)
+		tx.emit(bucket.ClusterCreatedEventId, &bucket.ClusterCreatedEvent{ClusterId: clusterId, AccountId: tx.caller, ClusterParams: params})
+		return nil
+	})
+}
+
+func (s *ddcBucketContractSimulator) ClusterAddNode(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, nodeKey bucket.NodeKey, vNodes [][]bucket.Token) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.managedCluster(clusterId, tx.caller)
+		if err != nil {
+			return err
+		}
+		node, err := s.node(nodeKey)
+		if err != nil {
+			return err
+		}
+		if !s.trustedBy[tx.caller][node.ProviderId] {
+			return bucket.ErrOnlyTrustedClusterManager
+		}
+		if hasCluster, _ := node.ClusterId.Unwrap(); hasCluster {
+			return bucket.ErrNodeIsAddedToCluster
+		}
+		tokens, err := cluster.freeVNodes(vNodes, nodeKey)
+		if err != nil {
+			return err
+		}
+		required := uint64(cluster.cluster.ResourcePerVNode) * uint64(len(tokens))
+		if required > uint64(node.FreeResources) {
+			return bucket.ErrInsufficientNodeResources
+		}
+
+		node.FreeResources -= bucket.Resource(required)
+		node.ClusterId = types.NewOptionU32(types.U32(clusterId))
+		node.StatusInCluster = types.NewOptionU8(bucket.ADDING)
+		cluster.cluster.NodesKeys = append(cluster.cluster.NodesKeys, nodeKey)
+		cluster.cluster.TotalRent = types.NewU128(*new(big.Int).Add(balance(cluster.cluster.TotalRent), balance(node.RentPerMonth)))
+		cluster.assign(nodeKey, tokens)
+		tx.emit(bucket.ClusterNodeAddedEventId, &bucket.ClusterNodeAddedEvent{ClusterId: clusterId, NodeKey: nodeKey, VNodes: tokens})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterRemoveNode(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, nodeKey bucket.NodeKey) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.cluster(clusterId)
+		if err != nil {
+			return err
+		}
+		node, err := s.node(nodeKey)
+		if err != nil {
+			return err
+		}
+		if cluster.cluster.ManagerId != tx.caller && node.ProviderId != tx.caller {
+			return bucket.ErrOnlyClusterManagerOrNodeProvider
+		}
+		if _, err := s.clusterNode(clusterId, nodeKey); err != nil {
+			return err
+		}
+
+		tokens := cluster.nodeVNodes[nodeKey]
+		node.FreeResources += cluster.cluster.ResourcePerVNode * bucket.Resource(len(tokens))
+		node.ClusterId = types.OptionU32{}
+		node.StatusInCluster = types.OptionU8{}
+		cluster.unassign(nodeKey, tokens)
+		delete(cluster.nodeVNodes, nodeKey)
+		cluster.cluster.NodesKeys = removeKey(cluster.cluster.NodesKeys, nodeKey)
+		cluster.cluster.TotalRent = types.NewU128(*new(big.Int).Sub(balance(cluster.cluster.TotalRent), balance(node.RentPerMonth)))
+		tx.emit(bucket.ClusterNodeRemovedEventId, &bucket.ClusterNodeRemovedEvent{ClusterId: clusterId, NodeKey: nodeKey})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterResetNode(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, nodeKey bucket.NodeKey, vNodes [][]bucket.Token) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.managedCluster(clusterId, tx.caller)
+		if err != nil {
+			return err
+		}
+		node, err := s.clusterNode(clusterId, nodeKey)
+		if err != nil {
+			return err
+		}
+		tokens, err := cluster.freeVNodes(vNodes, nodeKey)
+		if err != nil {
+			return err
+		}
+		current := cluster.nodeVNodes[nodeKey]
+		free := uint64(node.FreeResources) + uint64(cluster.cluster.ResourcePerVNode)*uint64(len(current))
+		required := uint64(cluster.cluster.ResourcePerVNode) * uint64(len(tokens))
+		if required > free {
+			return bucket.ErrInsufficientNodeResources
+		}
+
+		node.FreeResources = bucket.Resource(free - required)
+		cluster.unassign(nodeKey, current)
+		delete(cluster.nodeVNodes, nodeKey)
+		cluster.assign(nodeKey, tokens)
+		tx.emit(bucket.ClusterNodeResetEventId, &bucket.ClusterNodeResetEvent{ClusterId: clusterId, NodeKey: nodeKey, VNodes: tokens})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterReplaceNode(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, vNodes [][]bucket.Token, newNodeKey bucket.NodeKey) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.managedCluster(clusterId, tx.caller)
+		if err != nil {
+			return err
+		}
+		newNode, err := s.clusterNode(clusterId, newNodeKey)
+		if err != nil {
+			return err
+		}
+
+		tokens := flatten(vNodes)
+		if len(tokens) == 0 {
+			return bucket.ErrAtLeastOneVNodeHasToBeAssigned
+		}
+		moved := make(map[bucket.NodeKey]int)
+		seen := make(map[bucket.Token]bool, len(tokens))
+		for _, token := range tokens {
+			nodeKey, ok := cluster.vNodes[token]
+			if !ok {
+				return bucket.ErrVNodeDoesNotExistsInCluster
+			}
+			if seen[token] {
+				return bucket.ErrVNodeIsAlreadyAssignedToNode
+			}
+			seen[token] = true
+			if nodeKey != newNodeKey {
+				moved[nodeKey]++
+			}
+		}
+		total := 0
+		for nodeKey, count := range moved {
+			if count >= len(cluster.nodeVNodes[nodeKey]) {
+				return bucket.ErrAtLeastOneVNodeHasToBeAssigned
+			}
+			total += count
+		}
+		required := uint64(cluster.cluster.ResourcePerVNode) * uint64(total)
+		if required > uint64(newNode.FreeResources) {
+			return bucket.ErrInsufficientNodeResources
+		}
+
+		for nodeKey, count := range moved {
+			s.nodes[nodeKey].FreeResources += cluster.cluster.ResourcePerVNode * bucket.Resource(count)
+		}
+		newNode.FreeResources -= bucket.Resource(required)
+		for _, token := range tokens {
+			if nodeKey := cluster.vNodes[token]; nodeKey != newNodeKey {
+				cluster.unassign(nodeKey, []bucket.Token{token})
+				cluster.assign(newNodeKey, []bucket.Token{token})
+			}
+		}
+		tx.emit(bucket.ClusterNodeReplacedEventId, &bucket.ClusterNodeReplacedEvent{ClusterId: clusterId, NodeKey: newNodeKey, VNodes: tokens})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterAddCdnNode(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, nodeKey bucket.CdnNodeKey) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.managedCluster(clusterId, tx.caller)
+		if err != nil {
+			return err
+		}
+		node, err := s.cdnNode(nodeKey)
+		if err != nil {
+			return err
+		}
+		if !s.trustedBy[tx.caller][node.ProviderId] {
+			return bucket.ErrOnlyTrustedClusterManager
+		}
+		if hasCluster, _ := node.ClusterId.Unwrap(); hasCluster {
+			return bucket.ErrCdnNodeIsAddedToCluster
+		}
+
+		node.ClusterId = types.NewOptionU32(types.U32(clusterId))
+		node.StatusInCluster = types.NewOptionU8(bucket.ADDING)
+		cluster.cluster.CdnNodesKeys = append(cluster.cluster.CdnNodesKeys, nodeKey)
+		tx.emit(bucket.ClusterCdnNodeAddedEventId, &bucket.ClusterCdnNodeAddedEvent{ClusterId: clusterId, CdnNodeKey: nodeKey})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterRemoveCdnNode(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, nodeKey bucket.CdnNodeKey) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.cluster(clusterId)
+		if err != nil {
+			return err
+		}
+		node, err := s.cdnNode(nodeKey)
+		if err != nil {
+			return err
+		}
+		if cluster.cluster.ManagerId != tx.caller && node.ProviderId != tx.caller {
+			return bucket.ErrOnlyClusterManagerOrCdnNodeProvider
+		}
+		if hasCluster, nodeClusterId := node.ClusterId.Unwrap(); !hasCluster || bucket.ClusterId(nodeClusterId) != clusterId {
+			return bucket.ErrCdnNodeIsNotAddedToCluster
+		}
+
+		node.ClusterId = types.OptionU32{}
+		node.StatusInCluster = types.OptionU8{}
+		cluster.cluster.CdnNodesKeys = removeKey(cluster.cluster.CdnNodesKeys, nodeKey)
+		tx.emit(bucket.ClusterCdnNodeRemovedEventId, &bucket.ClusterCdnNodeRemovedEvent{ClusterId: clusterId, CdnNodeKey: nodeKey})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterSetParams(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, params bucket.Params) error {
+	if err := bucket.ValidateClusterParams(params); err != nil {
+		return err
+	}
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.managedCluster(clusterId, tx.caller)
+		if err != nil {
+			return err
+		}
+		if err := checkParams(params); err != nil {
+			return err
+		}
+
+		cluster.cluster.Params = params
+		event := &bucket.ClusterParamsSetEvent{ClusterId: clusterId}
+		_ = json.Unmarshal([]byte(params), &event.ClusterParams)
+		tx.emit(bucket.ClusterParamsSetEventId, event)
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterRemove(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		cluster, err := s.managedCluster(clusterId, tx.caller)
+		if err != nil {
+			return err
+		}
+		if len(cluster.cluster.NodesKeys) > 0 || len(cluster.cluster.CdnNodesKeys) > 0 {
+			return bucket.ErrClusterIsNotEmpty
+		}
+
+		delete(s.clusters, clusterId)
+		s.clusterIds = removeKey(s.clusterIds, clusterId)
+		tx.emit(bucket.ClusterRemovedEventId, &bucket.ClusterRemovedEvent{ClusterId: clusterId})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterSetNodeStatus(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, nodeKey bucket.NodeKey, statusInCluster string) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		status, ok := bucket.NodeStatusesInClusterMap[statusInCluster]
+		if !ok {
+			return fmt.Errorf("unknown node status %s", statusInCluster)
+		}
+		if _, err := s.managedCluster(clusterId, tx.caller); err != nil {
+			return err
+		}
+		node, err := s.clusterNode(clusterId, nodeKey)
+		if err != nil {
+			return err
+		}
+
+		node.StatusInCluster = types.NewOptionU8(types.U8(status))
+		tx.emit(bucket.ClusterNodeStatusSetEventId, &bucket.ClusterNodeStatusSetEvent{ClusterId: clusterId, NodeKey: nodeKey, NodeStatusInCluster: status})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterSetCdnNodeStatus(ctx context.Context, keyPair signature.KeyringPair, clusterId bucket.ClusterId, nodeKey bucket.CdnNodeKey, statusInCluster string) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		status, ok := bucket.NodeStatusesInClusterMap[statusInCluster]
+		if !ok {
+			return fmt.Errorf("unknown node status %s", statusInCluster)
+		}
+		if _, err := s.managedCluster(clusterId, tx.caller); err != nil {
+			return err
+		}
+		node, err := s.cdnNode(nodeKey)
+		if err != nil {
+			return err
+		}
+		if hasCluster, nodeClusterId := node.ClusterId.Unwrap(); !hasCluster || bucket.ClusterId(nodeClusterId) != clusterId {
+			return bucket.ErrCdnNodeIsNotAddedToCluster
+		}
+
+		node.StatusInCluster = types.NewOptionU8(types.U8(status))
+		tx.emit(bucket.ClusterCdnNodeStatusSetEventId, &bucket.ClusterCdnNodeStatusSetEvent{CdnNodeKey: nodeKey, ClusterId: clusterId, NodeStatusInCluster: status})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) ClusterGet(clusterId bucket.ClusterId) (*bucket.ClusterInfo, error) {
+	defer s.read()()
+
+	cluster, err := s.cluster(clusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	return cluster.info(clusterId), nil
+}
+
+func (s *ddcBucketContractSimulator) ClusterList(offset types.U32, limit types.U32, filterManagerId types.OptionAccountID) (*bucket.ClusterListInfo, error) {
+	defer s.read()()
+
+	result := &bucket.ClusterListInfo{Total: types.U32(len(s.clusterIds))}
+	for _, clusterId := range page(s.clusterIds, offset, limit) {
+		cluster := s.clusters[clusterId]
+		if matches(filterManagerId, cluster.cluster.ManagerId) {
+			result.Clusters = append(result.Clusters, *cluster.info(clusterId))
+		}
+	}
+
+	return result, nil
+}
+
+func (c *simulatedCluster) info(clusterId bucket.ClusterId) *bucket.ClusterInfo {
+	info := &bucket.ClusterInfo{ClusterId: clusterId, Cluster: c.cluster}
+	info.Cluster.NodesKeys = append([]bucket.NodeKey{}, c.cluster.NodesKeys...)
+	info.Cluster.CdnNodesKeys = append([]bucket.CdnNodeKey{}, c.cluster.CdnNodesKeys...)
+	for _, nodeKey := range c.cluster.NodesKeys {
+		info.NodesVNodes = append(info.NodesVNodes, bucket.NodeVNodesInfo{
+			NodeKey: nodeKey,
+			VNodes:  append([]bucket.Token{}, c.nodeVNodes[nodeKey]...),
+		})
+	}
+
+	return info
+}
+
+// freeVNodes flattens the vnodes and checks none of them is assigned to a node other than nodeKey.
+func (c *simulatedCluster) freeVNodes(vNodes [][]bucket.Token, nodeKey bucket.NodeKey) ([]bucket.Token, error) {
+	tokens := flatten(vNodes)
+	if len(tokens) == 0 {
+		return nil, bucket.ErrAtLeastOneVNodeHasToBeAssigned
+	}
+
+	seen := make(map[bucket.Token]bool, len(tokens))
+	for _, token := range tokens {
+		if owner, ok := c.vNodes[token]; (ok && owner != nodeKey) || seen[token] {
+			return nil, bucket.ErrVNodeIsAlreadyAssignedToNode
+		}
+		seen[token] = true
+	}
+
+	return tokens, nil
+}
+
+func (c *simulatedCluster) assign(nodeKey bucket.NodeKey, tokens []bucket.Token) {
+	for _, token := range tokens {
+		c.vNodes[token] = nodeKey
+	}
+	c.nodeVNodes[nodeKey] = append(c.nodeVNodes[nodeKey], tokens...)
+}
+
+func (c *simulatedCluster) unassign(nodeKey bucket.NodeKey, tokens []bucket.Token) {
+	for _, token := range tokens {
+		delete(c.vNodes, token)
+		c.nodeVNodes[nodeKey] = removeKey(c.nodeVNodes[nodeKey], token)
+	}
+}
+
+// Buckets
+
+func (s *ddcBucketContractSimulator) bucket(bucketId bucket.BucketId) (*simulatedBucket, error) {
+	if bucketId == 0 || int(bucketId) > len(s.buckets) {
+		return nil, bucket.ErrBucketDoesNotExist
+	}
+
+	return s.buckets[bucketId-1], nil
+}
+
+func (s *ddcBucketContractSimulator) ownedBucket(bucketId bucket.BucketId, caller bucket.AccountId) (*simulatedBucket, error) {
+	b, err := s.bucket(bucketId)
+	if err != nil {
+		return nil, err
+	}
+	if b.info.Bucket.OwnerId != caller {
+		return nil, bucket.ErrOnlyOwner
+	}
+
+	return b, nil
+}
+
+func (s *ddcBucketContractSimulator) BucketCreate(ctx context.Context, keyPair signature.KeyringPair, bucketParams bucket.BucketParams, clusterId bucket.ClusterId, ownerId types.OptionAccountID) (types.Hash, error) {
+	if err := bucket.ValidateBucketParams(bucketParams); err != nil {
+		return types.Hash{}, err
+	}
+	return s.exec(ctx, keyPair, func(tx *transaction) error {
+		if err := checkParams(bucketParams); err != nil {
+			return err
+		}
+		if _, err := s.cluster(clusterId); err != nil {
+			return err
+		}
+		owner := tx.caller
+		if hasOwner, id := ownerId.Unwrap(); hasOwner {
+			owner = id
+		}
+
+		s.deposit(tx)
+		s.ensureAccount(owner)
+		bucketId := bucket.BucketId(len(s.buckets) + 1)
+		s.buckets = append(s.buckets, &simulatedBucket{
+			info: bucket.BucketInfo{
+				BucketId: bucketId,
+				Bucket: bucket.Bucket{
+					OwnerId:           owner,
+					ClusterId:         clusterId,
+					GasConsumptionCap: math.MaxUint32,
+				},
+				Params: bucketParams,
+			},
+			rate:        big.NewInt(0),
+			paidUntilMs: tx.nowMs,
+		})
+		tx.emit(bucket.BucketCreatedEventId, &bucket.BucketCreatedEvent{BucketId: bucketId, AccountId: owner})
+		return nil
+	})
+}
+
+func (s *ddcBucketContractSimulator) BucketChangeOwner(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, ownerId bucket.AccountId) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		b, err := s.ownedBucket(bucketId, tx.caller)
+		if err != nil {
+			return err
+		}
+
+		// The rent due so far is paid by the former owner.
+		s.settle(b, tx.nowMs)
+		s.ensureAccount(ownerId)
+		b.info.Bucket.OwnerId = ownerId
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) BucketAllocIntoCluster(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, resource bucket.Resource) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		b, err := s.ownedBucket(bucketId, tx.caller)
+		if err != nil {
+			return err
+		}
+		cluster, err := s.cluster(b.info.Bucket.ClusterId)
+		if err != nil {
+			return err
+		}
+		if uint64(cluster.cluster.ResourceUsed)+uint64(resource) > uint64(cluster.cluster.ResourcePerVNode) {
+			return bucket.ErrInsufficientClusterResources
+		}
+
+		s.settle(b, tx.nowMs)
+		cluster.cluster.ResourceUsed += resource
+		b.info.Bucket.ResourceReserved += resource
+		b.rate = new(big.Int).Mul(balance(cluster.cluster.TotalRent), big.NewInt(int64(b.info.Bucket.ResourceReserved)))
+		b.rate.Div(b.rate, big.NewInt(MS_PER_MONTH))
+		tx.emit(bucket.BucketAllocatedEventId, &bucket.BucketAllocatedEvent{BucketId: bucketId, ClusterId: b.info.Bucket.ClusterId, Resource: resource})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) BucketSettlePayment(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		b, err := s.bucket(bucketId)
+		if err != nil {
+			return err
+		}
+		if _, err := s.cluster(b.info.Bucket.ClusterId); err != nil {
+			return err
+		}
+
+		s.settle(b, tx.nowMs)
+		tx.emit(bucket.BucketSettlePaymentEventId, &bucket.BucketSettlePaymentEvent{BucketId: bucketId, ClusterId: b.info.Bucket.ClusterId})
+		return nil
+	})
+	return err
+}
+
+// settle pays the rent due since the last payment from the bonded balance of the owner to the cluster, the part
+// the balance doesn't cover becomes a debt of the owner.
+func (s *ddcBucketContractSimulator) settle(b *simulatedBucket, nowMs uint64) {
+	due := b.due(nowMs)
+	b.paidUntilMs = nowMs
+	if due.Sign() == 0 {
+		return
+	}
+
+	account := s.ensureAccount(b.info.Bucket.OwnerId)
+	bonded := balance(account.Bonded)
+	paid := due
+	if bonded.Cmp(due) < 0 {
+		paid = bonded
+		account.Negative = types.NewU128(*new(big.Int).Add(balance(account.Negative), new(big.Int).Sub(due, bonded)))
+	}
+	account.Bonded = types.NewU128(*new(big.Int).Sub(bonded, paid))
+	if cluster, ok := s.clusters[b.info.Bucket.ClusterId]; ok {
+		cluster.cluster.Revenues = types.NewU128(*new(big.Int).Add(balance(cluster.cluster.Revenues), paid))
+	}
+}
+
+func (b *simulatedBucket) due(nowMs uint64) *big.Int {
+	if nowMs <= b.paidUntilMs {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Mul(b.rate, new(big.Int).SetUint64(nowMs-b.paidUntilMs))
+}
+
+func (s *ddcBucketContractSimulator) BucketChangeParams(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, bucketParams bucket.BucketParams) error {
+	if err := bucket.ValidateBucketParams(bucketParams); err != nil {
+		return err
+	}
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		b, err := s.ownedBucket(bucketId, tx.caller)
+		if err != nil {
+			return err
+		}
+		if err := checkParams(bucketParams); err != nil {
+			return err
+		}
+
+		b.info.Params = bucketParams
+		tx.emit(bucket.BucketParamsSetEventId, &bucket.BucketParamsSetEvent{BucketId: bucketId, BucketParams: bucketParams})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) BucketSetAvailability(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, publicAvailability bool) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		b, err := s.ownedBucket(bucketId, tx.caller)
+		if err != nil {
+			return err
+		}
+
+		b.info.Bucket.PublicAvailability = publicAvailability
+		tx.emit(bucket.BucketAvailabilityUpdatedId, &bucket.BucketAvailabilityUpdatedEvent{BucketId: bucketId, PublicAvailability: publicAvailability})
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) BucketSetResourceCap(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, newResourceCap bucket.Resource) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		b, err := s.ownedBucket(bucketId, tx.caller)
+		if err != nil {
+			return err
+		}
+
+		b.info.Bucket.GasConsumptionCap = newResourceCap
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) GetBucketWriters(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId) ([]bucket.AccountId, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer s.read()()
+
+	b, err := s.bucket(bucketId)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]bucket.AccountId{}, b.info.WriterIds...), nil
+}
+
+func (s *ddcBucketContractSimulator) GetBucketReaders(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId) ([]bucket.AccountId, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer s.read()()
+
+	b, err := s.bucket(bucketId)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]bucket.AccountId{}, b.info.ReaderIds...), nil
+}
+
+func (s *ddcBucketContractSimulator) BucketSetWriterPerm(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, writer bucket.AccountId) error {
+	return s.changeAccess(ctx, keyPair, bucketId, func(b *bucket.BucketInfo) {
+		b.WriterIds = addKey(b.WriterIds, writer)
+	})
+}
+
+func (s *ddcBucketContractSimulator) BucketRevokeWriterPerm(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, writer bucket.AccountId) error {
+	return s.changeAccess(ctx, keyPair, bucketId, func(b *bucket.BucketInfo) {
+		b.WriterIds = removeKey(b.WriterIds, writer)
+	})
+}
+
+func (s *ddcBucketContractSimulator) BucketSetReaderPerm(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, reader bucket.AccountId) error {
+	return s.changeAccess(ctx, keyPair, bucketId, func(b *bucket.BucketInfo) {
+		b.ReaderIds = addKey(b.ReaderIds, reader)
+	})
+}
+
+func (s *ddcBucketContractSimulator) BucketRevokeReaderPerm(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, reader bucket.AccountId) error {
+	return s.changeAccess(ctx, keyPair, bucketId, func(b *bucket.BucketInfo) {
+		b.ReaderIds = removeKey(b.ReaderIds, reader)
+	})
+}
+
+func (s *ddcBucketContractSimulator) changeAccess(ctx context.Context, keyPair signature.KeyringPair, bucketId bucket.BucketId, change func(b *bucket.BucketInfo)) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		b, err := s.ownedBucket(bucketId, tx.caller)
+		if err != nil {
+			return err
+		}
+
+		change(&b.info)
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) BucketGet(bucketId bucket.BucketId) (*bucket.BucketInfo, error) {
+	defer s.read()()
+
+	b, err := s.bucket(bucketId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.bucketInfo(b), nil
+}
+
+func (s *ddcBucketContractSimulator) BucketList(offset types.U32, limit types.U32, filterOwnerId types.OptionAccountID) (*bucket.BucketListInfo, error) {
+	defer s.read()()
+
+	result := &bucket.BucketListInfo{Total: types.U32(len(s.buckets))}
+	for _, b := range page(s.buckets, offset, limit) {
+		if matches(filterOwnerId, b.info.Bucket.OwnerId) {
+			result.Buckets = append(result.Buckets, *s.bucketInfo(b))
+		}
+	}
+
+	return result, nil
+}
+
+func (s *ddcBucketContractSimulator) BucketListForAccount(ownerId bucket.AccountId) ([]bucket.Bucket, error) {
+	defer s.read()()
+
+	var result []bucket.Bucket
+	for _, b := range s.buckets {
+		if b.info.Bucket.OwnerId == ownerId {
+			result = append(result, b.info.Bucket)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *ddcBucketContractSimulator) bucketInfo(b *simulatedBucket) *bucket.BucketInfo {
+	info := b.info
+	info.WriterIds = append([]bucket.AccountId{}, b.info.WriterIds...)
+	info.ReaderIds = append([]bucket.AccountId{}, b.info.ReaderIds...)
+	info.RentCoveredUntilMs = s.rentCoveredUntil(b.info.Bucket.OwnerId)
+
+	return &info
+}
+
+// rentCoveredUntil is the time the bonded balance of the owner runs out paying the rent of all the owner's buckets.
+func (s *ddcBucketContractSimulator) rentCoveredUntil(ownerId bucket.AccountId) types.U64 {
+	nowMs := uint64(s.now().UnixMilli())
+	rate, due, _ := s.payable(ownerId, nowMs)
+	if rate.Sign() == 0 {
+		return math.MaxUint64
+	}
+
+	available := big.NewInt(0)
+	if account, ok := s.accounts[ownerId]; ok {
+		available.Sub(balance(account.Bonded), due)
+	}
+	if available.Sign() <= 0 {
+		return types.U64(nowMs)
+	}
+
+	covered := available.Div(available, rate)
+	if !covered.IsUint64() || covered.Uint64() > math.MaxUint64-nowMs {
+		return math.MaxUint64
+	}
+
+	return types.U64(nowMs + covered.Uint64())
+}
+
+// payable sums the rent rates of the owner's buckets, the rent due until nowMs and the schedule offset.
+func (s *ddcBucketContractSimulator) payable(ownerId bucket.AccountId, nowMs uint64) (rate *big.Int, due *big.Int, offset *big.Int) {
+	rate, due, offset = big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	for _, b := range s.buckets {
+		if b.info.Bucket.OwnerId != ownerId {
+			continue
+		}
+		rate.Add(rate, b.rate)
+		due.Add(due, b.due(nowMs))
+		offset.Add(offset, new(big.Int).Mul(b.rate, new(big.Int).SetUint64(b.paidUntilMs)))
+	}
+
+	return rate, due, offset
+}
+
+// Accounts
+
+func (s *ddcBucketContractSimulator) ensureAccount(accountId bucket.AccountId) *bucket.Account {
+	account, ok := s.accounts[accountId]
+	if !ok {
+		account = &bucket.Account{
+			Deposit:         zero(),
+			Bonded:          zero(),
+			Negative:        zero(),
+			UnboundedAmount: zero(),
+			PayableSchedule: bucket.Schedule{Rate: zero(), Offset: zero()},
+		}
+		s.accounts[accountId] = account
+		s.accountIds = append(s.accountIds, accountId)
+	}
+
+	return account
+}
+
+// deposit credits the value transferred to the call to the account of the caller.
+func (s *ddcBucketContractSimulator) deposit(tx *transaction) {
+	if tx.value.Sign() == 0 {
+		return
+	}
+
+	account := s.ensureAccount(tx.caller)
+	account.Deposit = types.NewU128(*new(big.Int).Add(balance(account.Deposit), tx.value))
+	tx.emit(bucket.DepositEventId, &bucket.DepositEvent{AccountId: tx.caller, Value: types.NewU128(*tx.value)})
+}
+
+func (s *ddcBucketContractSimulator) AccountDeposit(ctx context.Context, keyPair signature.KeyringPair) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		s.ensureAccount(tx.caller)
+		s.deposit(tx)
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AccountBond(ctx context.Context, keyPair signature.KeyringPair, bondAmount bucket.Balance) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		amount := balance(bondAmount)
+		deposit := new(big.Int).Set(tx.value)
+		if account, ok := s.accounts[tx.caller]; ok {
+			deposit.Add(deposit, balance(account.Deposit))
+		} else if tx.value.Sign() == 0 {
+			return bucket.ErrAccountDoesNotExist
+		}
+		if deposit.Cmp(amount) < 0 {
+			return bucket.ErrInsufficientBalance
+		}
+
+		s.deposit(tx)
+		account := s.ensureAccount(tx.caller)
+		account.Deposit = types.NewU128(*deposit.Sub(deposit, amount))
+		bonded := new(big.Int).Add(balance(account.Bonded), amount)
+		// The bonded amount pays off the debt first.
+		negative := balance(account.Negative)
+		if negative.Cmp(bonded) > 0 {
+			negative = bonded
+		}
+		account.Negative = types.NewU128(*new(big.Int).Sub(balance(account.Negative), negative))
+		account.Bonded = types.NewU128(*bonded.Sub(bonded, negative))
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AccountUnbond(ctx context.Context, keyPair signature.KeyringPair, bondAmount bucket.Cash) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		account, ok := s.accounts[tx.caller]
+		if !ok {
+			return bucket.ErrAccountDoesNotExist
+		}
+		amount := balance(bondAmount)
+		if balance(account.Bonded).Cmp(amount) < 0 {
+			return bucket.ErrInsufficientBalance
+		}
+
+		account.Bonded = types.NewU128(*new(big.Int).Sub(balance(account.Bonded), amount))
+		account.UnboundedAmount = types.NewU128(*new(big.Int).Add(balance(account.UnboundedAmount), amount))
+		account.UnbondedTimestamp = types.U64(tx.nowMs)
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AccountWithdrawUnbonded(ctx context.Context, keyPair signature.KeyringPair) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		account, ok := s.accounts[tx.caller]
+		if !ok {
+			return bucket.ErrAccountDoesNotExist
+		}
+		if tx.nowMs < uint64(account.UnbondedTimestamp)+uint64(s.bondingPeriod.Milliseconds()) {
+			return bucket.ErrBondingPeriodNotFinished
+		}
+
+		// The unbonded amount is transferred back to the caller.
+		account.UnboundedAmount = zero()
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AccountSetUsdPerCere(ctx context.Context, keyPair signature.KeyringPair, usdPerCere bucket.Balance) error {
+	_, err := s.exec(ctx, keyPair, func(tx *transaction) error {
+		if !s.isAllowed(tx.caller, SET_EXCHANGE_RATE_PERMISSION) {
+			return bucket.ErrUnauthorized
+		}
+
+		s.usdPerCere = types.NewU128(*balance(usdPerCere))
+		return nil
+	})
+	return err
+}
+
+func (s *ddcBucketContractSimulator) AccountGetUsdPerCere() (bucket.Balance, error) {
+	defer s.read()()
+
+	return types.NewU128(*balance(s.usdPerCere)), nil
+}
+
+func (s *ddcBucketContractSimulator) AccountGet(accountId bucket.AccountId) (*bucket.Account, error) {
+	defer s.read()()
+
+	account, ok := s.accounts[accountId]
+	if !ok {
+		return nil, bucket.ErrAccountDoesNotExist
+	}
+
+	result := *account
+	rate, _, offset := s.payable(accountId, uint64(s.now().UnixMilli()))
+	result.PayableSchedule = bucket.Schedule{Rate: types.NewU128(*rate), Offset: types.NewU128(*offset)}
+
+	return &result, nil
+}
+
+func (s *ddcBucketContractSimulator) GetAccounts() ([]bucket.AccountId, error) {
+	defer s.read()()
+
+	return append([]bucket.AccountId{}, s.accountIds...), nil
+}
+
+// Helpers
+
+func balance(value bucket.Balance) *big.Int {
+	if value.Int == nil {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Set(value.Int)
+}
+
+func zero() bucket.Balance {
+	return types.NewU128(*big.NewInt(0))
+}
+
+func checkParams(params bucket.Params) error {
+	if len(params) > PARAMS_MAX_LEN {
+		return bucket.ErrParamsSizeExceedsLimit
+	}
+
+	return nil
+}
+
+func encodeCdnNodeParams(params bucket.CDNNodeParams) (string, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), checkParams(string(encoded))
+}
+
+func flatten(vNodes [][]bucket.Token) []bucket.Token {
+	var tokens []bucket.Token
+	for _, group := range vNodes {
+		tokens = append(tokens, group...)
+	}
+
+	return tokens
+}
+
+func matches(filter types.OptionAccountID, accountId bucket.AccountId) bool {
+	hasFilter, filterId := filter.Unwrap()
+	return !hasFilter || filterId == accountId
+}
+
+// page returns the items of the listing in [offset, offset+limit), the filters of the contract apply within it.
+func page[T any](items []T, offset types.U32, limit types.U32) []T {
+	if int(offset) >= len(items) {
+		return nil
+	}
+	end := uint64(offset) + uint64(limit)
+	if end > uint64(len(items)) {
+		end = uint64(len(items))
+	}
+
+	return items[offset:end]
+}
+
+func addKey[T comparable](keys []T, key T) []T {
+	for _, k := range keys {
+		if k == key {
+			return keys
+		}
+	}
+
+	return append(keys, key)
+}
+
+func removeKey[T comparable](keys []T, key T) []T {
+	for i, k := range keys {
+		if k == key {
+			return append(keys[:i:i], keys[i+1:]...)
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,247 @@
+package mock
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const simulatorAddress = "5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL"
+
+type simulatorSetup struct {
+	contract bucket.DdcBucketContract
+	admin    signature.KeyringPair
+	manager  signature.KeyringPair
+	provider signature.KeyringPair
+	owner    signature.KeyringPair
+	now      time.Time
+}
+
+func keyPair(t *testing.T, uri string) signature.KeyringPair {
+	pair, err := signature.KeyringPairFromSecret(uri, 42)
+	require.NoError(t, err)
+	return pair
+}
+
+func accountId(pair signature.KeyringPair) bucket.AccountId {
+	id, _ := types.NewAccountID(pair.PublicKey)
+	return *id
+}
+
+func createSimulator(t *testing.T) *simulatorSetup {
+	setup := &simulatorSetup{
+		admin:    keyPair(t, "//Alice"),
+		manager:  keyPair(t, "//Bob"),
+		provider: keyPair(t, "//Charlie"),
+		owner:    keyPair(t, "//Dave"),
+		now:      time.UnixMilli(1_000_000),
+	}
+	contract, err := CreateDdcBucketContractSimulator(simulatorAddress, accountId(setup.admin), WithClock(func() time.Time {
+		return setup.now
+	}))
+	require.NoError(t, err)
+	setup.contract = contract
+
+	return setup
+}
+
+// createCluster creates a cluster of one node with the vnodes 1, 2 and 3.
+func (s *simulatorSetup) createCluster(t *testing.T, ctx context.Context) (bucket.ClusterId, bucket.NodeKey) {
+	nodeKey := bucket.NodeKey{1}
+	_, err := s.contract.NodeCreate(ctx, s.provider, nodeKey, `{"url":"http://node"}`, 100, types.NewU128(*big.NewInt(MS_PER_MONTH)))
+	require.NoError(t, err)
+	require.NoError(t, s.contract.GrantTrustedManagerPermission(ctx, s.provider, accountId(s.manager)))
+	_, err = s.contract.ClusterCreate(ctx, s.manager, `{"replicationFactor":1}`, 10)
+	require.NoError(t, err)
+	require.NoError(t, s.contract.ClusterAddNode(ctx, s.manager, 1, nodeKey, [][]bucket.Token{{1, 2, 3}}))
+
+	return 1, nodeKey
+}
+
+func TestSimulatorClusterNodes(t *testing.T) {
+	//given
+	ctx := context.Background()
+	setup := createSimulator(t)
+	contract := setup.contract
+	var added []bucket.ClusterNodeAddedEvent
+	_, err := contract.AddContractEventHandler(bucket.ClusterNodeAddedEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
+		added = append(added, *raw.(*bucket.ClusterNodeAddedEvent))
+		return nil
+	})
+	require.NoError(t, err)
+	otherKey := bucket.NodeKey{2}
+	_, err = contract.NodeCreate(ctx, setup.admin, otherKey, `{"url":"http://other-node"}`, 100, types.NewU128(*big.NewInt(1)))
+	require.NoError(t, err)
+
+	//when
+	clusterId, nodeKey := setup.createCluster(t, ctx)
+	errUntrusted := contract.ClusterAddNode(ctx, setup.manager, clusterId, otherKey, [][]bucket.Token{{4}})
+	require.NoError(t, contract.GrantTrustedManagerPermission(ctx, setup.admin, accountId(setup.manager)))
+	errTaken := contract.ClusterAddNode(ctx, setup.manager, clusterId, otherKey, [][]bucket.Token{{3, 4}})
+	errNotManager := contract.ClusterAddNode(ctx, setup.provider, clusterId, otherKey, [][]bucket.Token{{4}})
+	errAdd := contract.ClusterAddNode(ctx, setup.manager, clusterId, otherKey, [][]bucket.Token{{4}})
+	errReplace := contract.ClusterReplaceNode(ctx, setup.manager, clusterId, [][]bucket.Token{{3}}, otherKey)
+	errRemoveCluster := contract.ClusterRemove(ctx, setup.manager, clusterId)
+	errRemoveNode := contract.NodeRemove(ctx, setup.provider, nodeKey)
+	cluster, errGet := contract.ClusterGet(clusterId)
+	node, errNode := contract.NodeGet(otherKey)
+
+	//then
+	assert.ErrorIs(t, errUntrusted, bucket.ErrOnlyTrustedClusterManager)
+	assert.ErrorIs(t, errTaken, bucket.ErrVNodeIsAlreadyAssignedToNode)
+	assert.ErrorIs(t, errNotManager, bucket.ErrOnlyClusterManager)
+	assert.NoError(t, errAdd)
+	assert.NoError(t, errReplace)
+	assert.ErrorIs(t, errRemoveCluster, bucket.ErrClusterIsNotEmpty)
+	assert.ErrorIs(t, errRemoveNode, bucket.ErrNodeIsAddedToCluster)
+	assert.NoError(t, errGet)
+	assert.Equal(t, []bucket.NodeVNodesInfo{
+		{NodeKey: nodeKey, VNodes: []bucket.Token{1, 2}},
+		{NodeKey: otherKey, VNodes: []bucket.Token{4, 3}},
+	}, cluster.NodesVNodes)
+	assert.Equal(t, types.NewU128(*big.NewInt(MS_PER_MONTH + 1)), cluster.Cluster.TotalRent)
+	assert.NoError(t, errNode)
+	assert.Equal(t, bucket.Resource(80), node.Node.FreeResources)
+	assert.Equal(t, []bucket.ClusterNodeAddedEvent{
+		{ClusterId: clusterId, NodeKey: nodeKey, VNodes: []bucket.Token{1, 2, 3}},
+		{ClusterId: clusterId, NodeKey: otherKey, VNodes: []bucket.Token{4}},
+	}, added)
+}
+
+func TestSimulatorBucketRent(t *testing.T) {
+	//given
+	ctx := context.Background()
+	setup := createSimulator(t)
+	contract := setup.contract
+	clusterId, _ := setup.createCluster(t, ctx)
+	ownerId := accountId(setup.owner)
+	events, err := bucket.Subscribe[bucket.BucketCreatedEvent](ctx, contract)
+	require.NoError(t, err)
+
+	//when
+	errNoAccount := contract.AccountBond(ctx, setup.owner, types.NewU128(*big.NewInt(1)))
+	errBond := contract.AccountBond(WithTransferredValue(ctx, types.NewU128(*big.NewInt(1000))), setup.owner, types.NewU128(*big.NewInt(1000)))
+	_, errCreate := contract.BucketCreate(ctx, setup.owner, "{}", clusterId, types.OptionAccountID{})
+	errOverAlloc := contract.BucketAllocIntoCluster(ctx, setup.owner, 1, 11)
+	errAlloc := contract.BucketAllocIntoCluster(ctx, setup.owner, 1, 2)
+	errNotOwner := contract.BucketSetWriterPerm(ctx, setup.manager, 1, accountId(setup.manager))
+	errWriter := contract.BucketSetWriterPerm(ctx, setup.owner, 1, accountId(setup.manager))
+	setup.now = setup.now.Add(100 * time.Millisecond)
+	bucketInfo, errGet := contract.BucketGet(1)
+	errSettle := contract.BucketSettlePayment(ctx, setup.manager, 1)
+	account, errAccount := contract.AccountGet(ownerId)
+	cluster, errCluster := contract.ClusterGet(clusterId)
+	created := <-events
+
+	//then
+	assert.ErrorIs(t, errNoAccount, bucket.ErrAccountDoesNotExist)
+	assert.NoError(t, errBond)
+	assert.NoError(t, errCreate)
+	assert.ErrorIs(t, errOverAlloc, bucket.ErrInsufficientClusterResources)
+	assert.NoError(t, errAlloc)
+	assert.ErrorIs(t, errNotOwner, bucket.ErrOnlyOwner)
+	assert.NoError(t, errWriter)
+	assert.NoError(t, errGet)
+	assert.True(t, bucketInfo.HasWriteAccess(setup.manager.PublicKey))
+	assert.Equal(t, types.U64(setup.now.UnixMilli()+400), bucketInfo.RentCoveredUntilMs)
+	assert.NoError(t, errSettle)
+	assert.NoError(t, errAccount)
+	assert.Equal(t, types.NewU128(*big.NewInt(800)), account.Bonded)
+	assert.Equal(t, types.NewU128(*big.NewInt(2)), account.PayableSchedule.Rate)
+	assert.NoError(t, errCluster)
+	assert.Equal(t, types.NewU128(*big.NewInt(200)), cluster.Cluster.Revenues)
+	assert.Equal(t, bucket.Resource(2), cluster.Cluster.ResourceUsed)
+	assert.Equal(t, bucket.BucketCreatedEvent{BucketId: 1, AccountId: ownerId}, created.Args)
+}
+
+func TestSimulatorAccountUnbond(t *testing.T) {
+	//given
+	ctx := context.Background()
+	setup := createSimulator(t)
+	contract := setup.contract
+	require.NoError(t, contract.AccountDeposit(WithTransferredValue(ctx, types.NewU128(*big.NewInt(500))), setup.owner))
+	require.NoError(t, contract.AccountBond(ctx, setup.owner, types.NewU128(*big.NewInt(300))))
+
+	//when
+	errOverUnbond := contract.AccountUnbond(ctx, setup.owner, types.NewU128(*big.NewInt(301)))
+	errUnbond := contract.AccountUnbond(ctx, setup.owner, types.NewU128(*big.NewInt(100)))
+	errEarly := contract.AccountWithdrawUnbonded(ctx, setup.owner)
+	setup.now = setup.now.Add(DEFAULT_BONDING_PERIOD)
+	errWithdraw := contract.AccountWithdrawUnbonded(ctx, setup.owner)
+	account, errAccount := contract.AccountGet(accountId(setup.owner))
+
+	//then
+	assert.ErrorIs(t, errOverUnbond, bucket.ErrInsufficientBalance)
+	assert.NoError(t, errUnbond)
+	assert.ErrorIs(t, errEarly, bucket.ErrBondingPeriodNotFinished)
+	assert.NoError(t, errWithdraw)
+	assert.NoError(t, errAccount)
+	assert.Equal(t, types.NewU128(*big.NewInt(200)), account.Deposit)
+	assert.Equal(t, types.NewU128(*big.NewInt(200)), account.Bonded)
+	assert.Equal(t, types.NewU128(*big.NewInt(0)), account.UnboundedAmount)
+}
+
+func TestSimulatorPermissions(t *testing.T) {
+	//given
+	ctx := context.Background()
+	setup := createSimulator(t)
+	contract := setup.contract
+	managerId := accountId(setup.manager)
+	var granted []bucket.GrantPermissionEvent
+	_, err := contract.AddContractEventHandler(bucket.GrantPermissionEventId, func(_ pkg.ContractEventContext, raw interface{}) error {
+		granted = append(granted, *raw.(*bucket.GrantPermissionEvent))
+		return nil
+	})
+	require.NoError(t, err)
+	rate := types.NewU128(*big.NewInt(42))
+
+	//when
+	errNotAdmin := contract.AdminGrantPermission(ctx, setup.manager, managerId, SET_EXCHANGE_RATE_PERMISSION)
+	errNoPermission := contract.AccountSetUsdPerCere(ctx, setup.manager, rate)
+	errGrant := contract.AdminGrantPermission(ctx, setup.admin, managerId, SET_EXCHANGE_RATE_PERMISSION)
+	errSet := contract.AccountSetUsdPerCere(ctx, setup.manager, rate)
+	hasPermission, errHas := contract.HasPermission(managerId, SET_EXCHANGE_RATE_PERMISSION)
+	usdPerCere, errGet := contract.AccountGetUsdPerCere()
+
+	//then
+	assert.ErrorIs(t, errNotAdmin, bucket.ErrOnlySuperAdmin)
+	assert.ErrorIs(t, errNoPermission, bucket.ErrUnauthorized)
+	assert.NoError(t, errGrant)
+	assert.NoError(t, errSet)
+	assert.NoError(t, errHas)
+	assert.True(t, hasPermission)
+	assert.NoError(t, errGet)
+	assert.Equal(t, rate, usdPerCere)
+	assert.Equal(t, []bucket.GrantPermissionEvent{{AccountId: managerId, Permission: setExchangeRatePermissionIndex}}, granted)
+}
+
+func TestSimulatorParamsValidated(t *testing.T) {
+	//given
+	ctx := context.Background()
+	setup := createSimulator(t)
+	contract := setup.contract
+	clusterId, nodeKey := setup.createCluster(t, ctx)
+
+	//when
+	_, errNodeCreate := contract.NodeCreate(ctx, setup.provider, bucket.NodeKey{2}, "", 100, types.NewU128(*big.NewInt(1)))
+	errNodeSet := contract.NodeSetParams(ctx, setup.provider, nodeKey, `{"url":"node"}`)
+	_, errClusterCreate := contract.ClusterCreate(ctx, setup.manager, `{"durability":-1}`, 10)
+	errClusterSet := contract.ClusterSetParams(ctx, setup.manager, clusterId, `{"replicationFactor":-1}`)
+	_, errBucketCreate := contract.BucketCreate(ctx, setup.owner, `{"replication":-1}`, clusterId, types.OptionAccountID{})
+	_, errNodeGet := contract.NodeGet(bucket.NodeKey{2})
+
+	//then
+	for _, err := range []error{errNodeCreate, errNodeSet, errClusterCreate, errClusterSet, errBucketCreate} {
+		var paramsError *bucket.ParamsError
+		assert.ErrorAs(t, err, &paramsError)
+	}
+	assert.ErrorIs(t, errNodeGet, bucket.ErrNodeDoesNotExist)
+}
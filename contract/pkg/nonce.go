@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"sync"
+)
+
+type (
+	// nonceManager hands out sequential nonces per account so concurrent submitters from the same key don't collide.
+	// The first nonce is taken from the node, later ones are counted locally until a failure requests a resync.
+	nonceManager struct {
+		mutex    sync.Mutex
+		accounts map[string]*accountNonce
+		fetch    func(address string) (uint64, error)
+	}
+
+	accountNonce struct {
+		mutex  sync.Mutex
+		next   uint64
+		synced bool
+	}
+)
+
+func newNonceManager(fetch func(address string) (uint64, error)) *nonceManager {
+	return &nonceManager{
+		accounts: make(map[string]*accountNonce),
+		fetch:    fetch,
+	}
+}
+
+func (m *nonceManager) account(address string) *accountNonce {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	account, ok := m.accounts[address]
+	if !ok {
+		account = &accountNonce{}
+		m.accounts[address] = account
+	}
+
+	return account
+}
+
+// next reserves the next nonce of the account.
+func (m *nonceManager) next(address string) (uint64, error) {
+	account := m.account(address)
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	if !account.synced {
+		nonce, err := m.fetch(address)
+		if err != nil {
+			return 0, err
+		}
+		account.next = nonce
+		account.synced = true
+	}
+
+	nonce := account.next
+	account.next++
+
+	return nonce, nil
+}
+
+// resync drops the local counter, the next nonce is fetched from the node again. It is used after a transaction
+// failed to be created or submitted, or was dropped from the pool, leaving a gap in the nonce sequence.
+func (m *nonceManager) resync(address string) {
+	account := m.account(address)
+	account.mutex.Lock()
+	defer account.mutex.Unlock()
+
+	account.synced = false
+}
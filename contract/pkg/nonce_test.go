@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceManagerConcurrentSubmitters(t *testing.T) {
+	//given
+	fetched := 0
+	nonces := newNonceManager(func(address string) (uint64, error) {
+		fetched++
+		return 10, nil
+	})
+
+	//when
+	var mutex sync.Mutex
+	var got []uint64
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := nonces.next("alice")
+			assert.NoError(t, err)
+			mutex.Lock()
+			got = append(got, nonce)
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	//then
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	for i, nonce := range got {
+		assert.Equal(t, uint64(10+i), nonce)
+	}
+	assert.Equal(t, 1, fetched)
+}
+
+func TestNonceManagerResync(t *testing.T) {
+	//given
+	chainNonce := uint64(5)
+	nonces := newNonceManager(func(address string) (uint64, error) {
+		return chainNonce, nil
+	})
+	first, _ := nonces.next("alice")
+	second, _ := nonces.next("alice")
+	other, _ := nonces.next("bob")
+
+	//when
+	chainNonce = 6
+	nonces.resync("alice")
+	resynced, _ := nonces.next("alice")
+
+	//then
+	assert.Equal(t, uint64(5), first)
+	assert.Equal(t, uint64(6), second)
+	assert.Equal(t, uint64(5), other)
+	assert.Equal(t, uint64(6), resynced)
+}
+
+func TestNonceManagerFetchError(t *testing.T) {
+	//given
+	fail := true
+	nonces := newNonceManager(func(address string) (uint64, error) {
+		if fail {
+			return 0, errors.New("connection refused")
+		}
+		return 3, nil
+	})
+
+	//when
+	_, err := nonces.next("alice")
+	fail = false
+	nonce, errRetry := nonces.next("alice")
+
+	//then
+	assert.Error(t, err)
+	assert.NoError(t, errRetry)
+	assert.Equal(t, uint64(3), nonce)
+}
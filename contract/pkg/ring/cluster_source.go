@@ -0,0 +1,147 @@
+// Package ring follows the contract clusters as the cluster sources of the topology rings of core, e.g.
+// topology.FollowCluster(ring.CreateClusterSource(contract, clusterId)). It doesn't depend on core, the sources only
+// exchange builtin types with it.
+package ring
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	"github.com/pkg/errors"
+)
+
+type (
+	// ClusterSource is the topology.ClusterSource of core for a contract cluster.
+	ClusterSource interface {
+		// Load reads the tokens of the cluster vnodes by node key and the replication factor of the cluster.
+		Load() (map[string][]uint64, uint, error)
+		// Follow passes the changes of the cluster nodes from the contract events to the handlers until unfollow is
+		// called. The events are received once the event dispatcher of the contract is set on the blockchain client.
+		Follow(assign func(nodeKey string, tokens []uint64), removeNode func(nodeKey string), reload func() error) (unfollow func(), err error)
+	}
+
+	clusterSource struct {
+		contract  bucket.DdcBucketContract
+		clusterId bucket.ClusterId
+		mutex     sync.Mutex
+		// replicationFactor is the one of the cluster last loaded, the params set keeping it don't reload the cluster.
+		replicationFactor uint
+		loaded            bool
+	}
+)
+
+// NodeKey is the key of the node in the topology rings, the hex of its account id.
+func NodeKey(nodeKey bucket.NodeKey) string {
+	return hex.EncodeToString(nodeKey[:])
+}
+
+// VNodes returns the tokens of the cluster vnodes by node key.
+func VNodes(cluster *bucket.ClusterInfo) map[string][]uint64 {
+	vNodes := make(map[string][]uint64, len(cluster.NodesVNodes))
+	for _, node := range cluster.NodesVNodes {
+		vNodes[NodeKey(node.NodeKey)] = tokens(node.VNodes)
+	}
+
+	return vNodes
+}
+
+func CreateClusterSource(contract bucket.DdcBucketContract, clusterId bucket.ClusterId) ClusterSource {
+	return &clusterSource{contract: contract, clusterId: clusterId}
+}
+
+func (s *clusterSource) Load() (map[string][]uint64, uint, error) {
+	cluster, err := s.contract.ClusterGet(s.clusterId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	replicationFactor := cluster.ReplicationFactor()
+	s.mutex.Lock()
+	s.replicationFactor, s.loaded = replicationFactor, true
+	s.mutex.Unlock()
+
+	return VNodes(cluster), replicationFactor, nil
+}
+
+func (s *clusterSource) Follow(assign func(nodeKey string, tokens []uint64), removeNode func(nodeKey string), reload func() error) (func(), error) {
+	handlers := map[string]pkg.ContractEventHandler{
+		bucket.ClusterNodeAddedEventId: func(_ pkg.ContractEventContext, raw interface{}) error {
+			args := raw.(*bucket.ClusterNodeAddedEvent)
+			if args.ClusterId == s.clusterId {
+				assign(NodeKey(args.NodeKey), tokens(args.VNodes))
+			}
+			return nil
+		},
+		bucket.ClusterNodeRemovedEventId: func(_ pkg.ContractEventContext, raw interface{}) error {
+			args := raw.(*bucket.ClusterNodeRemovedEvent)
+			if args.ClusterId == s.clusterId {
+				removeNode(NodeKey(args.NodeKey))
+			}
+			return nil
+		},
+		bucket.ClusterNodeResetEventId: func(_ pkg.ContractEventContext, raw interface{}) error {
+			args := raw.(*bucket.ClusterNodeResetEvent)
+			if args.ClusterId == s.clusterId {
+				removeNode(NodeKey(args.NodeKey))
+				assign(NodeKey(args.NodeKey), tokens(args.VNodes))
+			}
+			return nil
+		},
+		bucket.ClusterNodeReplacedEventId: func(_ pkg.ContractEventContext, raw interface{}) error {
+			args := raw.(*bucket.ClusterNodeReplacedEvent)
+			if args.ClusterId == s.clusterId {
+				assign(NodeKey(args.NodeKey), tokens(args.VNodes))
+			}
+			return nil
+		},
+		bucket.ClusterParamsSetEventId: func(_ pkg.ContractEventContext, raw interface{}) error {
+			args := raw.(*bucket.ClusterParamsSetEvent)
+			if args.ClusterId != s.clusterId || !s.replicationFactorChanged(args.ClusterParams) {
+				return nil
+			}
+			return reload()
+		},
+	}
+
+	var unregisters []func()
+	unfollow := func() {
+		for _, unregister := range unregisters {
+			unregister()
+		}
+	}
+	for event, handler := range handlers {
+		unregister, err := s.contract.AddContractEventHandler(event, handler)
+		if err != nil {
+			unfollow()
+			return nil, errors.Wrap(err, "Unable to hook event "+event)
+		}
+		unregisters = append(unregisters, unregister)
+	}
+
+	return unfollow, nil
+}
+
+// replicationFactorChanged tells if the params set change the replication factor of the cluster last loaded, reloading
+// the cluster on the dispatch of the events only when the ring changes.
+func (s *clusterSource) replicationFactorChanged(params bucket.ClusterParams) bool {
+	var replicationFactor uint
+	if params.ReplicationFactor > 0 {
+		replicationFactor = uint(params.ReplicationFactor)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return !s.loaded || replicationFactor != s.replicationFactor
+}
+
+func tokens(vNodes []bucket.Token) []uint64 {
+	result := make([]uint64, 0, len(vNodes))
+	for _, token := range vNodes {
+		result = append(result, uint64(token))
+	}
+
+	return result
+}
@@ -0,0 +1,186 @@
+package ring
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/bucket"
+	"github.com/cerebellum-network/cere-ddc-sdk-go/contract/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// followedRing applies the changes of a cluster source to the owners of the tokens like the rings of core.
+type followedRing struct {
+	source            ClusterSource
+	owners            map[uint64]string
+	replicationFactor uint
+}
+
+func (r *followedRing) load() error {
+	vNodes, replicationFactor, err := r.source.Load()
+	if err != nil {
+		return err
+	}
+	r.owners, r.replicationFactor = owners(vNodes), replicationFactor
+	return nil
+}
+
+func (r *followedRing) assign(nodeKey string, tokens []uint64) {
+	for _, token := range tokens {
+		r.owners[token] = nodeKey
+	}
+}
+
+func (r *followedRing) removeNode(nodeKey string) {
+	for token, owner := range r.owners {
+		if owner == nodeKey {
+			delete(r.owners, token)
+		}
+	}
+}
+
+func owners(vNodes map[string][]uint64) map[uint64]string {
+	result := make(map[uint64]string)
+	for nodeKey, tokens := range vNodes {
+		for _, token := range tokens {
+			result[token] = nodeKey
+		}
+	}
+	return result
+}
+
+func keyPair(t *testing.T, uri string) signature.KeyringPair {
+	pair, err := signature.KeyringPairFromSecret(uri, 42)
+	require.NoError(t, err)
+	return pair
+}
+
+func accountId(pair signature.KeyringPair) bucket.AccountId {
+	var id bucket.AccountId
+	copy(id[:], pair.PublicKey)
+	return id
+}
+
+func TestVNodes(t *testing.T) {
+	//given
+	cluster := &bucket.ClusterInfo{
+		Cluster:     bucket.Cluster{Params: `{"replicationFactor":2}`},
+		NodesVNodes: []bucket.NodeVNodesInfo{{NodeKey: bucket.NodeKey{0xab}, VNodes: []bucket.Token{20, 10}}, {NodeKey: bucket.NodeKey{0xcd}, VNodes: []bucket.Token{15}}},
+	}
+	nodeKey1 := "ab00000000000000000000000000000000000000000000000000000000000000"
+	nodeKey2 := "cd00000000000000000000000000000000000000000000000000000000000000"
+
+	//when
+	vNodes := VNodes(cluster)
+
+	//then
+	assert.Equal(t, map[string][]uint64{nodeKey1: {20, 10}, nodeKey2: {15}}, vNodes)
+	assert.Equal(t, nodeKey1, NodeKey(bucket.NodeKey{0xab}))
+}
+
+func TestClusterSource(t *testing.T) {
+	//given
+	ctx := context.Background()
+	admin, manager, provider := keyPair(t, "//Alice"), keyPair(t, "//Bob"), keyPair(t, "//Charlie")
+	contract, err := mock.CreateDdcBucketContractSimulator("5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL", accountId(admin), mock.WithClock(func() time.Time {
+		return time.UnixMilli(1_000_000)
+	}))
+	require.NoError(t, err)
+	node1, node2 := bucket.NodeKey{1}, bucket.NodeKey{2}
+	for _, nodeKey := range []bucket.NodeKey{node1, node2} {
+		_, err = contract.NodeCreate(ctx, provider, nodeKey, `{"url":"http://node"}`, 100, types.NewU128(*big.NewInt(1)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, contract.GrantTrustedManagerPermission(ctx, provider, accountId(manager)))
+	_, err = contract.ClusterCreate(ctx, manager, `{"replicationFactor":1}`, 10)
+	require.NoError(t, err)
+	require.NoError(t, contract.ClusterAddNode(ctx, manager, 1, node1, [][]bucket.Token{{1, 2, 3}}))
+	ring := &followedRing{source: CreateClusterSource(contract, 1)}
+	require.NoError(t, ring.load())
+	unfollow, err := ring.source.Follow(ring.assign, ring.removeNode, ring.load)
+	require.NoError(t, err)
+	defer unfollow()
+
+	steps := []struct {
+		name string
+		call func() error
+	}{
+		{"added", func() error { return contract.ClusterAddNode(ctx, manager, 1, node2, [][]bucket.Token{{4}}) }},
+		{"replaced", func() error { return contract.ClusterReplaceNode(ctx, manager, 1, [][]bucket.Token{{3}}, node2) }},
+		{"reset", func() error { return contract.ClusterResetNode(ctx, manager, 1, node1, [][]bucket.Token{{5, 6}}) }},
+		{"removed", func() error { return contract.ClusterRemoveNode(ctx, manager, 1, node1) }},
+		{"params set", func() error { return contract.ClusterSetParams(ctx, manager, 1, `{"replicationFactor":2}`) }},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			//when
+			require.NoError(t, step.call())
+
+			//then
+			cluster, err := contract.ClusterGet(1)
+			require.NoError(t, err)
+			assert.Equal(t, owners(VNodes(cluster)), ring.owners)
+			assert.Equal(t, cluster.ReplicationFactor(), ring.replicationFactor)
+		})
+	}
+	assert.Equal(t, map[uint64]string{3: NodeKey(node2), 4: NodeKey(node2)}, ring.owners)
+	assert.Equal(t, uint(2), ring.replicationFactor)
+}
+
+func TestClusterSourceParamsKeepingReplicationFactor(t *testing.T) {
+	//given
+	ctx := context.Background()
+	admin, manager := keyPair(t, "//Alice"), keyPair(t, "//Bob")
+	contract, err := mock.CreateDdcBucketContractSimulator("5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL", accountId(admin))
+	require.NoError(t, err)
+	_, err = contract.ClusterCreate(ctx, manager, `{"replicationFactor":1}`, 10)
+	require.NoError(t, err)
+	ring := &followedRing{source: CreateClusterSource(contract, 1)}
+	require.NoError(t, ring.load())
+	reloads := 0
+	unfollow, err := ring.source.Follow(ring.assign, ring.removeNode, func() error {
+		reloads++
+		return ring.load()
+	})
+	require.NoError(t, err)
+	defer unfollow()
+
+	//when
+	require.NoError(t, contract.ClusterSetParams(ctx, manager, 1, `{"replicationFactor":1,"durability":11}`))
+	kept := reloads
+	require.NoError(t, contract.ClusterSetParams(ctx, manager, 1, `{"replicationFactor":3}`))
+
+	//then
+	assert.Equal(t, 0, kept)
+	assert.Equal(t, 1, reloads)
+	assert.Equal(t, uint(3), ring.replicationFactor)
+}
+
+func TestClusterSourceUnfollow(t *testing.T) {
+	//given
+	ctx := context.Background()
+	admin, manager, provider := keyPair(t, "//Alice"), keyPair(t, "//Bob"), keyPair(t, "//Charlie")
+	contract, err := mock.CreateDdcBucketContractSimulator("5GmomkEekQQ3BipMvjDCG5bXKvzwhUDdXEcQqXRWmdkNCYkL", accountId(admin))
+	require.NoError(t, err)
+	_, err = contract.NodeCreate(ctx, provider, bucket.NodeKey{1}, `{"url":"http://node"}`, 100, types.NewU128(*big.NewInt(1)))
+	require.NoError(t, err)
+	require.NoError(t, contract.GrantTrustedManagerPermission(ctx, provider, accountId(manager)))
+	_, err = contract.ClusterCreate(ctx, manager, `{"replicationFactor":1}`, 10)
+	require.NoError(t, err)
+	ring := &followedRing{source: CreateClusterSource(contract, 1)}
+	require.NoError(t, ring.load())
+	unfollow, err := ring.source.Follow(ring.assign, ring.removeNode, ring.load)
+	require.NoError(t, err)
+
+	//when
+	unfollow()
+	require.NoError(t, contract.ClusterAddNode(ctx, manager, 1, bucket.NodeKey{1}, [][]bucket.Token{{1}}))
+
+	//then
+	assert.Empty(t, ring.owners)
+}
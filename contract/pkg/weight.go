@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"encoding/json"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+)
+
+const DEFAULT_PROOF_SIZE_LIMIT uint64 = 5 * 1024 * 1024
+
+type (
+	// Weight is the gas of a contract call. ProofSize is ignored by runtimes still using Weight v1.
+	Weight struct {
+		RefTime   uint64 `json:"refTime"`
+		ProofSize uint64 `json:"proofSize"`
+	}
+
+	// WeightV2 is the SCALE layout of `Weight { ref_time, proof_size }` of pallet-contracts extrinsics.
+	WeightV2 struct {
+		RefTime   types.UCompact
+		ProofSize types.UCompact
+	}
+)
+
+// UnmarshalJSON accepts both the Weight v1 number and the Weight v2 object returned by contracts_call.
+func (w *Weight) UnmarshalJSON(data []byte) error {
+	var refTime uint64
+	if err := json.Unmarshal(data, &refTime); err == nil {
+		*w = Weight{RefTime: refTime}
+		return nil
+	}
+
+	type weight Weight
+	return json.Unmarshal(data, (*weight)(w))
+}
+
+// encode returns the extrinsic argument for the weight depending on the runtime weight version.
+func (w Weight) encode(v2 bool) interface{} {
+	if !v2 {
+		return types.NewUCompactFromUInt(w.RefTime)
+	}
+
+	return WeightV2{
+		RefTime:   types.NewUCompactFromUInt(w.RefTime),
+		ProofSize: types.NewUCompactFromUInt(w.ProofSize),
+	}
+}
+
+// rpc returns the contracts_call gas limit depending on the runtime weight version.
+func (w Weight) rpc(v2 bool) interface{} {
+	if !v2 {
+		return w.RefTime
+	}
+
+	return w
+}
+
+// isWeightV2 tells whether the gas_limit argument of Contracts.call is `Weight { ref_time, proof_size }`
+// rather than the `Compact<u64>` of Weight v1.
+func isWeightV2(meta *types.Metadata) (bool, error) {
+	if meta.Version < 14 {
+		return false, nil
+	}
+
+	lookup := meta.AsMetadataV14.EfficientLookup
+	for _, pallet := range meta.AsMetadataV14.Pallets {
+		if pallet.Name != "Contracts" || !pallet.HasCalls {
+			continue
+		}
+
+		calls, ok := lookup[pallet.Calls.Type.Int64()]
+		if !ok {
+			return false, errors.New("Contracts calls type not found")
+		}
+		for _, variant := range calls.Def.Variant.Variants {
+			if variant.Name != "call" {
+				continue
+			}
+			for _, field := range variant.Fields {
+				if field.Name != "gas_limit" {
+					continue
+				}
+				gasLimit, ok := lookup[field.Type.Int64()]
+				if !ok {
+					return false, errors.New("gas_limit type not found")
+				}
+				return gasLimit.Def.IsComposite && len(gasLimit.Def.Composite.Fields) == 2, nil
+			}
+		}
+	}
+
+	return false, errors.New("Contracts.call not found in runtime metadata")
+}
@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightEncode(t *testing.T) {
+	//given
+	weight := Weight{RefTime: 1000, ProofSize: 64}
+
+	//when
+	v1, errV1 := codec.Encode(weight.encode(false))
+	v2, errV2 := codec.Encode(weight.encode(true))
+
+	//then
+	assert.NoError(t, errV1)
+	assert.NoError(t, errV2)
+	assert.Equal(t, []byte{0xa1, 0x0f}, v1)
+	assert.Equal(t, []byte{0xa1, 0x0f, 0x01, 0x01}, v2)
+}
+
+func TestIsWeightV2(t *testing.T) {
+	tests := []struct {
+		name     string
+		gasLimit types.Si1TypeDef
+		want     bool
+	}{
+		{
+			name:     "Weight v1",
+			gasLimit: types.Si1TypeDef{IsCompact: true, Compact: types.Si1TypeDefCompact{Type: lookupId(3)}},
+			want:     false,
+		},
+		{
+			name: "Weight v2",
+			gasLimit: types.Si1TypeDef{IsComposite: true, Composite: types.Si1TypeDefComposite{Fields: []types.Si1Field{
+				{HasName: true, Name: "ref_time", Type: lookupId(4)},
+				{HasName: true, Name: "proof_size", Type: lookupId(4)},
+			}}},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//given
+			meta := &types.Metadata{Version: 14}
+			meta.AsMetadataV14.Pallets = []types.PalletMetadataV14{
+				{Name: "Contracts", HasCalls: true, Calls: types.FunctionMetadataV14{Type: lookupId(1)}},
+			}
+			meta.AsMetadataV14.EfficientLookup = map[int64]*types.Si1Type{
+				1: {Def: types.Si1TypeDef{IsVariant: true, Variant: types.Si1TypeDefVariant{Variants: []types.Si1Variant{
+					{Name: "call", Fields: []types.Si1Field{
+						{HasName: true, Name: "dest", Type: lookupId(5)},
+						{HasName: true, Name: "gas_limit", Type: lookupId(2)},
+					}},
+				}}}},
+				2: {Def: tt.gasLimit},
+			}
+
+			//when
+			weightV2, err := isWeightV2(meta)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, weightV2)
+		})
+	}
+}
+
+func lookupId(id int64) types.Si1LookupTypeID {
+	return types.NewSi1LookupTypeID(big.NewInt(id))
+}
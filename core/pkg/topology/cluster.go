@@ -0,0 +1,195 @@
+package topology
+
+import (
+	"sync"
+)
+
+type (
+	// ClusterSource is a cluster of nodes changing over time, e.g. a cluster of the DDC bucket contract. Its methods
+	// only use builtin types so that the sources don't depend on this module.
+	ClusterSource interface {
+		// Load returns the tokens of the vnodes by node key and the replication factor of the cluster.
+		Load() (map[string][]uint64, uint, error)
+		// Follow passes the changes of the cluster to the handlers until unfollow is called. assign moves the tokens
+		// to the node, removeNode drops the vnodes of the node and reload loads the whole cluster again.
+		Follow(assign func(nodeKey string, tokens []uint64), removeNode func(nodeKey string), reload func() error) (unfollow func(), err error)
+	}
+
+	// ClusterRing is the ring of a cluster kept current with the changes of the cluster instead of loading it again.
+	ClusterRing interface {
+		Ring
+		// Reload rebuilds the ring from the cluster loaded from the source.
+		Reload() error
+		// Close stops following the changes of the cluster.
+		Close()
+	}
+
+	clusterRing struct {
+		source   ClusterSource
+		ring     MutableRing
+		mutex    sync.RWMutex
+		unfollow func()
+		// loading counts the reloads in progress, the changes received meanwhile are applied again to the loaded rings.
+		loading int
+		changes []func(ring MutableRing)
+	}
+)
+
+// FollowCluster loads the ring of the cluster and follows its changes until the ring is closed. The changes are
+// followed before the cluster is loaded so that none is lost in between.
+func FollowCluster(source ClusterSource) (ClusterRing, error) {
+	r := &clusterRing{source: source}
+	unfollow, err := source.Follow(r.assign, r.removeNode, r.Reload)
+	if err != nil {
+		return nil, err
+	}
+	r.unfollow = unfollow
+
+	if err := r.Reload(); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *clusterRing) Reload() error {
+	r.mutex.Lock()
+	r.loading++
+	r.mutex.Unlock()
+
+	ring, err := r.load()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.loading--
+	if err == nil {
+		// the loaded cluster may miss the changes received while loading
+		for _, change := range r.changes {
+			change(ring)
+		}
+		r.ring = ring
+	}
+	if r.loading == 0 {
+		r.changes = nil
+	}
+
+	return err
+}
+
+func (r *clusterRing) load() (MutableRing, error) {
+	vNodes, replicationFactor, err := r.source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(NodesVNodes, 0, len(vNodes))
+	for nodeKey, tokens := range vNodes {
+		nodes = append(nodes, NodeVNodes{NodeKey: nodeKey, VNodes: tokens})
+	}
+
+	return NewTopology(nodes, replicationFactor).(MutableRing), nil
+}
+
+func (r *clusterRing) Close() {
+	if r.unfollow != nil {
+		r.unfollow()
+		r.unfollow = nil
+	}
+}
+
+func (r *clusterRing) assign(nodeKey string, tokens []uint64) {
+	r.change(func(ring MutableRing) {
+		for _, token := range tokens {
+			ring.RemoveVNode(token)
+			ring.AddVNode(nodeKey, token)
+		}
+	})
+}
+
+func (r *clusterRing) removeNode(nodeKey string) {
+	r.change(func(ring MutableRing) {
+		for _, token := range ring.Tokens(nodeKey) {
+			ring.RemoveVNode(token)
+		}
+	})
+}
+
+// change applies the change to the ring and keeps it for the rings being loaded. The changes set the vnodes of a node
+// regardless of the ring, replayed in order on a ring loaded after some of them they lead to the same ring.
+func (r *clusterRing) change(change func(ring MutableRing)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.ring == nil || r.loading > 0 {
+		r.changes = append(r.changes, change)
+	}
+	if r.ring != nil {
+		change(r.ring)
+	}
+}
+
+func (r *clusterRing) Tokens(nodeKey string) []uint64 {
+	r.mutex.RLock()
+	result := r.ring.Tokens(nodeKey)
+	r.mutex.RUnlock()
+
+	return result
+}
+
+func (r *clusterRing) Neighbours(token uint64) (VNode, VNode) {
+	r.mutex.RLock()
+	prev, next := r.ring.Neighbours(token)
+	r.mutex.RUnlock()
+
+	return prev, next
+}
+
+func (r *clusterRing) Replicas(token uint64) []VNode {
+	r.mutex.RLock()
+	result := r.ring.Replicas(token)
+	r.mutex.RUnlock()
+
+	return result
+}
+
+func (r *clusterRing) Partitions(nodeKey string) []Partition {
+	r.mutex.RLock()
+	result := r.ring.Partitions(nodeKey)
+	r.mutex.RUnlock()
+
+	return result
+}
+
+func (r *clusterRing) ExcessPartitions(nodeKey string) []Partition {
+	r.mutex.RLock()
+	result := r.ring.ExcessPartitions(nodeKey)
+	r.mutex.RUnlock()
+
+	return result
+}
+
+func (r *clusterRing) RemoveVNode(token uint64) bool {
+	r.mutex.Lock()
+	result := r.ring.RemoveVNode(token)
+	r.mutex.Unlock()
+
+	return result
+}
+
+func (r *clusterRing) VNodes() []VNode {
+	r.mutex.RLock()
+	result := r.ring.VNodes()
+	r.mutex.RUnlock()
+
+	return result
+}
+
+func (r *clusterRing) ReplicationFactor() uint {
+	r.mutex.RLock()
+	result := r.ring.ReplicationFactor()
+	r.mutex.RUnlock()
+
+	return result
+}
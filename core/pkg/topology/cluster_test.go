@@ -0,0 +1,98 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clusterSource struct {
+	vNodes            map[string][]uint64
+	replicationFactor uint
+	assign            func(nodeKey string, tokens []uint64)
+	removeNode        func(nodeKey string)
+	reload            func() error
+	unfollowed        bool
+	// loading is called during Load, before the cluster is returned
+	loading func()
+}
+
+func (s *clusterSource) Load() (map[string][]uint64, uint, error) {
+	vNodes := s.vNodes
+	if s.loading != nil {
+		s.loading()
+	}
+	return vNodes, s.replicationFactor, nil
+}
+
+func (s *clusterSource) Follow(assign func(nodeKey string, tokens []uint64), removeNode func(nodeKey string), reload func() error) (func(), error) {
+	s.assign, s.removeNode, s.reload = assign, removeNode, reload
+	return func() { s.unfollowed = true }, nil
+}
+
+func TestFollowCluster(t *testing.T) {
+	//given
+	source := &clusterSource{vNodes: map[string][]uint64{NodeKey1: {30, 10}, NodeKey2: {20}}, replicationFactor: 1}
+
+	//when
+	testSubject, err := FollowCluster(source)
+	require.NoError(t, err)
+
+	//then
+	assert.Equal(t, []VNode{{nodeKey: NodeKey1, token: 10}, {nodeKey: NodeKey2, token: 20}, {nodeKey: NodeKey1, token: 30}}, testSubject.VNodes())
+	assert.Equal(t, uint(1), testSubject.ReplicationFactor())
+}
+
+func TestFollowClusterReload(t *testing.T) {
+	//given
+	source := &clusterSource{vNodes: map[string][]uint64{NodeKey1: {10, 30}, NodeKey2: {20}}, replicationFactor: 1}
+	testSubject, err := FollowCluster(source)
+	require.NoError(t, err)
+
+	//when
+	source.vNodes, source.replicationFactor = map[string][]uint64{NodeKey1: {10}}, 2
+	errReload := source.reload()
+	reloaded := testSubject.VNodes()
+
+	//then
+	assert.NoError(t, errReload)
+	assert.Equal(t, []VNode{{nodeKey: NodeKey1, token: 10}}, reloaded)
+	assert.Equal(t, uint(2), testSubject.ReplicationFactor())
+}
+
+func TestFollowClusterAssignAndRemove(t *testing.T) {
+	//given
+	source := &clusterSource{vNodes: map[string][]uint64{NodeKey1: {10, 30}, NodeKey2: {20}}, replicationFactor: 1}
+	testSubject, err := FollowCluster(source)
+	require.NoError(t, err)
+
+	//when
+	source.assign(NodeKey3, []uint64{40, 30})
+	source.removeNode(NodeKey2)
+	testSubject.Close()
+
+	//then
+	assert.Equal(t, []VNode{{nodeKey: NodeKey1, token: 10}, {nodeKey: NodeKey3, token: 30}, {nodeKey: NodeKey3, token: 40}}, testSubject.VNodes())
+	assert.True(t, source.unfollowed)
+}
+
+func TestFollowClusterChangedWhileLoading(t *testing.T) {
+	//given
+	source := &clusterSource{vNodes: map[string][]uint64{NodeKey1: {10, 30}, NodeKey2: {20}}, replicationFactor: 1}
+	source.loading = func() {
+		source.assign(NodeKey3, []uint64{40})
+		source.removeNode(NodeKey2)
+	}
+
+	//when
+	testSubject, err := FollowCluster(source)
+	require.NoError(t, err)
+	source.vNodes = map[string][]uint64{NodeKey1: {10, 30}, NodeKey3: {40}}
+	source.loading = func() { source.assign(NodeKey2, []uint64{10}) }
+	errReload := source.reload()
+
+	//then
+	assert.NoError(t, errReload)
+	assert.Equal(t, []VNode{{nodeKey: NodeKey2, token: 10}, {nodeKey: NodeKey1, token: 30}, {nodeKey: NodeKey3, token: 40}}, testSubject.VNodes())
+}
@@ -7,13 +7,13 @@ import (
 )
 
 type ring struct {
-	ring  topology.Ring
+	ring  topology.MutableRing
 	mutex sync.RWMutex
 }
 
 func NewTopology(nodes topology.NodesVNodes, replicaFactor uint) topology.Ring {
 	return &ring{
-		ring: topology.NewTopology(nodes, replicaFactor),
+		ring: topology.NewTopology(nodes, replicaFactor).(topology.MutableRing),
 	}
 }
 
@@ -57,6 +57,14 @@ func (r *ring) ExcessPartitions(nodeKey string) []topology.Partition {
 	return result
 }
 
+func (r *ring) AddVNode(nodeKey string, token uint64) bool {
+	r.mutex.Lock()
+	result := r.ring.AddVNode(nodeKey, token)
+	r.mutex.Unlock()
+
+	return result
+}
+
 func (r *ring) RemoveVNode(token uint64) bool {
 	r.mutex.Lock()
 	result := r.ring.RemoveVNode(token)
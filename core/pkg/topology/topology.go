@@ -21,6 +21,12 @@ type (
 		ReplicationFactor() uint
 	}
 
+	// MutableRing is a Ring which also takes vnodes, the rings of NewTopology are mutable.
+	MutableRing interface {
+		Ring
+		AddVNode(nodeKey string, token uint64) bool
+	}
+
 	ring struct {
 		vNodes            []VNode
 		replicationFactor uint
@@ -134,6 +140,17 @@ func (r *ring) ExcessPartitions(nodeKey string) []Partition {
 	return result
 }
 
+func (r *ring) AddVNode(nodeKey string, token uint64) bool {
+	vNodeId := r.search(token)
+	if vNodeId < len(r.vNodes) && r.vNodes[vNodeId].Token() == token {
+		return false
+	}
+
+	r.vNodes = utils.InsertSorted(r.vNodes, vNodeId, VNode{nodeKey: nodeKey, token: token})
+
+	return true
+}
+
 func (r *ring) RemoveVNode(token uint64) bool {
 	vNodeId := r.search(token)
 	if vNodeId >= len(r.vNodes) || r.vNodes[vNodeId].Token() != token {
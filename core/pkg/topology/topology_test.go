@@ -346,3 +346,30 @@ func TestRemoveVNode(t *testing.T) {
 		})
 	}
 }
+
+func TestAddVNode(t *testing.T) {
+	tests := []struct {
+		clusterId     int
+		token         uint64
+		expectIsAdded bool
+		expected      []VNode
+	}{
+		{0, 9223372036854775806, false, []VNode{{token: 3074457345618258602, nodeKey: NodeKey1}, {token: 6148914691236517204, nodeKey: NodeKey2}, {token: 9223372036854775806, nodeKey: NodeKey1}, {token: 12297829382473034408, nodeKey: NodeKey2}, {token: 15372286728091293010, nodeKey: NodeKey1}, {token: 18446744073709551612, nodeKey: NodeKey2}}},
+		{0, 100, true, []VNode{{token: 100, nodeKey: NodeKey3}, {token: 3074457345618258602, nodeKey: NodeKey1}, {token: 6148914691236517204, nodeKey: NodeKey2}, {token: 9223372036854775806, nodeKey: NodeKey1}, {token: 12297829382473034408, nodeKey: NodeKey2}, {token: 15372286728091293010, nodeKey: NodeKey1}, {token: 18446744073709551612, nodeKey: NodeKey2}}},
+		{0, 10000000000000000000, true, []VNode{{token: 3074457345618258602, nodeKey: NodeKey1}, {token: 6148914691236517204, nodeKey: NodeKey2}, {token: 9223372036854775806, nodeKey: NodeKey1}, {token: 10000000000000000000, nodeKey: NodeKey3}, {token: 12297829382473034408, nodeKey: NodeKey2}, {token: 15372286728091293010, nodeKey: NodeKey1}, {token: 18446744073709551612, nodeKey: NodeKey2}}},
+	}
+	for _, test := range tests {
+		cluster := clusters[test.clusterId]
+		t.Run(cluster.name, func(t *testing.T) {
+			//given
+			testSubject := NewTopology(cluster.nodesVNodes, cluster.replicaFactor).(MutableRing)
+
+			//when
+			ok := testSubject.AddVNode(NodeKey3, test.token)
+
+			//then
+			assert.Equal(t, test.expectIsAdded, ok)
+			assert.Equal(t, test.expected, testSubject.VNodes())
+		})
+	}
+}
@@ -15,6 +15,17 @@ func RemoveSorted[T any](slice []T, i int) []T {
 	return append(slice[:i], slice[i+1:]...)[: len(slice)-1 : len(slice)-1]
 }
 
+func InsertSorted[T any](slice []T, i int, value T) []T {
+	if i < 0 || len(slice) < i {
+		return slice
+	}
+
+	result := make([]T, 0, len(slice)+1)
+	result = append(result, slice[:i]...)
+	result = append(result, value)
+	return append(result, slice[i:]...)
+}
+
 func IsSuccessHttpStatus(code int) bool {
 	return code >= 200 && code < 300
 }
@@ -63,6 +63,32 @@ func TestRemoveSorted(t *testing.T) {
 	}
 }
 
+func TestInsertSorted(t *testing.T) {
+	tests := []struct {
+		i        int
+		expected []byte
+	}{
+		{-1, []byte{0, 1, 2, 3}},
+		{5, []byte{0, 1, 2, 3}},
+		{0, []byte{9, 0, 1, 2, 3}},
+		{2, []byte{0, 1, 9, 2, 3}},
+		{4, []byte{0, 1, 2, 3, 9}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("index=%d", test.i), func(t *testing.T) {
+			//given
+			slice := []byte{0, 1, 2, 3}
+
+			//when
+			result := InsertSorted(slice, test.i, 9)
+
+			//then
+			assert.Equal(t, test.expected, result)
+			assert.Equal(t, []byte{0, 1, 2, 3}, slice)
+		})
+	}
+}
+
 func TestRandomInt64(t *testing.T) {
 	tests := []struct {
 		max int64
@@ -31,8 +31,12 @@ func (a *ApplicationTestSuite) TestEventHandling() {
 	buck := bucket.CreateDdcBucketContract(client, contractAddress)
 	log.Infof("Contract: %s", buck.GetContractAddress())
 
-	bucketCreatedChan := a.subscribeToBucketCreateUpdates(t, buck)
-	bucketAvailabilityUpdatedChan := a.subscribeToBucketAvailabilityChangeUpdates(t, buck)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bucketCreatedChan, err := bucket.Subscribe[bucket.BucketCreatedEvent](ctx, buck)
+	assert.NoError(t, err)
+	bucketAvailabilityUpdatedChan, err := bucket.Subscribe[bucket.BucketAvailabilityUpdatedEvent](ctx, buck)
+	assert.NoError(t, err)
 	err = client.SetEventDispatcher(contractAddress, buck.GetEventDispatcher())
 	assert.NoError(t, err)
 
@@ -43,7 +47,7 @@ func (a *ApplicationTestSuite) TestEventHandling() {
 		assert.NoError(t, err)
 		select {
 		case event := <-bucketCreatedChan:
-			bucketId = event.BucketId
+			bucketId = event.Args.BucketId
 		case <-time.After(time.Minute):
 			log.Errorf("Timeout - bucket creation")
 			t.FailNow()
@@ -55,14 +59,14 @@ func (a *ApplicationTestSuite) TestEventHandling() {
 		assert.NoError(t, err)
 
 		log.Info("Waiting for availability update event")
-		var availabilityEvent *bucket.BucketAvailabilityUpdatedEvent
+		var availabilityEvent bucket.Event[bucket.BucketAvailabilityUpdatedEvent]
 		select {
 		case availabilityEvent = <-bucketAvailabilityUpdatedChan:
 		case <-time.After(time.Minute):
 			log.Errorf("Timeout - bucket creation")
 			t.FailNow()
 		}
-		assert.True(t, availabilityEvent.PublicAvailability)
+		assert.True(t, availabilityEvent.Args.PublicAvailability)
 
 		t.Run("Check availability change in the contract", func(t *testing.T) {
 			b, err := buck.BucketGet(bucketId)
@@ -71,25 +75,3 @@ func (a *ApplicationTestSuite) TestEventHandling() {
 		})
 	})
 }
-
-func (a *ApplicationTestSuite) subscribeToBucketAvailabilityChangeUpdates(t *testing.T, buck bucket.DdcBucketContract) chan *bucket.BucketAvailabilityUpdatedEvent {
-	bucketAvailabilityUpdatedChan := make(chan *bucket.BucketAvailabilityUpdatedEvent)
-	err := buck.AddContractEventHandler(bucket.BucketAvailabilityUpdatedId, func(raw interface{}) {
-		args := raw.(*bucket.BucketAvailabilityUpdatedEvent)
-		log.Info("BucketAvailabilityUpdated args:", args)
-		bucketAvailabilityUpdatedChan <- args
-	})
-	assert.NoError(t, err)
-	return bucketAvailabilityUpdatedChan
-}
-
-func (a *ApplicationTestSuite) subscribeToBucketCreateUpdates(t *testing.T, buck bucket.DdcBucketContract) chan *bucket.BucketCreatedEvent {
-	bucketCreatedChan := make(chan *bucket.BucketCreatedEvent)
-	err := buck.AddContractEventHandler(bucket.BucketCreatedEventId, func(raw interface{}) {
-		args := raw.(*bucket.BucketCreatedEvent)
-		log.Info("BucketCreated args:", args)
-		bucketCreatedChan <- args
-	})
-	assert.NoError(t, err)
-	return bucketCreatedChan
-}